@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/daterange"
+	alertDomain "github.com/lunemec/eve-accountant/pkg/domain/alert"
+	alertDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/alert/repository"
+	balanceDomain "github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/repository"
+	namesDomain "github.com/lunemec/eve-accountant/pkg/domain/names"
+	namesDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/names/repository"
+	namesDomainExternalRepository "github.com/lunemec/eve-accountant/pkg/domain/names/repository/external/esi"
+	reportDomain "github.com/lunemec/eve-accountant/pkg/domain/report"
+	reportDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/report/repository"
+	"github.com/lunemec/eve-accountant/pkg/export"
+	accountantService "github.com/lunemec/eve-accountant/pkg/services/accountant"
+
+	"github.com/asdine/storm/v3"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [period]",
+	Short: "Export journal or report from accountant.db as CSV, JSON lines or XLSX",
+	Long: `Export journal or report from accountant.db as CSV, JSON lines or XLSX.
+
+Period is given like in Discord commands: today, last month, Q2 2024, 2024-03,
+YYYY-MM-DD YYYY-MM-DD, this month when empty. Only data synchronized by the
+bot is exported, for all corporations stored in accountant.db. Export needs
+neither auth files nor EVE app credentials, ESI is asked only for names of
+parties and items missing from the names cached by the bot, set
+--resolve_names=false to export without network, with IDs of such names only.
+
+Buyback, compare and forecast are not exported: buyback values items at live
+market prices, compare is two balance exports and forecast is a projection,
+not recorded data.
+
+Export opens accountant.db for writing to store resolved names and bolt
+allows only one process to do so, stop the bot while exporting, or export
+from Discord with "!isk export" while it runs.`,
+	Run: runExport,
+}
+
+var (
+	exportReport       string
+	exportFormat       string
+	exportOutput       string
+	exportResolveNames bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportReport, "report", "r", string(export.JournalReport), "what to export: journal, balance, by_division, by_type, by_member, daily, wallet_balance, market or krab (default journal)")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", string(export.CSV), "format of exported file: csv, jsonl or xlsx (default csv)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "path to exported file, - for standard output (default report name with dates and extension of the format)")
+	exportCmd.Flags().IntVar(&fetchWorkers, "fetch_workers", 4, "how many wallet journals to read concurrently (default 4)")
+	exportCmd.Flags().StringVar(&refTypeGroupsFile, "ref_type_groups", "", "path to YAML or JSON file grouping journal ref types, built-in grouping is used when empty")
+	exportCmd.Flags().StringVar(&timezone, "timezone", "UTC", "IANA time zone of days and months in reports, e.g. Europe/Prague (default UTC, which is EVE time)")
+	exportCmd.Flags().BoolVar(&exportResolveNames, "resolve_names", true, "resolve names missing from the DB with ESI, disable to export without network (default true)")
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	log, err := zap.NewDevelopment()
+	if err != nil {
+		fmt.Printf("error inicializing logger: %s \n", err)
+		os.Exit(1)
+	}
+	err = exportWrapper(log, args)
+	if err != nil {
+		log.Fatal("error exporting", zap.Error(err))
+	}
+}
+
+func exportWrapper(log *zap.Logger, args []string) error {
+	report, err := export.ParseReport(exportReport)
+	if err != nil {
+		return err
+	}
+	format, err := export.ParseFormat(exportFormat)
+	if err != nil {
+		return err
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return errors.Wrap(err, "error loading timezone")
+	}
+	period, err := daterange.Parse(args, time.Now().In(location))
+	if err != nil {
+		return err
+	}
+	// Running bot holds lock of the DB, fail instead of waiting forever.
+	db, err := storm.Open("accountant.db", storm.BoltOptions(0600, &bolt.Options{Timeout: 5 * time.Second}))
+	if err != nil {
+		return errors.Wrap(err, "error openning DB, stop the bot while exporting")
+	}
+	defer db.Close()
+
+	// Corporations are read from DB only, no login is needed.
+	corporations, err := repository.Corporations(db)
+	if err != nil {
+		return err
+	}
+	if len(corporations) == 0 {
+		return errors.New("no corporations synchronized in DB, run the bot first")
+	}
+	repositories := make([]balanceDomain.Repository, 0, len(corporations))
+	for _, corporationID := range corporations {
+		repositories = append(repositories, repository.NewStored(log, db, corporationID))
+	}
+
+	refTypeGroups, err := balanceDomain.NewRefTypeGroups(refTypeGroupsFile)
+	if err != nil {
+		return errors.Wrap(err, "error loading ref type groups")
+	}
+	balanceSvc := balanceDomain.NewService(fetchWorkers, refTypeGroups, repositories...)
+	namesRepository := namesDomainRepository.NewCached(db)
+	if exportResolveNames {
+		namesRepository = namesDomainRepository.New(db, namesDomainExternalRepository.New(httpClient(log)))
+	}
+	namesSvc := namesDomain.NewService(namesRepository)
+	alertSvc := alertDomain.NewService(alertDomainRepository.New(db))
+	reportSvc := reportDomain.NewService(reportDomainRepository.New(db))
+	// Buyback is not exported, its service is not needed.
+	accountantSvc := accountantService.New(balanceSvc, nil, namesSvc, alertSvc, reportSvc, entity.Amount(0), location)
+
+	table, err := accountantSvc.Export(context.Background(), report, period)
+	if err != nil {
+		if !balanceDomain.IsPartial(err) {
+			return err
+		}
+		log.Warn("some corporations are missing from the export", zap.Error(err))
+	}
+
+	output := exportOutput
+	if output == "" {
+		output = export.FileName(report, format, period.Start, period.LastDay())
+	}
+	err = writeExport(output, format, table)
+	if err != nil {
+		return err
+	}
+	if output != "-" {
+		log.Info("Exported", zap.String("report", string(report)), zap.Int("rows", len(table.Rows)), zap.String("file", output))
+	}
+	return nil
+}
+
+// writeExport writes the table to file at path, or to standard output for -.
+func writeExport(path string, format export.Format, table *export.Table) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return errors.Wrapf(err, "error creating file: %s", path)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	err := export.Write(w, format, table)
+	if err != nil {
+		return errors.Wrap(err, "error writing export")
+	}
+	err = w.Flush()
+	if err != nil {
+		return errors.Wrap(err, "error writing export")
+	}
+	return nil
+}
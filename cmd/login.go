@@ -43,7 +43,7 @@ func runLogin(cmd *cobra.Command, args []string) {
 	}
 
 	doneChan := make(chan struct{})
-	signalChan := make(chan os.Signal)
+	signalChan := make(chan os.Signal, 1)
 	// Notify signalChan on SIGINT and SIGTERM.
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
@@ -53,7 +53,7 @@ func runLogin(cmd *cobra.Command, args []string) {
 			panic(fmt.Sprintf("unable to delete file: %s please remove it by hand", authfile))
 		}
 	}
-	httpClientInstance := httpClient()
+	httpClientInstance := httpClient(logger)
 
 	authRepository := authRepository.NewFileRepository(authfile)
 	handler := httpHandler.New(
@@ -6,9 +6,12 @@ import (
 	"os"
 	"time"
 
+	esiTransport "github.com/lunemec/eve-accountant/pkg/transport"
+
 	"github.com/gregjones/httpcache"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -36,14 +39,20 @@ var (
 var eveScopes = []string{
 	"publicData",
 	"esi-corporations.read_divisions.v1",
-	"esi-wallet.read_corporation_wallets.v1",
+	"esi-wallet.read_corporation_wallets.v1", // Wallet journal and market transactions.
+	"esi-contracts.read_corporation_contracts.v1",
 }
 
-func httpClient() *http.Client {
+func httpClient(log *zap.Logger) *http.Client {
 	transport := httpcache.NewTransport(httpcache.NewMemoryCache())
-	transport.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	transport.Transport = esiTransport.New(log, &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 10 * time.Second,
+	})
 	client := http.Client{
-		Timeout:   10 * time.Second,
+		// Each attempt is limited by the transport, this covers retries and
+		// waiting for ESI error limit reset.
+		Timeout:   5 * time.Minute,
 		Transport: transport,
 	}
 	return &client
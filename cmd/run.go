@@ -1,18 +1,37 @@
 package cmd
 
 import (
+	"context"
+	"expvar"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Time zones are available even without system tzdata.
 
+	"github.com/lunemec/eve-accountant/pkg/chart"
+	alertDomain "github.com/lunemec/eve-accountant/pkg/domain/alert"
+	alertDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/alert/repository"
 	balanceDomain "github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/repository"
 	balanceDomainExternalRepository "github.com/lunemec/eve-accountant/pkg/domain/balance/repository/external/esi"
+	buybackDomain "github.com/lunemec/eve-accountant/pkg/domain/buyback"
+	buybackDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/buyback/repository"
+	buybackDomainExternalRepository "github.com/lunemec/eve-accountant/pkg/domain/buyback/repository/external/esi"
+	namesDomain "github.com/lunemec/eve-accountant/pkg/domain/names"
+	namesDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/names/repository"
+	namesDomainExternalRepository "github.com/lunemec/eve-accountant/pkg/domain/names/repository/external/esi"
+	reportDomain "github.com/lunemec/eve-accountant/pkg/domain/report"
+	reportDomainRepository "github.com/lunemec/eve-accountant/pkg/domain/report/repository"
 	discordHandler "github.com/lunemec/eve-accountant/pkg/handlers/discord"
 	notifierHandler "github.com/lunemec/eve-accountant/pkg/handlers/notifier"
+	reloaderHandler "github.com/lunemec/eve-accountant/pkg/handlers/reloader"
+	synchronizerHandler "github.com/lunemec/eve-accountant/pkg/handlers/synchronizer"
+	"github.com/lunemec/eve-accountant/pkg/notify"
 	accountantService "github.com/lunemec/eve-accountant/pkg/services/accountant"
 	authRepository "github.com/lunemec/eve-bot-pkg/repositories/auth"
 	authService "github.com/lunemec/eve-bot-pkg/services/auth"
@@ -36,11 +55,32 @@ var (
 	checkInterval   time.Duration
 	notifyInterval  time.Duration
 	notifyThreshold float64
+	forecastWarning bool
+	anomalyAlerts   bool
+	fetchWorkers    int
 
 	discordChannelID string
 	discordAuthToken string
+	discordGuildID   string
+	prefixCommands   bool
 
 	repositoryFile string
+
+	metricsAddr string
+
+	buybackPriceSource string
+	chartRendererKind  string
+
+	refTypeGroupsFile          string
+	refTypeGroupsCheckInterval time.Duration
+	alertRulesCheckInterval    time.Duration
+	reportsCheckInterval       time.Duration
+
+	timezone string
+
+	alertRulesFile string
+	notifiersFile  string
+	reportsFile    string
 )
 
 func init() {
@@ -51,10 +91,27 @@ func init() {
 	runCmd.Flags().StringVar(&eveSSOSecret, "eve_sso_secret", "", "EVE APP SSO secret")
 	runCmd.Flags().StringVar(&discordChannelID, "discord_channel_id", "", "ID of discord channel")
 	runCmd.Flags().StringVar(&discordAuthToken, "discord_auth_token", "", "Auth token for discord")
+	runCmd.Flags().StringVar(&discordGuildID, "discord_guild_id", "", "ID of discord server to register slash commands in, registered globally when empty (global commands take up to an hour to show up)")
+	runCmd.Flags().BoolVar(&prefixCommands, "prefix_commands", true, "respond to legacy !isk and !help messages, requires Message Content intent (default true)")
 	runCmd.Flags().DurationVar(&checkInterval, "check_interval", 30*time.Minute, "how often to check EVE ESI API (default 30min)")
-	runCmd.Flags().DurationVar(&notifyInterval, "notify_interval", 24*time.Hour, "how often to spam Discord (default 24H)")
+	runCmd.Flags().DurationVar(&notifyInterval, "notify_interval", 24*time.Hour, "how often to repeat monthly balance and forecast alerts until acknowledged (default 24H)")
+	runCmd.Flags().BoolVar(&forecastWarning, "forecast_warning", true, "notify when balance is projected to end the month below notify_threshold (default true)")
+	runCmd.Flags().BoolVar(&anomalyAlerts, "anomaly_alerts", true, "alert unusual journal records: large withdrawals, payments to new parties and new ref types (default true)")
+	runCmd.Flags().IntVar(&fetchWorkers, "fetch_workers", 4, "how many wallet journals to fetch from EVE ESI API concurrently (default 4)")
 	runCmd.Flags().Float64Var(&notifyThreshold, "notify_threshold", 1000000000, "balance under which to notify (default 1 000 000 000 ISK)")
 
+	runCmd.Flags().StringVar(&buybackPriceSource, "buyback_price_source", buybackDomainExternalRepository.AveragePriceSource, "ESI market price used to value buyback items, average or adjusted")
+	runCmd.Flags().StringVar(&chartRendererKind, "chart_renderer", chart.LocalRenderer, "how to render charts, local or quickchart (sends chart data to quickchart.io)")
+	runCmd.Flags().StringVar(&refTypeGroupsFile, "ref_type_groups", "", "path to YAML or JSON file grouping journal ref types, built-in grouping is used when empty")
+	runCmd.Flags().StringVar(&alertRulesFile, "alert_rules", "", "path to YAML or JSON file with alert rules (see alert_rules.example.yaml), reloaded when the file changes, no rules when empty")
+	runCmd.Flags().StringVar(&notifiersFile, "notifiers", "", "path to YAML or JSON file with notifiers (Discord channels, webhooks, Slack, Matrix, SMTP) and routes of notifications to them (see notifiers.example.yaml), all go to discord_channel_id when empty")
+	runCmd.Flags().StringVar(&reportsFile, "reports", "", "path to YAML or JSON file with reports sent on cron schedule (see reports.example.yaml), reloaded when the file changes, no reports when empty")
+	runCmd.Flags().DurationVar(&refTypeGroupsCheckInterval, "ref_type_groups_check_interval", time.Minute, "how often to check ref type groups file for changes (default 1min)")
+	runCmd.Flags().DurationVar(&alertRulesCheckInterval, "alert_rules_check_interval", time.Minute, "how often to check alert rules file for changes (default 1min)")
+	runCmd.Flags().DurationVar(&reportsCheckInterval, "reports_check_interval", time.Minute, "how often to check reports file for changes (default 1min)")
+	runCmd.Flags().StringVar(&timezone, "timezone", "UTC", "IANA time zone of days and months in reports, e.g. Europe/Prague (default UTC, which is EVE time)")
+	runCmd.Flags().StringVar(&metricsAddr, "metrics_addr", "", "address where to serve expvar metrics at /debug/vars, disabled when empty")
+
 	must(runCmd.MarkFlagRequired("session_key"))
 	must(runCmd.MarkFlagRequired("eve_client_id"))
 	must(runCmd.MarkFlagRequired("eve_sso_secret"))
@@ -76,9 +133,13 @@ func runBot(cmd *cobra.Command, args []string) {
 }
 
 func runWrapper(log *zap.Logger, cmd *cobra.Command, args []string) error {
-	client := httpClient()
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return errors.Wrap(err, "error loading timezone")
+	}
+	client := httpClient(log)
 
-	signalChan := make(chan os.Signal)
+	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
 	db, err := storm.Open("accountant.db")
@@ -88,8 +149,10 @@ func runWrapper(log *zap.Logger, cmd *cobra.Command, args []string) error {
 	defer db.Close()
 
 	var (
-		authServices    []authService.Service
-		esiRepositories []balanceDomain.Repository
+		authServices             []authService.Service
+		esiRepositories          []balanceDomain.Repository
+		buybackRepositories      []buybackDomain.Repository
+		synchronizedRepositories []synchronizerHandler.Repository
 	)
 	for _, authfile := range authfiles {
 		authRepository := authRepository.NewFileRepository(authfile)
@@ -112,7 +175,17 @@ func runWrapper(log *zap.Logger, cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return errors.Wrapf(err, "error initializing ESI repository from: %s", authfile)
 		}
-		esiRepositories = append(esiRepositories, repository.New(db, esiRepository))
+		persistentRepository := repository.New(log, db, esiRepository)
+		esiRepositories = append(esiRepositories, persistentRepository)
+		synchronizedRepositories = append(synchronizedRepositories, persistentRepository)
+
+		buybackRepository := buybackDomainRepository.New(
+			log,
+			db,
+			buybackDomainExternalRepository.New(client, authService, esiRepository.CorporationID()),
+		)
+		buybackRepositories = append(buybackRepositories, buybackRepository)
+		synchronizedRepositories = append(synchronizedRepositories, buybackRepository)
 	}
 	defer closeAuth(log, authServices)
 
@@ -120,30 +193,109 @@ func runWrapper(log *zap.Logger, cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return errors.Wrap(err, "error inicializing discord client")
 	}
+	if prefixCommands {
+		discord.Identify.Intents |= discordgo.IntentMessageContent
+	}
 	err = discord.Open()
 	if err != nil {
 		return errors.Wrap(err, "unable to connect to discord")
 	}
 	var t tomb.Tomb
 
-	balanceSvc := balanceDomain.NewService(esiRepositories...)
-	accountantSvc := accountantService.New(balanceSvc, entity.Amount(notifyThreshold))
+	refTypeGroups, err := balanceDomain.NewRefTypeGroups(refTypeGroupsFile)
+	if err != nil {
+		return errors.Wrap(err, "error loading ref type groups")
+	}
+	balanceSvc := balanceDomain.NewService(fetchWorkers, refTypeGroups, esiRepositories...)
+	priceSource, err := buybackDomainExternalRepository.NewPriceSource(client, buybackPriceSource)
+	if err != nil {
+		return errors.Wrap(err, "error initializing buyback price source")
+	}
+	chartRenderer, err := chart.NewRenderer(chartRendererKind)
+	if err != nil {
+		return errors.Wrap(err, "error initializing chart renderer")
+	}
+	buybackSvc := buybackDomain.NewService(balanceSvc, priceSource, buybackRepositories...)
+	namesSvc := namesDomain.NewService(namesDomainRepository.New(db, namesDomainExternalRepository.New(client)))
+	alertRules, err := alertDomain.NewRules(alertRulesFile)
+	if err != nil {
+		return errors.Wrap(err, "error loading alert rules")
+	}
+	reports, err := notifierHandler.NewReports(reportsFile)
+	if err != nil {
+		return errors.Wrap(err, "error loading reports")
+	}
+	alertSvc := alertDomain.NewService(alertDomainRepository.New(db))
+	reportSvc := reportDomain.NewService(reportDomainRepository.New(db))
+	accountantSvc := accountantService.New(balanceSvc, buybackSvc, namesSvc, alertSvc, reportSvc, entity.Amount(notifyThreshold), location)
 	discordHandler := discordHandler.New(
 		t.Context(nil),
 		log,
 		discord,
-		discordChannelID,
+		discordGuildID,
+		prefixCommands,
 		accountantSvc,
+		chartRenderer,
+		location,
+	)
+	synchronizerHandler := synchronizerHandler.New(
+		t.Context(nil),
+		log,
+		checkInterval,
+		synchronizedRepositories...,
 	)
+	notifier, err := notify.Load(log, notifiersFile, discord, discordChannelID, &http.Client{Timeout: 30 * time.Second})
+	if err != nil {
+		return errors.Wrap(err, "error loading notifiers")
+	}
+	var forecastMessage func(context.Context, *aggregate.Forecast) notify.Message
+	if forecastWarning {
+		forecastMessage = discordHandler.ForecastBelowThresholdMessage
+	}
+	var anomalyMessage func(context.Context, aggregate.Anomaly) (notify.Message, error)
+	if anomalyAlerts {
+		anomalyMessage = discordHandler.AnomalyMessage
+	}
 	notifierHandler := notifierHandler.New(
 		t.Context(nil),
 		log,
 		checkInterval,
 		notifyInterval,
 		accountantSvc,
+		location,
+		notifier,
 		discordHandler.MonthlyBalanceBelowThresholdMessage,
+		forecastMessage,
+		anomalyMessage,
+		alertRules,
+		discordHandler.AlertMessage,
+		discordHandler.ResolvedMessage,
+		reports,
+		discordHandler.ReportMessages,
+	)
+	reportsReloaderHandler := reloaderHandler.New(
+		t.Context(nil),
+		log,
+		reportsCheckInterval,
+		reports,
+	)
+	alertRulesReloaderHandler := reloaderHandler.New(
+		t.Context(nil),
+		log,
+		alertRulesCheckInterval,
+		alertRules,
+	)
+	reloaderHandler := reloaderHandler.New(
+		t.Context(nil),
+		log,
+		refTypeGroupsCheckInterval,
+		refTypeGroups,
 	)
 
+	t.Go(func() error {
+		synchronizerHandler.Start()
+		return nil
+	})
 	t.Go(func() error {
 		discordHandler.Start()
 		return nil
@@ -152,6 +304,39 @@ func runWrapper(log *zap.Logger, cmd *cobra.Command, args []string) error {
 		notifierHandler.Start()
 		return nil
 	})
+	t.Go(func() error {
+		reloaderHandler.Start()
+		return nil
+	})
+	t.Go(func() error {
+		alertRulesReloaderHandler.Start()
+		return nil
+	})
+	t.Go(func() error {
+		reportsReloaderHandler.Start()
+		return nil
+	})
+
+	if metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/debug/vars", expvar.Handler())
+		metricsServer := &http.Server{
+			Addr:    metricsAddr,
+			Handler: mux,
+		}
+		t.Go(func() error {
+			<-t.Dying()
+			return metricsServer.Close()
+		})
+		t.Go(func() error {
+			log.Info("Serving metrics", zap.String("addr", metricsAddr))
+			err := metricsServer.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				return errors.Wrap(err, "error serving metrics")
+			}
+			return nil
+		})
+	}
 
 	select {
 	case <-t.Dying():
@@ -0,0 +1,213 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+var (
+	backgroundColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	textColor       = color.RGBA{0x44, 0x44, 0x44, 0xff}
+	gridColor       = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	axisColor       = color.RGBA{0x99, 0x99, 0x99, 0xff}
+
+	// palette colors series in order, it repeats for more series.
+	palette = []color.RGBA{
+		{0x36, 0xa2, 0xeb, 0xff},
+		{0xff, 0x63, 0x84, 0xff},
+		{0x4b, 0xc0, 0xc0, 0xff},
+		{0xff, 0x9f, 0x40, 0xff},
+		{0x99, 0x66, 0xff, 0xff},
+		{0xff, 0xcd, 0x56, 0xff},
+		{0x2e, 0x7d, 0x32, 0xff},
+		{0x8d, 0x6e, 0x63, 0xff},
+		{0xc9, 0xcb, 0xcf, 0xff},
+		{0x00, 0x83, 0x8f, 0xff},
+		{0xad, 0x14, 0x57, 0xff},
+		{0x55, 0x8b, 0x2f, 0xff},
+	}
+)
+
+func seriesColor(i int) color.RGBA {
+	return palette[i%len(palette)]
+}
+
+var (
+	fontOnce sync.Once
+	fontErr  error
+	goFont   *opentype.Font
+)
+
+// faces holds font faces of one canvas, faces are not safe for concurrent use.
+type faces struct {
+	title font.Face
+	label font.Face
+}
+
+func newFaces() (*faces, error) {
+	fontOnce.Do(func() {
+		goFont, fontErr = opentype.Parse(goregular.TTF)
+	})
+	if fontErr != nil {
+		return nil, errors.Wrap(fontErr, "error parsing font")
+	}
+	title, err := opentype.NewFace(goFont, &opentype.FaceOptions{Size: 28, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating title font face")
+	}
+	label, err := opentype.NewFace(goFont, &opentype.FaceOptions{Size: 16, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating label font face")
+	}
+	return &faces{
+		title: title,
+		label: label,
+	}, nil
+}
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+	alignRight
+)
+
+// canvas is an image with drawing primitives used by charts.
+type canvas struct {
+	img   *image.RGBA
+	faces *faces
+}
+
+func newCanvas(width, height int) (*canvas, error) {
+	faces, err := newFaces()
+	if err != nil {
+		return nil, err
+	}
+	c := &canvas{
+		img:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		faces: faces,
+	}
+	c.fillRect(c.img.Bounds(), backgroundColor)
+	return c, nil
+}
+
+func (c *canvas) width() int {
+	return c.img.Bounds().Dx()
+}
+
+func (c *canvas) height() int {
+	return c.img.Bounds().Dy()
+}
+
+func (c *canvas) fillRect(r image.Rectangle, col color.Color) {
+	draw.Draw(c.img, r.Canon(), image.NewUniform(col), image.Point{}, draw.Over)
+}
+
+// polygon fills anti-aliased polygon, the rasterizer only covers bounding box
+// of the points.
+func (c *canvas) polygon(points [][2]float64, col color.Color) {
+	if len(points) < 3 {
+		return
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range points {
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+	bounds := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1)
+	if bounds.Empty() {
+		return
+	}
+
+	z := vector.NewRasterizer(bounds.Dx(), bounds.Dy())
+	z.MoveTo(float32(points[0][0]-float64(bounds.Min.X)), float32(points[0][1]-float64(bounds.Min.Y)))
+	for _, p := range points[1:] {
+		z.LineTo(float32(p[0]-float64(bounds.Min.X)), float32(p[1]-float64(bounds.Min.Y)))
+	}
+	z.ClosePath()
+	z.Draw(c.img, bounds, image.NewUniform(col), image.Point{})
+}
+
+// line draws segment of given width.
+func (c *canvas) line(x0, y0, x1, y1, width float64, col color.Color) {
+	length := math.Hypot(x1-x0, y1-y0)
+	if length == 0 {
+		return
+	}
+	// Normal vector scaled to half of the width.
+	nx := -(y1 - y0) / length * width / 2
+	ny := (x1 - x0) / length * width / 2
+	c.polygon([][2]float64{
+		{x0 + nx, y0 + ny},
+		{x1 + nx, y1 + ny},
+		{x1 - nx, y1 - ny},
+		{x0 - nx, y0 - ny},
+	}, col)
+}
+
+// circle fills circle centered at x, y.
+func (c *canvas) circle(x, y, radius float64, col color.Color) {
+	c.wedge(x, y, radius, 0, 2*math.Pi, col)
+}
+
+// wedge fills pie slice between angles in radians, zero is at 12 o'clock and
+// angles grow clockwise.
+func (c *canvas) wedge(x, y, radius, from, to float64, col color.Color) {
+	var points [][2]float64
+	full := to-from >= 2*math.Pi
+	if !full {
+		points = append(points, [2]float64{x, y})
+	}
+	steps := int(math.Ceil((to - from) / (math.Pi / 180)))
+	if steps < 2 {
+		steps = 2
+	}
+	for i := 0; i <= steps; i++ {
+		angle := from + (to-from)*float64(i)/float64(steps)
+		points = append(points, [2]float64{
+			x + radius*math.Sin(angle),
+			y - radius*math.Cos(angle),
+		})
+	}
+	c.polygon(points, col)
+}
+
+// textWidth returns width of s in pixels.
+func (c *canvas) textWidth(face font.Face, s string) int {
+	return font.MeasureString(face, s).Ceil()
+}
+
+// text draws s with baseline at y, x is left, center or right edge of the text
+// depending on align.
+func (c *canvas) text(face font.Face, x, y int, s string, a align, col color.Color) {
+	switch a {
+	case alignCenter:
+		x -= c.textWidth(face, s) / 2
+	case alignRight:
+		x -= c.textWidth(face, s)
+	}
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+// lineHeight returns height of one line of text of the face.
+func lineHeight(face font.Face) int {
+	return face.Metrics().Height.Ceil()
+}
@@ -0,0 +1,60 @@
+// Package chart renders balance charts, either locally into PNG images or with
+// external quickchart.io service.
+package chart
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// LocalRenderer draws charts in-process into PNG images.
+	LocalRenderer = "local"
+	// QuickchartRenderer sends chart data to quickchart.io and returns its URL.
+	QuickchartRenderer = "quickchart"
+)
+
+// Series is one named line or bar segment, it has one value for each label.
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// LineChart plots every series as a line over labels.
+type LineChart struct {
+	Title  string
+	Labels []string
+	Series []Series
+}
+
+// StackedBarChart stacks series into one bar per label, positive values are
+// stacked above the axis and negative values below it.
+type StackedBarChart struct {
+	Title  string
+	Labels []string
+	Series []Series
+}
+
+// Image is a rendered chart, either PNG data to upload or URL of an image
+// rendered by external service.
+type Image struct {
+	PNG []byte
+	URL string
+}
+
+// Renderer draws charts into images.
+type Renderer interface {
+	Line(chart LineChart) (*Image, error)
+	StackedBar(chart StackedBarChart) (*Image, error)
+}
+
+// NewRenderer returns renderer of given kind, LocalRenderer or QuickchartRenderer.
+func NewRenderer(kind string) (Renderer, error) {
+	switch kind {
+	case LocalRenderer:
+		return NewPNGRenderer(defaultWidth, defaultHeight), nil
+	case QuickchartRenderer:
+		return NewQuickchartRenderer(defaultWidth, defaultHeight), nil
+	default:
+		return nil, errors.Errorf("unknown chart renderer: %s, use %s or %s", kind, LocalRenderer, QuickchartRenderer)
+	}
+}
@@ -0,0 +1,359 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultWidth  = 1280
+	defaultHeight = 720
+
+	margin      = 20
+	legendBox   = 14
+	legendGap   = 24
+	yTicksCount = 6
+)
+
+type pngRenderer struct {
+	width  int
+	height int
+}
+
+// NewPNGRenderer returns renderer drawing charts into PNG images of given size.
+func NewPNGRenderer(width, height int) *pngRenderer {
+	return &pngRenderer{
+		width:  width,
+		height: height,
+	}
+}
+
+func (r *pngRenderer) Line(chart LineChart) (*Image, error) {
+	c, err := newCanvas(r.width, r.height)
+	if err != nil {
+		return nil, err
+	}
+	top := c.title(chart.Title)
+	top = c.legend(top, seriesNames(chart.Series))
+
+	min, max := valuesRange(chart.Series)
+	plot := c.axes(top, chart.Labels, min, max, false)
+	for i, series := range chart.Series {
+		col := seriesColor(i)
+		var prevX, prevY float64
+		for j, value := range series.Values {
+			if j >= len(chart.Labels) {
+				break
+			}
+			x, y := plot.pointX(j), plot.y(value)
+			if j > 0 {
+				c.line(prevX, prevY, x, y, 3, col)
+			}
+			c.circle(x, y, 3, col)
+			prevX, prevY = x, y
+		}
+	}
+	return encode(c)
+}
+
+func (r *pngRenderer) StackedBar(chart StackedBarChart) (*Image, error) {
+	c, err := newCanvas(r.width, r.height)
+	if err != nil {
+		return nil, err
+	}
+	top := c.title(chart.Title)
+	top = c.legend(top, seriesNames(chart.Series))
+
+	min, max := stackedRange(chart.Labels, chart.Series)
+	plot := c.axes(top, chart.Labels, min, max, true)
+	barWidth := plot.slot() * 0.7
+	for j := range chart.Labels {
+		var positive, negative float64
+		x := plot.barX(j) - barWidth/2
+		for i, series := range chart.Series {
+			if j >= len(series.Values) {
+				continue
+			}
+			value := series.Values[j]
+			base := &positive
+			if value < 0 {
+				base = &negative
+			}
+			y0, y1 := plot.y(*base), plot.y(*base+value)
+			*base += value
+			c.fillRect(image.Rect(int(math.Round(x)), int(math.Round(y0)), int(math.Round(x+barWidth)), int(math.Round(y1))), seriesColor(i))
+		}
+	}
+	// Zero line is drawn again over the bars.
+	zero := int(math.Round(plot.y(0)))
+	c.fillRect(image.Rect(plot.rect.Min.X, zero, plot.rect.Max.X, zero+1), axisColor)
+	return encode(c)
+}
+
+func encode(c *canvas) (*Image, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, c.img)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding chart to PNG")
+	}
+	return &Image{PNG: buf.Bytes()}, nil
+}
+
+// title draws chart title and returns y where the rest of the chart starts.
+func (c *canvas) title(title string) int {
+	if title == "" {
+		return margin
+	}
+	y := margin + lineHeight(c.faces.title)
+	c.text(c.faces.title, c.width()/2, y, title, alignCenter, textColor)
+	return y + margin/2
+}
+
+// legend draws names of series in rows and returns y below the legend.
+func (c *canvas) legend(top int, names []string) int {
+	if len(names) == 0 {
+		return top
+	}
+	var (
+		height = lineHeight(c.faces.label)
+		x      = margin
+		y      = top + height
+	)
+	for i, name := range names {
+		width := legendBox + 6 + c.textWidth(c.faces.label, name)
+		if x+width > c.width()-margin && x > margin {
+			x = margin
+			y += height + 6
+		}
+		c.fillRect(image.Rect(x, y-legendBox, x+legendBox, y), seriesColor(i))
+		c.text(c.faces.label, x+legendBox+6, y, name, alignLeft, textColor)
+		x += width + legendGap
+	}
+	return y + margin
+}
+
+// plot maps values and label indexes into pixels of the plot area.
+type plot struct {
+	rect     image.Rectangle
+	labels   int
+	min, max float64
+}
+
+func (p plot) y(value float64) float64 {
+	return float64(p.rect.Max.Y) - (value-p.min)/(p.max-p.min)*float64(p.rect.Dy())
+}
+
+// slot returns width reserved for one label.
+func (p plot) slot() float64 {
+	return float64(p.rect.Dx()) / float64(p.labels)
+}
+
+// pointX returns x of line point, first and last points are at the edges.
+func (p plot) pointX(i int) float64 {
+	if p.labels < 2 {
+		return float64(p.rect.Min.X) + float64(p.rect.Dx())/2
+	}
+	return float64(p.rect.Min.X) + float64(i)*float64(p.rect.Dx())/float64(p.labels-1)
+}
+
+// barX returns center of the bar slot.
+func (p plot) barX(i int) float64 {
+	return float64(p.rect.Min.X) + (float64(i)+0.5)*p.slot()
+}
+
+// axes draws grid, y axis values and x axis labels and returns the plot area.
+func (c *canvas) axes(top int, labels []string, min, max float64, bars bool) plot {
+	ticks := niceTicks(min, max, yTicksCount)
+	tickLabels := make([]string, len(ticks))
+	var tickWidth int
+	for i, tick := range ticks {
+		tickLabels[i] = FormatValue(tick)
+		if w := c.textWidth(c.faces.label, tickLabels[i]); w > tickWidth {
+			tickWidth = w
+		}
+	}
+
+	var labelWidth int
+	for _, label := range labels {
+		if w := c.textWidth(c.faces.label, label); w > labelWidth {
+			labelWidth = w
+		}
+	}
+
+	// Labels centered at the edges of the plot must fit into the image.
+	height := lineHeight(c.faces.label)
+	left := margin + tickWidth + 10
+	if min := margin + labelWidth/2; left < min {
+		left = min
+	}
+	p := plot{
+		rect:   image.Rect(left, top+height/2, c.width()-margin-labelWidth/2, c.height()-margin-2*height),
+		labels: len(labels),
+		min:    ticks[0],
+		max:    ticks[len(ticks)-1],
+	}
+	if p.labels == 0 {
+		p.labels = 1
+	}
+
+	for i, tick := range ticks {
+		y := int(math.Round(p.y(tick)))
+		col := gridColor
+		if tick == 0 {
+			col = axisColor
+		}
+		c.fillRect(image.Rect(p.rect.Min.X, y, p.rect.Max.X, y+1), col)
+		c.text(c.faces.label, p.rect.Min.X-10, y+height/3, tickLabels[i], alignRight, textColor)
+	}
+
+	// Show only every n-th label so they do not overlap.
+	step := 1
+	if slot := p.slot(); slot > 0 {
+		step = int(math.Ceil(float64(labelWidth+10) / slot))
+		if step < 1 {
+			step = 1
+		}
+	}
+	for i := 0; i < len(labels); i += step {
+		x := p.pointX(i)
+		if bars {
+			x = p.barX(i)
+		}
+		c.fillRect(image.Rect(int(x), p.rect.Max.Y, int(x)+1, p.rect.Max.Y+5), axisColor)
+		c.text(c.faces.label, int(x), p.rect.Max.Y+5+height, labels[i], alignCenter, textColor)
+	}
+	c.fillRect(image.Rect(p.rect.Min.X, p.rect.Min.Y, p.rect.Min.X+1, p.rect.Max.Y), axisColor)
+	return p
+}
+
+func seriesNames(series []Series) []string {
+	names := make([]string, 0, len(series))
+	for _, s := range series {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// valuesRange returns smallest and largest value of all series.
+func valuesRange(series []Series) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, value := range s.Values {
+			min, max = math.Min(min, value), math.Max(max, value)
+		}
+	}
+	if math.IsInf(min, 1) {
+		return 0, 0
+	}
+	return min, max
+}
+
+// stackedRange returns lowest sum of negative and highest sum of positive
+// values of any label.
+func stackedRange(labels []string, series []Series) (float64, float64) {
+	var min, max float64
+	for j := range labels {
+		var positive, negative float64
+		for _, s := range series {
+			if j >= len(s.Values) {
+				continue
+			}
+			if s.Values[j] > 0 {
+				positive += s.Values[j]
+			} else {
+				negative += s.Values[j]
+			}
+		}
+		min, max = math.Min(min, negative), math.Max(max, positive)
+	}
+	return min, max
+}
+
+// niceTicks returns evenly spaced round values covering min and max.
+func niceTicks(min, max float64, count int) []float64 {
+	if min == max {
+		switch {
+		case min > 0:
+			min = 0
+		case min < 0:
+			max = 0
+		default:
+			max = 1
+		}
+	}
+	step := niceNumber((max-min)/float64(count-1), true)
+	start := math.Floor(min/step) * step
+	end := math.Ceil(max/step) * step
+
+	var ticks []float64
+	for i := 0; start+float64(i)*step <= end+step/2; i++ {
+		tick := start + float64(i)*step
+		// Avoid -0 and float errors around zero.
+		if math.Abs(tick) < step/1e6 {
+			tick = 0
+		}
+		ticks = append(ticks, tick)
+	}
+	return ticks
+}
+
+// niceNumber returns 1, 2, 5 or 10 times power of ten close to x.
+func niceNumber(x float64, round bool) float64 {
+	exponent := math.Floor(math.Log10(x))
+	fraction := x / math.Pow(10, exponent)
+
+	var nice float64
+	switch {
+	case round && fraction < 1.5:
+		nice = 1
+	case round && fraction < 3:
+		nice = 2
+	case round && fraction < 7:
+		nice = 5
+	case round:
+		nice = 10
+	case fraction <= 1:
+		nice = 1
+	case fraction <= 2:
+		nice = 2
+	case fraction <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exponent)
+}
+
+// FormatValue formats ISK amount with k, M, B or T suffix.
+func FormatValue(value float64) string {
+	abs := math.Abs(value)
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{
+		{1e12, "T"},
+		{1e9, "B"},
+		{1e6, "M"},
+		{1e3, "k"},
+	} {
+		if abs >= unit.size {
+			return trimZeros(fmt.Sprintf("%.2f", value/unit.size)) + unit.suffix
+		}
+	}
+	return trimZeros(fmt.Sprintf("%.2f", value))
+}
+
+func trimZeros(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if len(s) > 0 && s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
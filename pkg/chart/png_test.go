@@ -0,0 +1,150 @@
+package chart
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden images in testdata")
+
+const (
+	goldenWidth  = 640
+	goldenHeight = 360
+)
+
+var goldenLabels = []string{"03-01", "03-02", "03-03", "03-04", "03-05", "03-06", "03-07"}
+
+// assertGolden compares rendered image pixel by pixel with the golden image,
+// run `go test ./pkg/chart -update` to accept changes of the charts.
+func assertGolden(t *testing.T, name string, rendered *Image) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden.png")
+	if *update {
+		err := ioutil.WriteFile(path, rendered.PNG, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goldenPNG, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("error reading golden image, run with -update to create it: %v", err)
+	}
+	golden, err := png.Decode(bytes.NewReader(goldenPNG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := png.Decode(bytes.NewReader(rendered.PNG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bounds() != golden.Bounds() {
+		t.Fatalf("expected size %v, got %v", golden.Bounds(), got.Bounds())
+	}
+	if diff := differentPixels(got, golden); diff > 0 {
+		t.Errorf("%d pixels differ from %s", diff, path)
+	}
+}
+
+func differentPixels(a, b image.Image) int {
+	var diff int
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r0, g0, b0, a0 := a.At(x, y).RGBA()
+			r1, g1, b1, a1 := b.At(x, y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				diff++
+			}
+		}
+	}
+	return diff
+}
+
+func TestPNGRendererLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart LineChart
+	}{
+		{
+			name: "line",
+			chart: LineChart{
+				Title:  "Balance 2024-03-01 - 2024-03-07",
+				Labels: goldenLabels,
+				Series: []Series{
+					{Name: "Total", Values: []float64{1.2e9, 1.35e9, 1.1e9, 1.6e9, 1.55e9, 1.9e9, 2.05e9}},
+					{Name: "Master Wallet", Values: []float64{8e8, 9e8, 7e8, 1.1e9, 1e9, 1.3e9, 1.4e9}},
+				},
+			},
+		},
+		{
+			name: "line_negative",
+			chart: LineChart{
+				Title:  "Daily balance",
+				Labels: goldenLabels,
+				Series: []Series{
+					{Name: "Balance", Values: []float64{-2.5e7, 4e7, -1e7, 0, 3.3e7, -4.1e7, 1.2e7}},
+				},
+			},
+		},
+		{
+			name:  "line_empty",
+			chart: LineChart{Title: "No data"},
+		},
+	}
+	renderer := NewPNGRenderer(goldenWidth, goldenHeight)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered, err := renderer.Line(test.chart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, test.name, rendered)
+		})
+	}
+}
+
+func TestPNGRendererStackedBar(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart StackedBarChart
+	}{
+		{
+			name: "stacked_bar",
+			chart: StackedBarChart{
+				Title:  "Income and expenses by type",
+				Labels: goldenLabels,
+				Series: []Series{
+					{Name: "Bounties", Values: []float64{3e8, 2.5e8, 4e8, 3.2e8, 2.8e8, 3.9e8, 4.4e8}},
+					{Name: "Market", Values: []float64{1e8, -5e7, 2e8, -1.5e8, 8e7, 1.2e8, -3e7}},
+					{Name: "Structures", Values: []float64{-9e7, -9e7, -9e7, -9e7, -9e7, -9e7, -9e7}},
+				},
+			},
+		},
+		{
+			name: "stacked_bar_short_series",
+			chart: StackedBarChart{
+				Title:  "Missing values",
+				Labels: goldenLabels,
+				Series: []Series{
+					{Name: "Donations", Values: []float64{5e6, 1e7}},
+				},
+			},
+		},
+	}
+	renderer := NewPNGRenderer(goldenWidth, goldenHeight)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered, err := renderer.StackedBar(test.chart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, test.name, rendered)
+		})
+	}
+}
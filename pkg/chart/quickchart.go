@@ -0,0 +1,128 @@
+package chart
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+
+	quickchartgo "github.com/henomis/quickchart-go"
+	"github.com/pkg/errors"
+)
+
+// quickchartRenderer sends Chart.js config to quickchart.io, chart data
+// become visible to the service.
+type quickchartRenderer struct {
+	width  int
+	height int
+}
+
+// NewQuickchartRenderer returns renderer creating charts with quickchart.io.
+func NewQuickchartRenderer(width, height int) *quickchartRenderer {
+	return &quickchartRenderer{
+		width:  width,
+		height: height,
+	}
+}
+
+// Chart.js 2 config, only the options used by our charts.
+type chartJSConfig struct {
+	Type    string         `json:"type"`
+	Data    chartJSData    `json:"data"`
+	Options chartJSOptions `json:"options"`
+}
+
+type chartJSData struct {
+	Labels   []string         `json:"labels"`
+	Datasets []chartJSDataset `json:"datasets"`
+}
+
+type chartJSDataset struct {
+	Label           string      `json:"label,omitempty"`
+	Data            []float64   `json:"data"`
+	Fill            bool        `json:"fill"`
+	LineTension     float64     `json:"lineTension"`
+	BorderColor     interface{} `json:"borderColor,omitempty"`
+	BackgroundColor interface{} `json:"backgroundColor,omitempty"`
+}
+
+type chartJSOptions struct {
+	Title  chartJSTitle   `json:"title"`
+	Scales *chartJSScales `json:"scales,omitempty"`
+}
+
+type chartJSTitle struct {
+	Display bool   `json:"display"`
+	Text    string `json:"text,omitempty"`
+}
+
+type chartJSScales struct {
+	XAxes []chartJSAxis `json:"xAxes"`
+	YAxes []chartJSAxis `json:"yAxes"`
+}
+
+type chartJSAxis struct {
+	Stacked bool `json:"stacked"`
+}
+
+func (r *quickchartRenderer) Line(chart LineChart) (*Image, error) {
+	config := chartJSConfig{
+		Type: "line",
+		Data: chartJSData{Labels: chart.Labels},
+		Options: chartJSOptions{
+			Title: chartJSTitle{Display: chart.Title != "", Text: chart.Title},
+		},
+	}
+	for i, series := range chart.Series {
+		config.Data.Datasets = append(config.Data.Datasets, chartJSDataset{
+			Label:       series.Name,
+			Data:        series.Values,
+			BorderColor: cssColor(seriesColor(i)),
+		})
+	}
+	return r.render(config)
+}
+
+func (r *quickchartRenderer) StackedBar(chart StackedBarChart) (*Image, error) {
+	config := chartJSConfig{
+		Type: "bar",
+		Data: chartJSData{Labels: chart.Labels},
+		Options: chartJSOptions{
+			Title: chartJSTitle{Display: chart.Title != "", Text: chart.Title},
+			Scales: &chartJSScales{
+				XAxes: []chartJSAxis{{Stacked: true}},
+				YAxes: []chartJSAxis{{Stacked: true}},
+			},
+		},
+	}
+	for i, series := range chart.Series {
+		config.Data.Datasets = append(config.Data.Datasets, chartJSDataset{
+			Label:           series.Name,
+			Data:            series.Values,
+			BackgroundColor: cssColor(seriesColor(i)),
+		})
+	}
+	return r.render(config)
+}
+
+func (r *quickchartRenderer) render(config chartJSConfig) (*Image, error) {
+	configB, err := json.Marshal(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding chart config")
+	}
+
+	qc := quickchartgo.New()
+	qc.Config = string(configB)
+	qc.Width = int64(r.width)
+	qc.Height = int64(r.height)
+	qc.Version = "2.9.4"
+
+	chartURL, err := qc.GetShortUrl()
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating chart url")
+	}
+	return &Image{URL: chartURL}, nil
+}
+
+func cssColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
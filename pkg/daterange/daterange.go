@@ -0,0 +1,136 @@
+// Package daterange parses reporting periods typed by users, like `last month`,
+// `Q2 2024` or two dates, shared by Discord and CLI commands.
+package daterange
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/pkg/errors"
+)
+
+const dateFormat = "2006-01-02"
+
+// Usage lists supported formats, it is appended to parsing errors.
+const Usage = "use `today`, `yesterday`, `this week`, `last week`, `this month`, `last month`, `this year`, `last year`, `ytd`, `last 30d`, `last 4w`, `last 3m`, `Q2 2024`, `2024`, `2024-03`, `YYYY-MM-DD` or `YYYY-MM-DD YYYY-MM-DD`"
+
+var (
+	lastNRegexp   = regexp.MustCompile(`^last (\d+) ?(d|w|m)$`)
+	quarterRegexp = regexp.MustCompile(`^q([1-4])(?: (\d{4}))?$`)
+	yearRegexp    = regexp.MustCompile(`^\d{4}$`)
+	monthRegexp   = regexp.MustCompile(`^\d{4}-\d{2}$`)
+)
+
+// Parse returns whole days of the period described by args, days are
+// calendar days in location of now. Empty args mean current month. Periods
+// starting after today and periods ending before they start are rejected,
+// end date typed after today is clamped to today. Named periods such as
+// `this month` keep their calendar days.
+func Parse(args []string, now time.Time) (entity.Period, error) {
+	today := entity.Midnight(now)
+	expr := strings.ToLower(strings.Join(strings.Fields(strings.Join(args, " ")), " "))
+
+	dateStart, dateEnd, err := parse(expr, today)
+	if err != nil {
+		return entity.Period{}, err
+	}
+	if dateStart.After(today) {
+		return entity.Period{}, errors.Errorf("period starts %s in the future", dateStart.Format(dateFormat))
+	}
+	if dateEnd.Before(dateStart) {
+		return entity.Period{}, errors.Errorf("period ends %s before it starts %s", dateEnd.Format(dateFormat), dateStart.Format(dateFormat))
+	}
+	return entity.DaysPeriod(dateStart, dateEnd), nil
+}
+
+// parse returns first and last day of the period, in location of today.
+func parse(expr string, today time.Time) (time.Time, time.Time, error) {
+	year, month, _ := today.Date()
+	location := today.Location()
+	thisMonth := time.Date(year, month, 1, 0, 0, 0, 0, location)
+	thisWeek := today.AddDate(0, 0, -(int(today.Weekday())+6)%7) // Weeks start on Monday.
+	thisYear := time.Date(year, 1, 1, 0, 0, 0, 0, location)
+
+	switch expr {
+	case "", "this month":
+		return thisMonth, thisMonth.AddDate(0, 1, -1), nil
+	case "today":
+		return today, today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today.AddDate(0, 0, -1), nil
+	case "this week":
+		return thisWeek, thisWeek.AddDate(0, 0, 6), nil
+	case "last week":
+		return thisWeek.AddDate(0, 0, -7), thisWeek.AddDate(0, 0, -1), nil
+	case "last month":
+		return thisMonth.AddDate(0, -1, 0), thisMonth.AddDate(0, 0, -1), nil
+	case "this year":
+		return thisYear, thisYear.AddDate(1, 0, -1), nil
+	case "last year":
+		return thisYear.AddDate(-1, 0, 0), thisYear.AddDate(0, 0, -1), nil
+	case "ytd":
+		return thisYear, today, nil
+	}
+
+	if match := lastNRegexp.FindStringSubmatch(expr); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 {
+			return time.Time{}, time.Time{}, errors.Errorf("invalid period length: %s", match[1])
+		}
+		// Period ends today and has n days, weeks or months.
+		switch match[2] {
+		case "d":
+			return today.AddDate(0, 0, 1-n), today, nil
+		case "w":
+			return today.AddDate(0, 0, 1-7*n), today, nil
+		default:
+			return today.AddDate(0, -n, 1), today, nil
+		}
+	}
+	if match := quarterRegexp.FindStringSubmatch(expr); match != nil {
+		quarter, _ := strconv.Atoi(match[1])
+		quarterYear := year
+		if match[2] != "" {
+			quarterYear, _ = strconv.Atoi(match[2])
+		}
+		start := time.Date(quarterYear, time.Month(3*quarter-2), 1, 0, 0, 0, 0, location)
+		return start, start.AddDate(0, 3, -1), nil
+	}
+	if yearRegexp.MatchString(expr) {
+		start, err := time.ParseInLocation("2006", expr, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrapf(err, "invalid year: %s", expr)
+		}
+		return start, start.AddDate(1, 0, -1), nil
+	}
+	if monthRegexp.MatchString(expr) {
+		start, err := time.ParseInLocation("2006-01", expr, location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrapf(err, "invalid month: %s", expr)
+		}
+		return start, start.AddDate(0, 1, -1), nil
+	}
+
+	dates := strings.Split(expr, " ")
+	if len(dates) > 2 {
+		return time.Time{}, time.Time{}, errors.Errorf("unknown period: %s, %s", expr, Usage)
+	}
+	dateStart, err := time.ParseInLocation(dateFormat, dates[0], location)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Errorf("unknown period: %s, %s", expr, Usage)
+	}
+	if len(dates) == 1 {
+		return dateStart, dateStart, nil
+	}
+	dateEnd, err := time.ParseInLocation(dateFormat, dates[1], location)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Errorf("unknown period: %s, %s", expr, Usage)
+	}
+	if dateEnd.After(today) {
+		dateEnd = today
+	}
+	return dateStart, dateEnd, nil
+}
@@ -0,0 +1,84 @@
+package daterange
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	prague, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	// Wednesday, in the week clocks change to summer time.
+	now := time.Date(2024, 3, 27, 15, 4, 5, 0, prague)
+
+	tests := []struct {
+		expr      string
+		wantStart string
+		wantEnd   string // Last day of the period.
+		wantErr   string
+	}{
+		{expr: "", wantStart: "2024-03-01", wantEnd: "2024-03-31"},
+		{expr: "this month", wantStart: "2024-03-01", wantEnd: "2024-03-31"},
+		{expr: "  This   Month ", wantStart: "2024-03-01", wantEnd: "2024-03-31"},
+		{expr: "today", wantStart: "2024-03-27", wantEnd: "2024-03-27"},
+		{expr: "yesterday", wantStart: "2024-03-26", wantEnd: "2024-03-26"},
+		{expr: "this week", wantStart: "2024-03-25", wantEnd: "2024-03-31"},
+		{expr: "last week", wantStart: "2024-03-18", wantEnd: "2024-03-24"},
+		{expr: "last month", wantStart: "2024-02-01", wantEnd: "2024-02-29"},
+		{expr: "this year", wantStart: "2024-01-01", wantEnd: "2024-12-31"},
+		{expr: "last year", wantStart: "2023-01-01", wantEnd: "2023-12-31"},
+		{expr: "ytd", wantStart: "2024-01-01", wantEnd: "2024-03-27"},
+		{expr: "last 30d", wantStart: "2024-02-27", wantEnd: "2024-03-27"},
+		{expr: "last 1d", wantStart: "2024-03-27", wantEnd: "2024-03-27"},
+		{expr: "last 4w", wantStart: "2024-02-29", wantEnd: "2024-03-27"},
+		{expr: "last 3m", wantStart: "2023-12-28", wantEnd: "2024-03-27"},
+		{expr: "last 0d", wantErr: "invalid period length"},
+		{expr: "q1", wantStart: "2024-01-01", wantEnd: "2024-03-31"},
+		{expr: "Q4 2023", wantStart: "2023-10-01", wantEnd: "2023-12-31"},
+		{expr: "q3", wantErr: "in the future"},
+		{expr: "q5 2023", wantErr: "unknown period"},
+		{expr: "2023", wantStart: "2023-01-01", wantEnd: "2023-12-31"},
+		{expr: "2099", wantErr: "in the future"},
+		{expr: "2024-02", wantStart: "2024-02-01", wantEnd: "2024-02-29"},
+		{expr: "2024-13", wantErr: "invalid month"},
+		{expr: "2024-03-15", wantStart: "2024-03-15", wantEnd: "2024-03-15"},
+		{expr: "2024-03-15 2024-03-20", wantStart: "2024-03-15", wantEnd: "2024-03-20"},
+		{expr: "2024-03-01 2099-01-01", wantStart: "2024-03-01", wantEnd: "2024-03-27"},
+		{expr: "2024-03-27 2024-03-28", wantStart: "2024-03-27", wantEnd: "2024-03-27"},
+		{expr: "2024-03 2099-01-01", wantErr: "unknown period"},
+		{expr: "2024-03-20 2024-03-15", wantErr: "before it starts"},
+		{expr: "2024-03-28", wantErr: "in the future"},
+		{expr: "2024-03-28 2024-04-02", wantErr: "in the future"},
+		{expr: "2024-03-01 2024-03-05 2024-03-10", wantErr: "unknown period"},
+		{expr: "next month", wantErr: "unknown period"},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			period, err := Parse(strings.Fields(test.expr), now)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := period.Start.Format(dateFormat); got != test.wantStart {
+				t.Errorf("expected start %s, got %s", test.wantStart, got)
+			}
+			if got := period.LastDay().Format(dateFormat); got != test.wantEnd {
+				t.Errorf("expected last day %s, got %s", test.wantEnd, got)
+			}
+			if period.Start.Location() != prague || !period.Start.Equal(time.Date(period.Start.Year(), period.Start.Month(), period.Start.Day(), 0, 0, 0, 0, prague)) {
+				t.Errorf("expected period to start at midnight in %s, got %s", prague, period.Start)
+			}
+			if period.End.Hour() != 0 || period.End.Minute() != 0 {
+				t.Errorf("expected period to end at midnight, got %s", period.End)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	balanceAggregate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+const (
+	// DivisionBalanceFloor fires when wallet balance of the division in all
+	// corporations is below Amount.
+	DivisionBalanceFloor entity.RuleKind = "division_balance_floor"
+	// TypeSpendingCap fires when expenses of the ref type over Window exceed
+	// Amount.
+	TypeSpendingCap entity.RuleKind = "type_spending_cap"
+	// NetLossLimit fires when balance over Window is a loss bigger than Amount.
+	NetLossLimit entity.RuleKind = "net_loss_limit"
+	// LargeTransaction fires for every journal record within Window of at
+	// least Amount either way, each record only once.
+	LargeTransaction entity.RuleKind = "large_transaction"
+)
+
+// Rule declares one alert, amounts are positive.
+type Rule struct {
+	Name entity.RuleName `yaml:"name" json:"name"`
+	Kind entity.RuleKind `yaml:"kind" json:"kind"`
+	// Division limits division_balance_floor and large_transaction rules, its
+	// name is matched ignoring case.
+	Division balanceEntity.DivisionName `yaml:"division" json:"division"`
+	// RefType is raw ref type or group as shown by `!isk by type`, spending of
+	// all raw ref types of a group is summed.
+	RefType balanceEntity.RefType `yaml:"ref_type" json:"ref_type"`
+	Amount  balanceEntity.Amount  `yaml:"amount" json:"amount"`
+	// Window is how far back from now amounts are summed.
+	Window time.Duration `yaml:"window" json:"window"`
+	// Cooldown is the shortest time between two alerts of the rule.
+	Cooldown time.Duration    `yaml:"cooldown" json:"cooldown"`
+	Channel  entity.ChannelID `yaml:"channel" json:"channel"`
+}
+
+// Alert is a rule that fired.
+type Alert struct {
+	Key    entity.Key
+	Rule   Rule
+	Period balanceEntity.Period
+	// Value is the amount compared to Rule.Amount.
+	Value balanceEntity.Amount
+	// Record that fired LargeTransaction rule.
+	Record *balanceAggregate.WalletRecord
+}
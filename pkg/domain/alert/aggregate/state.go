@@ -0,0 +1,33 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+)
+
+// State of an alert, persisted so restarts do not send alerts again.
+type State struct {
+	Key entity.Key `storm:"id"`
+	// Title of the last message, to tell which alert was resolved.
+	Title string
+	// Channel the alert was sent to, when it overrides the default one.
+	Channel entity.ChannelID
+	SentAt  time.Time
+	// Firing is true while condition of the alert holds, alerts sent only
+	// once are never firing.
+	Firing bool `storm:"index"`
+	// Acknowledged alert is not sent again until SnoozedUntil, or until its
+	// condition clears when SnoozedUntil is zero.
+	Acknowledged   bool
+	AcknowledgedBy string
+	SnoozedUntil   time.Time
+}
+
+// Snoozed returns true when the alert is acknowledged at given time.
+func (s State) Snoozed(at time.Time) bool {
+	if !s.Acknowledged {
+		return false
+	}
+	return s.SnoozedUntil.IsZero() || at.Before(s.SnoozedUntil)
+}
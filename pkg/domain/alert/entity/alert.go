@@ -0,0 +1,8 @@
+package entity
+
+type (
+	Key       string /* Unique key of an alert, alert with the same key is sent only once */
+	RuleName  string /* Name of the alert rule, unique within the rules file */
+	RuleKind  string /* What the rule checks, see aggregate.Rule */
+	ChannelID string /* ID of discord channel alerts of the rule are sent to instead of channel of Discord notifiers, when not empty */
+)
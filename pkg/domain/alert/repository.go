@@ -0,0 +1,15 @@
+package alert
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+)
+
+type Repository interface {
+	// State returns nil when alert with the key was never sent.
+	State(ctx context.Context, key entity.Key) (*aggregate.State, error)
+	SaveState(ctx context.Context, state aggregate.State) error
+	FiringStates(ctx context.Context) ([]aggregate.State, error)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/pkg/errors"
+
+	"github.com/asdine/storm/v3"
+)
+
+const statesNodeKey = "alerts_sent"
+
+type persistentRepository struct {
+	statesNode storm.Node
+}
+
+func New(db *storm.DB) *persistentRepository {
+	return &persistentRepository{
+		statesNode: db.From(statesNodeKey),
+	}
+}
+
+func (r *persistentRepository) State(ctx context.Context, key entity.Key) (*aggregate.State, error) {
+	var state aggregate.State
+	err := r.statesNode.One("Key", key, &state)
+	if err != nil {
+		if errors.Is(err, storm.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "error loading alert state from DB")
+	}
+	return &state, nil
+}
+
+func (r *persistentRepository) SaveState(ctx context.Context, state aggregate.State) error {
+	err := r.statesNode.Save(&state)
+	if err != nil {
+		return errors.Wrap(err, "error saving alert state")
+	}
+	return nil
+}
+
+// FiringStates returns states of alerts whose condition holds.
+func (r *persistentRepository) FiringStates(ctx context.Context) ([]aggregate.State, error) {
+	var states []aggregate.State
+	err := r.statesNode.Find("Firing", true, &states)
+	if err != nil {
+		if errors.Is(err, storm.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "error loading firing alerts from DB")
+	}
+	return states, nil
+}
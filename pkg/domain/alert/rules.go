@@ -0,0 +1,170 @@
+package alert
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultRuleWindow   = 24 * time.Hour
+	defaultRuleCooldown = 24 * time.Hour
+)
+
+// RulesConfig is the format of the alert rules file, for example:
+//
+//	rules:
+//	  - name: srp wallet low
+//	    kind: division_balance_floor
+//	    division: SRP
+//	    amount: 5000000000
+//	    channel: "123456789012345678"
+//
+// JSON files use the same structure, durations are strings like "24h".
+type RulesConfig struct {
+	Rules []aggregate.Rule `yaml:"rules" json:"rules"`
+}
+
+// Rules are alert rules loaded from a file which can be reloaded at runtime,
+// there are none without a file.
+type Rules struct {
+	path string
+
+	mu    sync.RWMutex
+	rules []aggregate.Rule
+}
+
+// NewRules returns rules loaded from path, no rules when path is empty.
+func NewRules(path string) (*Rules, error) {
+	r := &Rules{
+		path: path,
+	}
+	if path == "" {
+		return r, nil
+	}
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Path returns file the rules are loaded from.
+func (r *Rules) Path() string {
+	return r.path
+}
+
+// Reload reads the rules file again, current rules are kept when the file is
+// invalid.
+func (r *Rules) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading alert rules file: %s", r.path)
+	}
+	rules, err := ParseRules(data, filepath.Ext(r.path))
+	if err != nil {
+		return errors.Wrapf(err, "error loading alert rules file: %s", r.path)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = rules
+	return nil
+}
+
+// Rules returns current rules.
+func (r *Rules) Rules() []aggregate.Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.rules
+}
+
+// ParseRules parses and validates alert rules, ext selects the format
+// (".json", ".yaml" or ".yml").
+func ParseRules(data []byte, ext string) ([]aggregate.Rule, error) {
+	var config RulesConfig
+	switch strings.ToLower(ext) {
+	// JSON is a subset of YAML, strict YAML decoder rejects unknown fields and
+	// duplicate keys in both.
+	case ".json", ".yaml", ".yml":
+		err := yaml.UnmarshalStrict(data, &config)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid alert rules")
+		}
+	default:
+		return nil, errors.Errorf("unsupported alert rules format: %q, use .yaml, .yml or .json", ext)
+	}
+
+	return config.rules()
+}
+
+// rules validates the config and fills in default window and cooldown.
+func (c RulesConfig) rules() ([]aggregate.Rule, error) {
+	var (
+		problems []string
+		names    = make(map[entity.RuleName]struct{}, len(c.Rules))
+		rules    = make([]aggregate.Rule, 0, len(c.Rules))
+	)
+	for i, rule := range c.Rules {
+		if strings.TrimSpace(string(rule.Name)) == "" {
+			problems = append(problems, fmt.Sprintf("rule %d has no name", i+1))
+			continue
+		}
+		if _, ok := names[rule.Name]; ok {
+			problems = append(problems, fmt.Sprintf("rule %q is defined twice", rule.Name))
+			continue
+		}
+		names[rule.Name] = struct{}{}
+
+		switch rule.Kind {
+		case aggregate.DivisionBalanceFloor:
+			if rule.Division == "" {
+				problems = append(problems, fmt.Sprintf("rule %q has no division", rule.Name))
+			}
+		case aggregate.TypeSpendingCap:
+			if rule.RefType == "" {
+				problems = append(problems, fmt.Sprintf("rule %q has no ref_type", rule.Name))
+			}
+		case aggregate.NetLossLimit, aggregate.LargeTransaction:
+		default:
+			problems = append(problems, fmt.Sprintf("rule %q has unknown kind %q", rule.Name, rule.Kind))
+		}
+		if rule.RefType != "" && rule.Kind != aggregate.TypeSpendingCap {
+			problems = append(problems, fmt.Sprintf("rule %q of kind %q does not use ref_type", rule.Name, rule.Kind))
+		}
+		if rule.Division != "" && rule.Kind != aggregate.DivisionBalanceFloor && rule.Kind != aggregate.LargeTransaction {
+			problems = append(problems, fmt.Sprintf("rule %q of kind %q does not use division", rule.Name, rule.Kind))
+		}
+		if rule.Amount <= 0 {
+			problems = append(problems, fmt.Sprintf("rule %q must have positive amount", rule.Name))
+		}
+		if rule.Window < 0 || rule.Cooldown < 0 {
+			problems = append(problems, fmt.Sprintf("rule %q must not have negative window or cooldown", rule.Name))
+		}
+
+		if rule.Window == 0 {
+			rule.Window = defaultRuleWindow
+		}
+		if rule.Cooldown == 0 {
+			rule.Cooldown = defaultRuleCooldown
+		}
+		rules = append(rules, rule)
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("invalid alert rules: %s", strings.Join(problems, "; "))
+	}
+	return rules, nil
+}
@@ -0,0 +1,127 @@
+package alert
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/pkg/errors"
+)
+
+type Service interface {
+	Sent(ctx context.Context, key entity.Key) (bool, error)
+	State(ctx context.Context, key entity.Key) (*aggregate.State, error)
+	Firing(ctx context.Context) ([]aggregate.State, error)
+	MarkSent(ctx context.Context, key entity.Key, at time.Time) error
+	MarkFiring(ctx context.Context, key entity.Key, title string, channel entity.ChannelID, at time.Time) error
+	Clear(ctx context.Context, key entity.Key) error
+	Acknowledge(ctx context.Context, key entity.Key, until time.Time, by string) error
+}
+
+type alertService struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *alertService {
+	return &alertService{
+		repository: repository,
+	}
+}
+
+// Sent returns true when alert with the key was already delivered.
+func (s *alertService) Sent(ctx context.Context, key entity.Key) (bool, error) {
+	state, err := s.State(ctx, key)
+	return state != nil, err
+}
+
+// State returns state of alert with the key, nil when it was never sent.
+func (s *alertService) State(ctx context.Context, key entity.Key) (*aggregate.State, error) {
+	state, err := s.repository.State(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error checking alert: %s", key)
+	}
+	return state, nil
+}
+
+// Firing returns alerts whose condition holds, oldest first.
+func (s *alertService) Firing(ctx context.Context) ([]aggregate.State, error) {
+	states, err := s.repository.FiringStates(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing firing alerts")
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].SentAt.Before(states[j].SentAt)
+	})
+	return states, nil
+}
+
+// MarkSent remembers that alert sent only once was delivered at.
+func (s *alertService) MarkSent(ctx context.Context, key entity.Key, at time.Time) error {
+	err := s.repository.SaveState(ctx, aggregate.State{Key: key, SentAt: at})
+	if err != nil {
+		return errors.Wrapf(err, "error marking alert as sent: %s", key)
+	}
+	return nil
+}
+
+// MarkFiring remembers that alert with the key was delivered at and its
+// condition holds, acknowledgement is kept.
+func (s *alertService) MarkFiring(ctx context.Context, key entity.Key, title string, channel entity.ChannelID, at time.Time) error {
+	state, err := s.State(ctx, key)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &aggregate.State{Key: key}
+	}
+	state.Title = title
+	state.Channel = channel
+	state.SentAt = at
+	state.Firing = true
+	err = s.repository.SaveState(ctx, *state)
+	if err != nil {
+		return errors.Wrapf(err, "error marking alert as firing: %s", key)
+	}
+	return nil
+}
+
+// Clear remembers that condition of the alert no longer holds, which ends
+// acknowledgement until cleared.
+func (s *alertService) Clear(ctx context.Context, key entity.Key) error {
+	state, err := s.State(ctx, key)
+	if err != nil || state == nil {
+		return err
+	}
+	state.Firing = false
+	if state.SnoozedUntil.IsZero() {
+		state.Acknowledged = false
+		state.AcknowledgedBy = ""
+	}
+	err = s.repository.SaveState(ctx, *state)
+	if err != nil {
+		return errors.Wrapf(err, "error clearing alert: %s", key)
+	}
+	return nil
+}
+
+// Acknowledge stops firing alert from being sent until, or until its
+// condition clears when until is zero.
+func (s *alertService) Acknowledge(ctx context.Context, key entity.Key, until time.Time, by string) error {
+	state, err := s.State(ctx, key)
+	if err != nil {
+		return err
+	}
+	if state == nil || !state.Firing {
+		return errors.Errorf("alert is not firing: %s", key)
+	}
+	state.Acknowledged = true
+	state.AcknowledgedBy = by
+	state.SnoozedUntil = until
+	err = s.repository.SaveState(ctx, *state)
+	if err != nil {
+		return errors.Wrapf(err, "error acknowledging alert: %s", key)
+	}
+	return nil
+}
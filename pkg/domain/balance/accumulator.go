@@ -0,0 +1,237 @@
+package balance
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+type balanceAccumulator struct {
+	balance *aggregate.Balance
+}
+
+func newBalanceAccumulator() *balanceAccumulator {
+	return &balanceAccumulator{balance: aggregate.NewBalance()}
+}
+
+func (a *balanceAccumulator) Accumulate(_ Source, record aggregate.JournalRecord) {
+	if record.Amount > 0 {
+		a.balance.Income += record.Amount
+	}
+	if record.Amount < 0 {
+		a.balance.Expenses += record.Amount
+	}
+}
+
+type balanceByDivisionAccumulator struct {
+	balance *aggregate.BalanceByDivision
+}
+
+func newBalanceByDivisionAccumulator() *balanceByDivisionAccumulator {
+	return &balanceByDivisionAccumulator{balance: aggregate.NewBalanceByDivision()}
+}
+
+func (a *balanceByDivisionAccumulator) Accumulate(source Source, record aggregate.JournalRecord) {
+	if record.Amount > 0 {
+		a.balance.IncomeByDivision[source.DivisionName()] += record.Amount
+	}
+	if record.Amount < 0 {
+		a.balance.ExpensesByDivision[source.DivisionName()] += record.Amount
+	}
+}
+
+type balanceByTypeAccumulator struct {
+	groups  *RefTypeGroups
+	balance *aggregate.BalanceByType
+}
+
+func newBalanceByTypeAccumulator(groups *RefTypeGroups) *balanceByTypeAccumulator {
+	return &balanceByTypeAccumulator{
+		groups:  groups,
+		balance: aggregate.NewBalanceByType(),
+	}
+}
+
+func (a *balanceByTypeAccumulator) Accumulate(_ Source, record aggregate.JournalRecord) {
+	if record.Amount > 0 {
+		a.balance.IncomeByType[a.groups.Group(record.RefType)] += record.Amount
+	}
+	if record.Amount < 0 {
+		a.balance.ExpensesByType[a.groups.Group(record.RefType)] += record.Amount
+	}
+}
+
+// unmappedTypeAccumulator sums only records of raw ref types without a group.
+type unmappedTypeAccumulator struct {
+	groups  *RefTypeGroups
+	balance *aggregate.BalanceByType
+}
+
+func newUnmappedTypeAccumulator(groups *RefTypeGroups) *unmappedTypeAccumulator {
+	return &unmappedTypeAccumulator{
+		groups:  groups,
+		balance: aggregate.NewBalanceByType(),
+	}
+}
+
+func (a *unmappedTypeAccumulator) Accumulate(_ Source, record aggregate.JournalRecord) {
+	if a.groups.IsGrouped(record.RefType) {
+		return
+	}
+	if record.Amount > 0 {
+		a.balance.IncomeByType[record.RefType] += record.Amount
+	}
+	if record.Amount < 0 {
+		a.balance.ExpensesByType[record.RefType] += record.Amount
+	}
+}
+
+type balanceByPartyAccumulator struct {
+	balance *aggregate.BalanceByParty
+}
+
+func newBalanceByPartyAccumulator() *balanceByPartyAccumulator {
+	return &balanceByPartyAccumulator{balance: aggregate.NewBalanceByParty()}
+}
+
+// Accumulate skips market transactions and records of NPCs and our own
+// corporation, they are not paid by nor to members.
+func (a *balanceByPartyAccumulator) Accumulate(source Source, record aggregate.JournalRecord) {
+	if _, ok := record.TransactionId(); ok {
+		return
+	}
+	party := source.Party(record)
+	if party == 0 || entity.CorporationID(party) == source.CorporationID || isNPC(party) {
+		return
+	}
+	if record.Amount > 0 {
+		a.balance.IncomeByParty[party] += record.Amount
+	}
+	if record.Amount < 0 {
+		a.balance.ExpensesByParty[party] += record.Amount
+	}
+}
+
+// balanceByDayAccumulator buckets records into days of the period in its
+// location, records outside of the period are ignored.
+type balanceByDayAccumulator struct {
+	groups  *RefTypeGroups
+	period  entity.Period
+	balance []*aggregate.BalanceByDivisionByType
+}
+
+func newBalanceByDayAccumulator(groups *RefTypeGroups, period entity.Period) *balanceByDayAccumulator {
+	var dailyBalance []*aggregate.BalanceByDivisionByType
+	for _, d := range period.Days() {
+		dailyBalance = append(dailyBalance, aggregate.NewBalanceByDivisionByType(d))
+	}
+	return &balanceByDayAccumulator{
+		groups:  groups,
+		period:  period,
+		balance: dailyBalance,
+	}
+}
+
+func (a *balanceByDayAccumulator) Accumulate(source Source, record aggregate.JournalRecord) {
+	day, ok := a.period.Day(record.Date)
+	if !ok || day >= len(a.balance) {
+		return
+	}
+
+	if record.Amount > 0 {
+		a.balance[day].Income.Record(source.DivisionName(), a.groups.Group(record.RefType), record.Amount)
+	}
+	if record.Amount < 0 {
+		a.balance[day].Expenses.Record(source.DivisionName(), a.groups.Group(record.RefType), record.Amount)
+	}
+}
+
+// dailyAmountsAccumulator sums raw ref types into days of the period.
+type dailyAmountsAccumulator struct {
+	period  entity.Period
+	amounts *aggregate.DailyAmounts
+}
+
+func newDailyAmountsAccumulator(period entity.Period) *dailyAmountsAccumulator {
+	return &dailyAmountsAccumulator{
+		period:  period,
+		amounts: aggregate.NewDailyAmounts(period.Days()),
+	}
+}
+
+func (a *dailyAmountsAccumulator) Accumulate(_ Source, record aggregate.JournalRecord) {
+	day, ok := a.period.Day(record.Date)
+	if !ok || day >= len(a.amounts.Days) {
+		return
+	}
+	a.amounts.Record(day, record.RefType, record.Amount)
+}
+
+// walletBalanceAccumulator keeps the last record of each wallet for every day
+// of the period, records outside of the period are ignored.
+type walletBalanceAccumulator struct {
+	period entity.Period
+	days   []time.Time
+	last   []map[aggregate.Wallet]aggregate.JournalRecord
+}
+
+func newWalletBalanceAccumulator(period entity.Period) *walletBalanceAccumulator {
+	a := &walletBalanceAccumulator{
+		period: period,
+		days:   period.Days(),
+	}
+	for range a.days {
+		a.last = append(a.last, make(map[aggregate.Wallet]aggregate.JournalRecord))
+	}
+	return a
+}
+
+func (a *walletBalanceAccumulator) Accumulate(source Source, record aggregate.JournalRecord) {
+	day, ok := a.period.Day(record.Date)
+	if !ok || day >= len(a.last) {
+		return
+	}
+
+	wallet := aggregate.Wallet{
+		CorporationID: source.CorporationID,
+		Division:      source.DivisionName(),
+	}
+	last, ok := a.last[day][wallet]
+	if !ok || record.Date.After(last.Date) || (record.Date.Equal(last.Date) && record.Id > last.Id) {
+		a.last[day][wallet] = record
+	}
+}
+
+// taxRefTypes are journal records of tax members pay from their ratting and
+// missions.
+var taxRefTypes = map[entity.RefType]struct{}{
+	entity.RefType("bounty_prizes"):                   {},
+	entity.RefType("ess_escrow_transfer"):             {},
+	entity.RefType("agent_mission_reward"):            {},
+	entity.RefType("agent_mission_time_bonus_reward"): {},
+}
+
+// taxLedgerAccumulator records tax into calendar months of location.
+type taxLedgerAccumulator struct {
+	location *time.Location
+	ledger   *aggregate.TaxLedger
+}
+
+func newTaxLedgerAccumulator(location *time.Location) *taxLedgerAccumulator {
+	return &taxLedgerAccumulator{
+		location: location,
+		ledger:   aggregate.NewTaxLedger(),
+	}
+}
+
+func (a *taxLedgerAccumulator) Accumulate(source Source, record aggregate.JournalRecord) {
+	if _, ok := taxRefTypes[record.RefType]; !ok {
+		return
+	}
+	pilot, ok := source.Pilot(record)
+	if !ok {
+		return
+	}
+	a.ledger.Record(record.Date.In(a.location), pilot, record.Amount)
+}
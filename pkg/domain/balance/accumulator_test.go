@@ -0,0 +1,37 @@
+package balance
+
+import (
+	"testing"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+)
+
+func TestBalanceByPartyAccumulator(t *testing.T) {
+	const (
+		corporation = 98000001
+		member      = 91000001
+		npc         = 1000125
+	)
+	source := Source{CorporationID: corporation}
+	records := []aggregate.JournalRecord{
+		{Amount: 100, FirstPartyId: member, SecondPartyId: corporation, RefType: "player_donation"},
+		{Amount: -40, FirstPartyId: corporation, SecondPartyId: member, RefType: "corporation_account_withdrawal"},
+		{Amount: 500, FirstPartyId: npc, SecondPartyId: corporation, RefType: "bounty_prizes"},
+		{Amount: 70, FirstPartyId: member, SecondPartyId: corporation, RefType: "market_transaction", ContextIdType: "market_transaction_id", ContextId: 1},
+		{Amount: -10, FirstPartyId: corporation, SecondPartyId: corporation, RefType: "corporation_account_withdrawal"},
+	}
+
+	accumulator := newBalanceByPartyAccumulator()
+	for _, record := range records {
+		accumulator.Accumulate(source, record)
+	}
+
+	expectedIncome := aggregate.AmountByParty{member: 100}
+	expectedExpenses := aggregate.AmountByParty{member: -40}
+	if len(accumulator.balance.IncomeByParty) != len(expectedIncome) || accumulator.balance.IncomeByParty[member] != expectedIncome[member] {
+		t.Errorf("expected income %v, got %v", expectedIncome, accumulator.balance.IncomeByParty)
+	}
+	if len(accumulator.balance.ExpensesByParty) != len(expectedExpenses) || accumulator.balance.ExpensesByParty[member] != expectedExpenses[member] {
+		t.Errorf("expected expenses %v, got %v", expectedExpenses, accumulator.balance.ExpensesByParty)
+	}
+}
@@ -0,0 +1,40 @@
+package aggregate
+
+import (
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+// AnomalyReason explains why a journal record is unusual.
+type AnomalyReason string
+
+const (
+	// LargeWithdrawal is withdrawal several times larger than typical.
+	LargeWithdrawal AnomalyReason = "large_withdrawal"
+	// NewRecipient is payment to a party that received no ISK in the history
+	// window.
+	NewRecipient AnomalyReason = "new_recipient"
+	// NewRefType is the first record of a ref type in the history window.
+	NewRefType AnomalyReason = "new_ref_type"
+)
+
+// Anomaly is an unusual journal record with all reasons it was flagged for.
+type Anomaly struct {
+	CorporationID entity.CorporationID
+	Division      entity.DivisionName
+	Record        JournalRecord
+	// Party on the other side of the transaction.
+	Party   entity.PartyID
+	Reasons []AnomalyReason
+	// Typical is median of past withdrawals, set for LargeWithdrawal.
+	Typical entity.Amount
+}
+
+// Has returns true when the anomaly was flagged for reason.
+func (a Anomaly) Has(reason AnomalyReason) bool {
+	for _, r := range a.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,7 @@ import (
 type AmountByType map[entity.RefType]entity.Amount
 type AmountByDivision map[entity.DivisionName]entity.Amount
 type AmountByDivisionByType map[entity.DivisionName]map[entity.RefType]entity.Amount
+type AmountByParty map[entity.PartyID]entity.Amount
 
 type Balance struct {
 	Income   entity.Amount
@@ -62,6 +63,23 @@ func (b *BalanceByDivision) Sum(other *BalanceByDivision) {
 	b.ExpensesByDivision.sum(other.ExpensesByDivision)
 }
 
+type BalanceByParty struct {
+	IncomeByParty   AmountByParty
+	ExpensesByParty AmountByParty
+}
+
+func NewBalanceByParty() *BalanceByParty {
+	return &BalanceByParty{
+		IncomeByParty:   make(AmountByParty),
+		ExpensesByParty: make(AmountByParty),
+	}
+}
+
+func (b *BalanceByParty) Sum(other *BalanceByParty) {
+	b.IncomeByParty.sum(other.IncomeByParty)
+	b.ExpensesByParty.sum(other.ExpensesByParty)
+}
+
 func (abt AmountByType) sum(other AmountByType) {
 	for refType, amount := range other {
 		abt[refType] += amount
@@ -74,6 +92,12 @@ func (abt AmountByDivision) sum(other AmountByDivision) {
 	}
 }
 
+func (abp AmountByParty) sum(other AmountByParty) {
+	for partyID, amount := range other {
+		abp[partyID] += amount
+	}
+}
+
 type BalanceByDivisionByType struct {
 	Timestamp time.Time
 	Income    AmountByDivisionByType
@@ -111,7 +135,7 @@ func (b AmountByDivisionByType) Record(divisionName entity.DivisionName, refType
 }
 
 type MonthlyBalanceNotification struct {
-	Threshold          entity.Amount
-	DateStart, DateEnd time.Time
-	Balance            Balance
+	Threshold entity.Amount
+	Period    entity.Period
+	Balance   Balance
 }
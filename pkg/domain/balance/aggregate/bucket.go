@@ -0,0 +1,56 @@
+package aggregate
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bucket is a period daily balance is summed into.
+type Bucket string
+
+const (
+	BucketDay   = Bucket("daily")
+	BucketWeek  = Bucket("weekly")
+	BucketMonth = Bucket("monthly")
+)
+
+// Start returns start of the bucket the time belongs to, weeks start on Monday.
+func (b Bucket) Start(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch b {
+	case BucketWeek:
+		weekday := (int(t.Weekday()) + 6) % 7 // Monday is 0.
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Label formats start of the bucket for charts.
+func (b Bucket) Label(start time.Time) string {
+	switch b {
+	case BucketWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case BucketMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// BucketBalance sums daily balance into buckets, first bucket may be partial
+// when days do not start at bucket start.
+func BucketBalance(days []*BalanceByDivisionByType, bucket Bucket) []*BalanceByDivisionByType {
+	var buckets []*BalanceByDivisionByType
+	for _, day := range days {
+		start := bucket.Start(day.Timestamp)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Timestamp.Equal(start) {
+			buckets = append(buckets, NewBalanceByDivisionByType(start))
+		}
+		buckets[len(buckets)-1].Sum(day)
+	}
+	return buckets
+}
@@ -0,0 +1,124 @@
+package aggregate
+
+import (
+	"math"
+	"sort"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+// BalanceSummary is balance of one period in total, by division and by type.
+type BalanceSummary struct {
+	Period     entity.Period
+	Balance    *Balance
+	ByDivision *BalanceByDivision
+	ByType     *BalanceByType
+}
+
+func NewBalanceSummary(period entity.Period) *BalanceSummary {
+	return &BalanceSummary{
+		Period:     period,
+		Balance:    NewBalance(),
+		ByDivision: NewBalanceByDivision(),
+		ByType:     NewBalanceByType(),
+	}
+}
+
+// Comparison holds balance of the current period and of the periods it is
+// compared to.
+type Comparison struct {
+	Current  *BalanceSummary
+	Previous *BalanceSummary
+	YearAgo  *BalanceSummary
+}
+
+// Delta is change of one amount against the base period.
+type Delta struct {
+	Name    string
+	Current entity.Amount
+	Base    entity.Amount
+}
+
+func (d Delta) Change() entity.Amount {
+	return d.Current - d.Base
+}
+
+// Percent returns change relative to the base amount, false when base is zero.
+func (d Delta) Percent() (float64, bool) {
+	if d.Base == 0 {
+		return 0, false
+	}
+	return float64(d.Change()) / math.Abs(float64(d.Base)) * 100, true
+}
+
+// CompareTotal returns change of income, expenses and balance.
+func CompareTotal(current, base *BalanceSummary) []Delta {
+	return []Delta{
+		{Name: "Income", Current: current.Balance.Income, Base: base.Balance.Income},
+		{Name: "Expenses", Current: current.Balance.Expenses, Base: base.Balance.Expenses},
+		{Name: "Balance", Current: current.Balance.Balance(), Base: base.Balance.Balance()},
+	}
+}
+
+// CompareByDivision returns change of balance of every division, biggest
+// movers first.
+func CompareByDivision(current, base *BalanceSummary) []Delta {
+	set := make(deltaSet)
+	record := func(amounts AmountByDivision, isCurrent bool) {
+		for divisionName, amount := range amounts {
+			set.record(string(divisionName), amount, isCurrent)
+		}
+	}
+	record(current.ByDivision.IncomeByDivision, true)
+	record(current.ByDivision.ExpensesByDivision, true)
+	record(base.ByDivision.IncomeByDivision, false)
+	record(base.ByDivision.ExpensesByDivision, false)
+	return set.sorted()
+}
+
+// CompareByType returns change of balance of every ref type, biggest movers
+// first.
+func CompareByType(current, base *BalanceSummary) []Delta {
+	set := make(deltaSet)
+	record := func(amounts AmountByType, isCurrent bool) {
+		for refType, amount := range amounts {
+			set.record(string(refType), amount, isCurrent)
+		}
+	}
+	record(current.ByType.IncomeByType, true)
+	record(current.ByType.ExpensesByType, true)
+	record(base.ByType.IncomeByType, false)
+	record(base.ByType.ExpensesByType, false)
+	return set.sorted()
+}
+
+type deltaSet map[string]*Delta
+
+func (d deltaSet) record(name string, amount entity.Amount, isCurrent bool) {
+	delta, ok := d[name]
+	if !ok {
+		delta = &Delta{Name: name}
+		d[name] = delta
+	}
+	if isCurrent {
+		delta.Current += amount
+	} else {
+		delta.Base += amount
+	}
+}
+
+// sorted returns deltas ordered by absolute change, then by name.
+func (d deltaSet) sorted() []Delta {
+	sorted := make([]Delta, 0, len(d))
+	for _, delta := range d {
+		sorted = append(sorted, *delta)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := math.Abs(float64(sorted[i].Change())), math.Abs(float64(sorted[j].Change()))
+		if a != b {
+			return a > b
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
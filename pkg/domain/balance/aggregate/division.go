@@ -6,3 +6,12 @@ type Division struct {
 	ID   entity.DivisionID
 	Name entity.DivisionName
 }
+
+// DisplayName returns name of the division, master wallet has no name so it
+// is shown as "Main".
+func (d Division) DisplayName() entity.DivisionName {
+	if d.Name == "" {
+		return entity.DivisionName("Main")
+	}
+	return d.Name
+}
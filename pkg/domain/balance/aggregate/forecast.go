@@ -0,0 +1,60 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+// DailyAmounts holds net amount of every raw ref type for each day of a period.
+type DailyAmounts struct {
+	Days   []time.Time
+	ByType map[entity.RefType][]entity.Amount
+}
+
+func NewDailyAmounts(days []time.Time) *DailyAmounts {
+	return &DailyAmounts{
+		Days:   days,
+		ByType: make(map[entity.RefType][]entity.Amount),
+	}
+}
+
+func (d *DailyAmounts) Record(day int, refType entity.RefType, amount entity.Amount) {
+	amounts, ok := d.ByType[refType]
+	if !ok {
+		amounts = make([]entity.Amount, len(d.Days))
+		d.ByType[refType] = amounts
+	}
+	amounts[day] += amount
+}
+
+// Forecast projects balance of the month at its end from balance so far,
+// scheduled recurring fees and daily income and expenses of the past days.
+type Forecast struct {
+	Period entity.Period
+	AsOf   time.Time
+	// Actual balance of the month so far.
+	Actual entity.Amount
+	// Scheduled recurring fees expected until the end of the month.
+	Scheduled       entity.Amount
+	ScheduledByType AmountByType
+	// Expected balance of other ref types until the end of the month.
+	Expected entity.Amount
+	// Low and High bound projected month end balance with Confidence.
+	Low        entity.Amount
+	High       entity.Amount
+	Confidence float64
+	// HistoryDays is number of days daily statistics were calculated from.
+	HistoryDays int
+	Threshold   entity.Amount
+}
+
+// Projected returns expected balance at the end of the month.
+func (f *Forecast) Projected() entity.Amount {
+	return f.Actual + f.Scheduled + f.Expected
+}
+
+// BelowThreshold returns true when projected balance is below threshold.
+func (f *Forecast) BelowThreshold() bool {
+	return f.Projected() < f.Threshold
+}
@@ -1,6 +1,7 @@
 package aggregate
 
 import (
+	"strings"
 	"time"
 
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
@@ -21,3 +22,78 @@ type JournalRecord struct {
 	Tax           entity.Tax           /* Tax amount received. Only applies to tax related transactions */
 	TaxReceiverId entity.TaxReceiverId /* The corporation ID receiving any tax paid. Only applies to tax related transactions */
 }
+
+// marketTransactionIdType is the ContextIdType of market transaction records.
+const marketTransactionIdType = entity.ContextIdType("market_transaction_id")
+
+// TransactionId returns ID of the market transaction this record was created by.
+func (r JournalRecord) TransactionId() (entity.TransactionId, bool) {
+	if r.ContextIdType != marketTransactionIdType {
+		return 0, false
+	}
+	return entity.TransactionId(r.ContextId), true
+}
+
+// JournalQuery selects journal records, empty fields match all records.
+type JournalQuery struct {
+	Period entity.Period
+	// Division is compared with names of divisions ignoring case.
+	Division entity.DivisionName
+	// RefTypes are raw ref types or groups, balance service expands groups to
+	// raw ref types before records are matched.
+	RefTypes []entity.RefType
+	// MinAmount and MaxAmount bound amount either way, so withdrawals match
+	// the same as deposits.
+	MinAmount entity.Amount
+	MaxAmount entity.Amount
+	// Description is searched in description and reason, ignoring case.
+	Description string
+	// Party is searched in names of the first and second party ignoring case,
+	// records do not contain names so it is matched by accountant service.
+	Party string
+	// Limit is the maximum number of the newest matching records, 0 returns
+	// all of them.
+	Limit int
+}
+
+// MatchesDivision returns true when records of the division match the query.
+func (q JournalQuery) MatchesDivision(division Division) bool {
+	return q.Division == "" || strings.EqualFold(string(division.DisplayName()), string(q.Division))
+}
+
+// Matches returns true when the record matches all fields of the query but
+// the division and party, which are not stored in records.
+func (q JournalQuery) Matches(record JournalRecord) bool {
+	if !q.Period.Contains(record.Date) {
+		return false
+	}
+	if len(q.RefTypes) > 0 {
+		found := false
+		for _, refType := range q.RefTypes {
+			if record.RefType == refType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	amount := record.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+	if q.MinAmount > 0 && amount < q.MinAmount {
+		return false
+	}
+	if q.MaxAmount > 0 && amount > q.MaxAmount {
+		return false
+	}
+	if q.Description != "" {
+		text := strings.ToLower(q.Description)
+		if !strings.Contains(strings.ToLower(string(record.Description)), text) && !strings.Contains(strings.ToLower(string(record.Reason)), text) {
+			return false
+		}
+	}
+	return true
+}
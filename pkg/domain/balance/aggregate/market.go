@@ -0,0 +1,101 @@
+package aggregate
+
+import "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+// ItemMarket sums market transactions of one item type.
+type ItemMarket struct {
+	TypeId entity.TypeId
+
+	// Transactions within the reported period.
+	BoughtQuantity int64
+	Spent          entity.Amount // Negative, same as expenses.
+	SoldQuantity   int64
+	Revenue        entity.Amount
+
+	// All purchases until the end of the reported period, used to calculate
+	// the cost of sold items.
+	HistoryBoughtQuantity int64
+	HistorySpent          entity.Amount
+}
+
+// Volume returns number of items bought and sold within the period.
+func (i *ItemMarket) Volume() int64 {
+	return i.BoughtQuantity + i.SoldQuantity
+}
+
+// AverageBuyPrice returns weighted average price the item was bought for.
+func (i *ItemMarket) AverageBuyPrice() (entity.Amount, bool) {
+	if i.HistoryBoughtQuantity == 0 {
+		return 0, false
+	}
+	return -i.HistorySpent / entity.Amount(i.HistoryBoughtQuantity), true
+}
+
+// RealisedMargin returns revenue minus the average cost of the sold items, it
+// is unknown for items that were never bought.
+func (i *ItemMarket) RealisedMargin() (entity.Amount, bool) {
+	averageBuyPrice, ok := i.AverageBuyPrice()
+	if !ok {
+		return 0, false
+	}
+	return i.Revenue - averageBuyPrice*entity.Amount(i.SoldQuantity), true
+}
+
+type MarketByItem map[entity.TypeId]*ItemMarket
+
+func NewMarketByItem() MarketByItem {
+	return make(MarketByItem)
+}
+
+// Record adds transaction to the item totals, transactions before the reported
+// period only count towards the purchase history.
+func (m MarketByItem) Record(transaction Transaction, inPeriod bool) {
+	item, ok := m[transaction.TypeId]
+	if !ok {
+		item = &ItemMarket{TypeId: transaction.TypeId}
+		m[transaction.TypeId] = item
+	}
+
+	if transaction.IsBuy {
+		item.HistoryBoughtQuantity += int64(transaction.Quantity)
+		item.HistorySpent += transaction.Amount()
+	}
+	if !inPeriod {
+		return
+	}
+	if transaction.IsBuy {
+		item.BoughtQuantity += int64(transaction.Quantity)
+		item.Spent += transaction.Amount()
+	} else {
+		item.SoldQuantity += int64(transaction.Quantity)
+		item.Revenue += transaction.Amount()
+	}
+}
+
+// Add adds totals of other market to the market.
+func (m MarketByItem) Add(other MarketByItem) {
+	for typeID, otherItem := range other {
+		item, ok := m[typeID]
+		if !ok {
+			item = &ItemMarket{TypeId: typeID}
+			m[typeID] = item
+		}
+		item.BoughtQuantity += otherItem.BoughtQuantity
+		item.Spent += otherItem.Spent
+		item.SoldQuantity += otherItem.SoldQuantity
+		item.Revenue += otherItem.Revenue
+		item.HistoryBoughtQuantity += otherItem.HistoryBoughtQuantity
+		item.HistorySpent += otherItem.HistorySpent
+	}
+}
+
+// Items returns items with transactions within the reported period.
+func (m MarketByItem) Items() []*ItemMarket {
+	items := make([]*ItemMarket, 0, len(m))
+	for _, item := range m {
+		if item.Volume() > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,89 @@
+package aggregate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+// TaxLedger sums tax paid to the corporation by the pilot who generated it, by
+// calendar month.
+type TaxLedger struct {
+	ByMonth map[time.Time]AmountByParty
+}
+
+func NewTaxLedger() *TaxLedger {
+	return &TaxLedger{
+		ByMonth: make(map[time.Time]AmountByParty),
+	}
+}
+
+// Month returns first day of the month date belongs to in location of date,
+// used as ByMonth key.
+func Month(date time.Time) time.Time {
+	year, month, _ := date.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+}
+
+func (l *TaxLedger) Record(date time.Time, pilot entity.PartyID, amount entity.Amount) {
+	month := Month(date)
+	byParty, ok := l.ByMonth[month]
+	if !ok {
+		byParty = make(AmountByParty)
+		l.ByMonth[month] = byParty
+	}
+	byParty[pilot] += amount
+}
+
+// Total sums all months of the ledger, which covers exactly the period it was
+// accumulated for, first and last month may be partial.
+func (l *TaxLedger) Total() AmountByParty {
+	total := make(AmountByParty)
+	for _, byParty := range l.ByMonth {
+		total.sum(byParty)
+	}
+	return total
+}
+
+type MonthAmount struct {
+	Month  time.Time
+	Amount entity.Amount
+}
+
+// History returns tax paid by the pilot every month, oldest first, months
+// without any tax are included as zero.
+func (l *TaxLedger) History(pilot entity.PartyID) []MonthAmount {
+	months := make([]time.Time, 0, len(l.ByMonth))
+	for month := range l.ByMonth {
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+
+	history := make([]MonthAmount, 0, len(months))
+	for _, month := range months {
+		history = append(history, MonthAmount{
+			Month:  month,
+			Amount: l.ByMonth[month][pilot],
+		})
+	}
+	return history
+}
+
+// Pilots returns all pilots that paid any tax.
+func (l *TaxLedger) Pilots() []entity.PartyID {
+	seen := make(map[entity.PartyID]struct{})
+	var pilots []entity.PartyID
+	for _, byParty := range l.ByMonth {
+		for pilot := range byParty {
+			if _, ok := seen[pilot]; ok {
+				continue
+			}
+			seen[pilot] = struct{}{}
+			pilots = append(pilots, pilot)
+		}
+	}
+	return pilots
+}
@@ -0,0 +1,28 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+type Transaction struct {
+	ClientId     entity.ClientId      /* ID of the other party of the market transaction */
+	Date         time.Time            `storm:"index"` /* Date and time of transaction */
+	IsBuy        entity.IsBuy         /* True when the corporation bought the items */
+	JournalRefId entity.JournalRefId  /* ID of the corresponding wallet journal record, -1 if there is none */
+	LocationId   entity.LocationId    /* ID of the station or structure where the transaction happened */
+	Quantity     entity.Quantity      /* Number of items bought or sold */
+	Id           entity.TransactionId `storm:"id"`    /* Unique transaction ID */
+	TypeId       entity.TypeId        `storm:"index"` /* ID of the item type */
+	UnitPrice    entity.UnitPrice     /* Amount paid per unit */
+}
+
+// Amount returns ISK value of the transaction, negative for purchases.
+func (t Transaction) Amount() entity.Amount {
+	amount := entity.Amount(float64(t.Quantity) * float64(t.UnitPrice))
+	if t.IsBuy {
+		return -amount
+	}
+	return amount
+}
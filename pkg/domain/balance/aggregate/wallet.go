@@ -0,0 +1,44 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+// Wallet identifies division wallet of one corporation.
+type Wallet struct {
+	CorporationID entity.CorporationID
+	Division      entity.DivisionName
+}
+
+// WalletBalanceByDay holds balance of each wallet at the end of the day.
+type WalletBalanceByDay struct {
+	Timestamp time.Time
+	ByWallet  map[Wallet]entity.Balance
+}
+
+func NewWalletBalanceByDay(timestamp time.Time) *WalletBalanceByDay {
+	return &WalletBalanceByDay{
+		Timestamp: timestamp,
+		ByWallet:  make(map[Wallet]entity.Balance),
+	}
+}
+
+// Total returns balance of all wallets of all corporations.
+func (b *WalletBalanceByDay) Total() entity.Balance {
+	var total entity.Balance
+	for _, balance := range b.ByWallet {
+		total += balance
+	}
+	return total
+}
+
+// WalletRecord is a journal record of the wallet it was read from.
+type WalletRecord struct {
+	Wallet Wallet
+	Record JournalRecord
+	// Transaction is the market transaction the record was created by, nil
+	// for other ref types.
+	Transaction *Transaction
+}
@@ -0,0 +1,328 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/pkg/errors"
+)
+
+// Source identifies the corporation wallet division a journal record was read from.
+type Source struct {
+	CorporationID entity.CorporationID
+	Division      aggregate.Division
+}
+
+// DivisionName returns name of the division, master wallet has no name so it is
+// reported as "Main".
+func (s Source) DivisionName() entity.DivisionName {
+	return s.Division.DisplayName()
+}
+
+// Party returns ID of the other side of the transaction, journal records list
+// our corporation as either first or second party depending on the ref type.
+func (s Source) Party(record aggregate.JournalRecord) entity.PartyID {
+	if entity.CorporationID(record.FirstPartyId) == s.CorporationID {
+		return entity.PartyID(record.SecondPartyId)
+	}
+	return entity.PartyID(record.FirstPartyId)
+}
+
+// Pilot returns the character who generated a tax record. Both parties are
+// checked, skipping our corporation and NPC corporations and characters such
+// as CONCORD paying out the bounty.
+func (s Source) Pilot(record aggregate.JournalRecord) (entity.PartyID, bool) {
+	for _, party := range []entity.PartyID{entity.PartyID(record.SecondPartyId), entity.PartyID(record.FirstPartyId)} {
+		if party == 0 || entity.CorporationID(party) == s.CorporationID || isNPC(party) {
+			continue
+		}
+		return party, true
+	}
+	return 0, false
+}
+
+// Recipient returns the party ISK was paid to from our wallet, false for
+// income, transfers between our own wallets and payments to NPCs.
+func (s Source) Recipient(record aggregate.JournalRecord) (entity.PartyID, bool) {
+	if record.Amount >= 0 {
+		return 0, false
+	}
+	party := s.Party(record)
+	if party == 0 || entity.CorporationID(party) == s.CorporationID || isNPC(party) {
+		return 0, false
+	}
+	return party, true
+}
+
+// isNPC returns true for IDs of NPC corporations and characters.
+func isNPC(party entity.PartyID) bool {
+	return (party >= 1000000 && party < 2000000) || (party >= 3000000 && party < 4000000)
+}
+
+// Accumulator receives every journal record streamed by Aggregate.
+type Accumulator interface {
+	Accumulate(source Source, record aggregate.JournalRecord)
+}
+
+// AccumulatorFunc allows use of ordinary functions as Accumulator.
+type AccumulatorFunc func(source Source, record aggregate.JournalRecord)
+
+func (f AccumulatorFunc) Accumulate(source Source, record aggregate.JournalRecord) {
+	f(source, record)
+}
+
+// CorporationsError is returned by Aggregate when journals of some corporations
+// could not be loaded.
+type CorporationsError struct {
+	// Errors by corporation that failed to load.
+	Errors map[entity.CorporationID]error
+	// Partial is true when accumulators contain data of the remaining corporations.
+	Partial bool
+}
+
+func (e *CorporationsError) Error() string {
+	corporationIDs := make([]entity.CorporationID, 0, len(e.Errors))
+	for corporationID := range e.Errors {
+		corporationIDs = append(corporationIDs, corporationID)
+	}
+	sort.Slice(corporationIDs, func(i, j int) bool {
+		return corporationIDs[i] < corporationIDs[j]
+	})
+
+	msgs := make([]string, 0, len(corporationIDs))
+	for _, corporationID := range corporationIDs {
+		msgs = append(msgs, fmt.Sprintf("error loading balance for corporation: %d: %s", corporationID, e.Errors[corporationID]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// IsPartial returns true if err only means some corporations are missing from
+// otherwise valid results.
+func IsPartial(err error) bool {
+	var corporationsErr *CorporationsError
+	if errors.As(err, &corporationsErr) {
+		return corporationsErr.Partial
+	}
+	return false
+}
+
+// journalBuffer is how many records of each division are read ahead while
+// records of the previous divisions are accumulated.
+const journalBuffer = 256
+
+// sourceRecord is journal record held until its corporation is read whole.
+type sourceRecord struct {
+	source Source
+	record aggregate.JournalRecord
+}
+
+// divisionJournal streams records of one division, err is set before records
+// is closed.
+type divisionJournal struct {
+	ctx        context.Context
+	repository Repository
+	source     Source
+	records    chan aggregate.JournalRecord
+	err        error
+	// cancel stops the remaining divisions of the corporation.
+	cancel context.CancelFunc
+}
+
+// Aggregate lists divisions of every corporation once and streams each division
+// journal once, passing every record to all accumulators. Divisions are listed
+// and journals are read concurrently with at most s.workers in flight, records
+// are accumulated in repository and division order. With more corporations,
+// records of each corporation are held until all its divisions were read and
+// accumulated only then, so a corporation which fails is missing from the
+// results entirely. A single corporation is accumulated as records arrive, only
+// journalBuffer records of each division are held in memory, its failure fails
+// the whole aggregation.
+func (s *balanceService) Aggregate(ctx context.Context, period entity.Period, accumulators ...Accumulator) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		limiter   = make(chan struct{}, s.workers)
+		divisions = make([][]aggregate.Division, len(s.repositories))
+		errs      = make([]error, len(s.repositories))
+	)
+	for i, repository := range s.repositories {
+		wg.Add(1)
+		go func(i int, repository Repository) {
+			defer wg.Done()
+			errs[i] = limit(ctx, limiter, func() error {
+				var err error
+				divisions[i], err = repository.WalletDivisions(ctx)
+				return err
+			})
+			if errs[i] != nil {
+				errs[i] = errors.Wrapf(errs[i], "error listing divisions for corporation: %d", repository.CorporationID())
+			}
+		}(i, repository)
+	}
+	wg.Wait()
+
+	corporationsErr := &CorporationsError{
+		Errors: make(map[entity.CorporationID]error),
+	}
+	var journals []*divisionJournal
+	for i, repository := range s.repositories {
+		if errs[i] != nil {
+			corporationsErr.Errors[repository.CorporationID()] = errs[i]
+			continue
+		}
+		corporationCtx, corporationCancel := context.WithCancel(ctx)
+		defer corporationCancel()
+		for _, division := range divisions[i] {
+			journals = append(journals, &divisionJournal{
+				ctx:        corporationCtx,
+				repository: repository,
+				source: Source{
+					CorporationID: repository.CorporationID(),
+					Division:      division,
+				},
+				records: make(chan aggregate.JournalRecord, journalBuffer),
+				cancel:  corporationCancel,
+			})
+		}
+	}
+	go streamJournals(limiter, period, journals)
+
+	var (
+		hold    = len(s.repositories)-len(corporationsErr.Errors) > 1
+		pending []sourceRecord
+	)
+	accumulate := func(source Source, record aggregate.JournalRecord) {
+		for _, accumulator := range accumulators {
+			accumulator.Accumulate(source, record)
+		}
+	}
+	for i, journal := range journals {
+		_, failed := corporationsErr.Errors[journal.source.CorporationID]
+		for record := range journal.records {
+			switch {
+			case failed:
+			case hold:
+				pending = append(pending, sourceRecord{source: journal.source, record: record})
+			default:
+				accumulate(journal.source, record)
+			}
+		}
+		if !failed && journal.err != nil {
+			corporationsErr.Errors[journal.source.CorporationID] = journal.err
+			failed = true
+		}
+		if i+1 < len(journals) && journals[i+1].source.CorporationID == journal.source.CorporationID {
+			continue
+		}
+		// Last division of the corporation.
+		if !failed {
+			for _, pending := range pending {
+				accumulate(pending.source, pending.record)
+			}
+		}
+		pending = pending[:0]
+	}
+	return s.corporationsError(corporationsErr.Errors)
+}
+
+// corporationsError returns CorporationsError of the failed corporations, nil
+// when none failed.
+func (s *balanceService) corporationsError(failed map[entity.CorporationID]error) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	return &CorporationsError{
+		Errors:  failed,
+		Partial: len(failed) < len(s.repositories),
+	}
+}
+
+// eachCorporation calls f for every repository concurrently, with at most
+// s.workers calls in flight. Corporations for which f failed are reported by
+// CorporationsError, f should keep its results only when it succeeds.
+func (s *balanceService) eachCorporation(ctx context.Context, f func(i int, repository Repository) error) error {
+	var (
+		wg      sync.WaitGroup
+		limiter = make(chan struct{}, s.workers)
+		errs    = make([]error, len(s.repositories))
+	)
+	for i, repository := range s.repositories {
+		wg.Add(1)
+		go func(i int, repository Repository) {
+			defer wg.Done()
+			errs[i] = limit(ctx, limiter, func() error {
+				return f(i, repository)
+			})
+		}(i, repository)
+	}
+	wg.Wait()
+
+	failed := make(map[entity.CorporationID]error)
+	for i, repository := range s.repositories {
+		if errs[i] != nil {
+			failed[repository.CorporationID()] = errs[i]
+		}
+	}
+	return s.corporationsError(failed)
+}
+
+// streamJournals streams journals in the order they are accumulated, worker
+// slot is taken for each division before the following ones so the division
+// being accumulated never waits for a slot held by the next ones. First error
+// cancels the remaining divisions of the corporation.
+func streamJournals(limiter chan struct{}, period entity.Period, journals []*divisionJournal) {
+	for _, journal := range journals {
+		select {
+		case limiter <- struct{}{}:
+		case <-journal.ctx.Done():
+			journal.err = journal.ctx.Err()
+			close(journal.records)
+			continue
+		}
+		go func(journal *divisionJournal) {
+			defer func() { <-limiter }()
+			defer close(journal.records)
+
+			journal.err = streamJournal(period, journal)
+			if journal.err != nil {
+				journal.cancel()
+			}
+		}(journal)
+	}
+}
+
+// streamJournal forwards records of one division until its journal ends or
+// the corporation is cancelled.
+func streamJournal(period entity.Period, journal *divisionJournal) error {
+	journalRecords, err := journal.repository.WalletJournal(journal.ctx, journal.source.Division, period)
+	if err != nil {
+		return errors.Wrapf(err, "unable to list journal records for corporation: %d", journal.source.CorporationID)
+	}
+	for journalRecord := range journalRecords {
+		select {
+		case journal.records <- journalRecord:
+		case <-journal.ctx.Done():
+			return errors.Wrapf(journal.ctx.Err(), "error streaming journal records for corporation: %d", journal.source.CorporationID)
+		}
+	}
+	return journal.ctx.Err()
+}
+
+// limit runs f once there is a free slot in limiter.
+func limit(ctx context.Context, limiter chan struct{}, f func() error) error {
+	select {
+	case limiter <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-limiter }()
+
+	return f()
+}
@@ -0,0 +1,282 @@
+package balance
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/repository"
+
+	"github.com/asdine/storm/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	benchmarkCorporations      = 2
+	benchmarkDivisions         = 3
+	benchmarkDays              = 90
+	benchmarkRecordsPerDay     = 20
+	benchmarkFirstCorporation  = 98000001
+	benchmarkAmountsPerRefType = 7
+)
+
+var benchmarkRefTypes = []entity.RefType{"bounty_prizes", "market_transaction", "player_donation", "office_rental_fee", "corporation_account_withdrawal"}
+
+// seedESI serves generated journal pages for synchronization into the DB.
+type seedESI struct {
+	corporationID entity.CorporationID
+	start         time.Time
+}
+
+func (e *seedESI) CharacterID() entity.CharacterID     { return 0 }
+func (e *seedESI) CorporationID() entity.CorporationID { return e.corporationID }
+func (e *seedESI) WalletDivisions(ctx context.Context) ([]aggregate.Division, error) {
+	divisions := make([]aggregate.Division, benchmarkDivisions)
+	for i := range divisions {
+		divisions[i] = aggregate.Division{ID: entity.DivisionID(i + 1), Name: entity.DivisionName(fmt.Sprintf("Division %d", i+1))}
+	}
+	return divisions, nil
+}
+
+func (e *seedESI) WalletJournalPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.JournalRecord) bool) (time.Time, error) {
+	var (
+		page    []aggregate.JournalRecord
+		id      = entity.Id(int64(e.corporationID)*1000000 + int64(division.ID)*100000 + 1)
+		balance entity.Balance
+	)
+	for day := 0; day < benchmarkDays; day++ {
+		for i := 0; i < benchmarkRecordsPerDay; i++ {
+			amount := entity.Amount(1000000 * ((i % benchmarkAmountsPerRefType) - 3))
+			balance += entity.Balance(amount)
+			page = append(page, aggregate.JournalRecord{
+				Id:            id,
+				Date:          e.start.AddDate(0, 0, day).Add(time.Duration(i) * time.Hour),
+				Amount:        amount,
+				Balance:       balance,
+				RefType:       benchmarkRefTypes[i%len(benchmarkRefTypes)],
+				FirstPartyId:  entity.FirstPartyId(90000000 + i),
+				SecondPartyId: entity.SecondPartyId(e.corporationID),
+			})
+			id++
+		}
+	}
+	f(page)
+	return time.Time{}, nil
+}
+
+func (e *seedESI) WalletTransactionsPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.Transaction) bool) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// countingRepository counts journals streamed from the DB.
+type countingRepository struct {
+	Repository
+	calls *int64
+}
+
+func (r countingRepository) WalletJournal(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.JournalRecord, error) {
+	atomic.AddInt64(r.calls, 1)
+	return r.Repository.WalletJournal(ctx, division, period)
+}
+
+// seededService returns service over temporary DB with synchronized journals
+// of all benchmark corporations and the period they cover.
+func seededService(b *testing.B) (*balanceService, entity.Period, *int64) {
+	dir, err := ioutil.TempDir("", "eve-accountant-benchmark")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { os.RemoveAll(dir) })
+	db, err := storm.Open(filepath.Join(dir, "accountant.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	var (
+		calls        int64
+		start        = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		repositories []Repository
+	)
+	for i := 0; i < benchmarkCorporations; i++ {
+		persistent := repository.New(zap.NewNop(), db, &seedESI{corporationID: entity.CorporationID(benchmarkFirstCorporation + i), start: start})
+		err = persistent.Sync(context.Background())
+		if err != nil {
+			b.Fatal(err)
+		}
+		repositories = append(repositories, countingRepository{Repository: persistent, calls: &calls})
+	}
+	groups, err := NewRefTypeGroups("")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return NewService(4, groups, repositories...), entity.NewPeriod(start, start.AddDate(0, 0, benchmarkDays)), &calls
+}
+
+// BenchmarkAggregate streams every division journal once for all reports of
+// `!isk graph` and `!isk by`.
+func BenchmarkAggregate(b *testing.B) {
+	s, period, calls := seededService(b)
+	ctx := context.Background()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := s.Aggregate(ctx, period,
+			newBalanceAccumulator(),
+			newBalanceByDivisionAccumulator(),
+			newBalanceByTypeAccumulator(s.groups),
+			newBalanceByDayAccumulator(s.groups, period),
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(calls))/float64(b.N), "journals/op")
+}
+
+// BenchmarkAggregatePerReport streams the journals once per report, like the
+// methods did before they shared one aggregation.
+func BenchmarkAggregatePerReport(b *testing.B) {
+	s, period, calls := seededService(b)
+	ctx := context.Background()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, accumulator := range []Accumulator{
+			newBalanceAccumulator(),
+			newBalanceByDivisionAccumulator(),
+			newBalanceByTypeAccumulator(s.groups),
+		} {
+			err := s.Aggregate(ctx, period, accumulator)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+		// Daily balance was calculated by streaming journals of each day.
+		for day := period.Start; day.Before(period.End); day = day.AddDate(0, 0, 1) {
+			err := s.Aggregate(ctx, entity.NewPeriod(day, day.AddDate(0, 0, 1)), newBalanceByDivisionAccumulator())
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(calls))/float64(b.N), "journals/op")
+}
+
+// staticRepository serves the same records in every division, second division
+// fails to open when failSecond is set.
+type staticRepository struct {
+	Repository
+	corporationID entity.CorporationID
+	records       []aggregate.JournalRecord
+	failSecond    bool
+}
+
+func (r *staticRepository) CorporationID() entity.CorporationID { return r.corporationID }
+
+func (r *staticRepository) WalletDivisions(ctx context.Context) ([]aggregate.Division, error) {
+	return []aggregate.Division{{ID: 1}, {ID: 2, Name: "Second"}}, nil
+}
+
+func (r *staticRepository) WalletJournal(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.JournalRecord, error) {
+	if r.failSecond && division.ID == 2 {
+		return nil, fmt.Errorf("division %d failed", division.ID)
+	}
+	records := make(chan aggregate.JournalRecord)
+	go func() {
+		defer close(records)
+		for _, record := range r.records {
+			select {
+			case records <- record:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return records, nil
+}
+
+func TestAggregate(t *testing.T) {
+	var records []aggregate.JournalRecord
+	for i := 0; i < 3*journalBuffer; i++ {
+		records = append(records, aggregate.JournalRecord{Id: entity.Id(i), Amount: 1})
+	}
+	groups, err := NewRefTypeGroups("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(1, groups,
+		&staticRepository{corporationID: 1, records: records},
+		&staticRepository{corporationID: 2, records: records, failSecond: true},
+		&staticRepository{corporationID: 3, records: records},
+	)
+
+	var order []entity.CorporationID
+	balance := newBalanceByDivisionAccumulator()
+	err = s.Aggregate(context.Background(), entity.Period{}, balance, AccumulatorFunc(func(source Source, _ aggregate.JournalRecord) {
+		if len(order) == 0 || order[len(order)-1] != source.CorporationID {
+			order = append(order, source.CorporationID)
+		}
+	}))
+	if !IsPartial(err) {
+		t.Fatalf("expected partial error, got: %v", err)
+	}
+	corporationsErr := err.(*CorporationsError)
+	if _, ok := corporationsErr.Errors[2]; !ok || len(corporationsErr.Errors) != 1 {
+		t.Errorf("expected error of corporation 2 only, got: %v", corporationsErr.Errors)
+	}
+	// First division of the failing corporation is read before the second one
+	// fails, none of its records are accumulated.
+	if fmt.Sprint(order) != "[1 3]" {
+		t.Errorf("expected records of corporations 1 and 3 in order, got: %v", order)
+	}
+	expected := entity.Amount(2 * len(records))
+	if got := balance.balance.IncomeByDivision["Main"]; got != expected {
+		t.Errorf("expected income of main divisions %v, got %v", expected, got)
+	}
+	if got := balance.balance.IncomeByDivision["Second"]; got != expected {
+		t.Errorf("expected income of second divisions %v, got %v", expected, got)
+	}
+}
+
+func (r *staticRepository) WalletTransactions(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.Transaction, error) {
+	if r.failSecond && division.ID == 2 {
+		return nil, fmt.Errorf("division %d failed", division.ID)
+	}
+	transactions := make(chan aggregate.Transaction, 1)
+	transactions <- aggregate.Transaction{TypeId: 34, Quantity: 10, UnitPrice: 5, Date: period.End.Add(-time.Hour)}
+	close(transactions)
+	return transactions, nil
+}
+
+func TestMarketByItemPartial(t *testing.T) {
+	groups, err := NewRefTypeGroups("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(2, groups,
+		&staticRepository{corporationID: 1},
+		&staticRepository{corporationID: 2, failSecond: true},
+		&staticRepository{corporationID: 3},
+	)
+	period := entity.NewPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	market, err := s.MarketByItem(context.Background(), period)
+	if !IsPartial(err) {
+		t.Fatalf("expected partial error, got: %v", err)
+	}
+	if corporationsErr := err.(*CorporationsError); len(corporationsErr.Errors) != 1 || corporationsErr.Errors[2] == nil {
+		t.Errorf("expected error of corporation 2 only, got: %v", corporationsErr.Errors)
+	}
+	// Two divisions of corporations 1 and 3, nothing of corporation 2.
+	if item := market[34]; item == nil || item.SoldQuantity != 40 {
+		t.Errorf("expected 40 sold items, got %+v", item)
+	}
+}
@@ -13,4 +13,5 @@ type (
 	SecondPartyId int32   /* The id of the second party involved in the transaction. This attribute has no consistency and is different or non existant for particular ref_types. The description attribute will help make sense of what this attribute means. For more info about the given ID it can be dropped into the /universe/names/ ESI route to determine its type and name */
 	Tax           float64 /* Tax amount received. Only applies to tax related transactions */
 	TaxReceiverId int32   /* The corporation ID receiving any tax paid. Only applies to tax related transactions */
+	PartyID       int32   /* The id of the character, corporation or alliance on the other side of the transaction, regardless of whether it was recorded as first or second party */
 )
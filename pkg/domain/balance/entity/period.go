@@ -0,0 +1,144 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a half-open interval of time, records at Start are included and
+// records at End are not. Days and months of the period are calendar days in
+// location of Start.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewPeriod returns period from start up to, but not including, end.
+func NewPeriod(start, end time.Time) Period {
+	return Period{
+		Start: start,
+		End:   end,
+	}
+}
+
+// DaysPeriod returns period covering whole days from firstDay to lastDay
+// including the last day, in location of firstDay.
+func DaysPeriod(firstDay, lastDay time.Time) Period {
+	start := Midnight(firstDay)
+	return Period{
+		Start: start,
+		End:   Midnight(lastDay.In(start.Location())).AddDate(0, 0, 1),
+	}
+}
+
+// MonthPeriod returns calendar month t belongs to, in location of t.
+func MonthPeriod(t time.Time) Period {
+	year, month, _ := t.Date()
+	start := time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	return Period{
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+// Midnight returns start of the day t belongs to, in location of t.
+func Midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// Location returns location days of the period are counted in.
+func (p Period) Location() *time.Location {
+	return p.Start.Location()
+}
+
+// Contains returns true when t is within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// LastDay returns start of the last day the period includes.
+func (p Period) LastDay() time.Time {
+	return Midnight(p.End.Add(-1 * time.Nanosecond).In(p.Location()))
+}
+
+// Days returns start of every day of the period, first day is the day of Start.
+func (p Period) Days() []time.Time {
+	var days []time.Time
+	for day := Midnight(p.Start); day.Before(p.End); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// Day returns index of the day t belongs to in Days, false when t is outside
+// of the period. Days are counted by calendar so days shortened or extended by
+// daylight saving time are counted correctly.
+func (p Period) Day(t time.Time) (int, bool) {
+	if !p.Contains(t) {
+		return 0, false
+	}
+	first, day := calendarDay(p.Start), calendarDay(t.In(p.Location()))
+	return int(day.Sub(first) / (24 * time.Hour)), true
+}
+
+// calendarDay returns the date of t as midnight UTC, so that differences of
+// two dates are whole days.
+func calendarDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Previous returns period of the same length right before p. Periods of whole
+// calendar months are shifted by months, so previous of March is February,
+// other periods are shifted by their number of days.
+func (p Period) Previous() Period {
+	if months, ok := p.months(); ok {
+		return Period{
+			Start: p.Start.AddDate(0, -months, 0),
+			End:   p.Start,
+		}
+	}
+	if p.Start.Equal(Midnight(p.Start)) && p.End.Equal(Midnight(p.End)) {
+		days := len(p.Days())
+		return Period{
+			Start: p.Start.AddDate(0, 0, -days),
+			End:   p.Start,
+		}
+	}
+	return Period{
+		Start: p.Start.Add(-p.End.Sub(p.Start)),
+		End:   p.Start,
+	}
+}
+
+// YearAgo returns the same period one year earlier.
+func (p Period) YearAgo() Period {
+	return Period{
+		Start: p.Start.AddDate(-1, 0, 0),
+		End:   p.End.AddDate(-1, 0, 0),
+	}
+}
+
+// Until returns the period ending at end when it ends before p.End.
+func (p Period) Until(end time.Time) Period {
+	if end.Before(p.End) {
+		p.End = end
+	}
+	return p
+}
+
+// months returns number of calendar months of period made of whole months.
+func (p Period) months() (int, bool) {
+	startYear, startMonth, startDay := p.Start.Date()
+	endYear, endMonth, endDay := p.End.In(p.Location()).Date()
+	if startDay != 1 || endDay != 1 || !p.Start.Equal(Midnight(p.Start)) || !p.End.Equal(Midnight(p.End.In(p.Location()))) {
+		return 0, false
+	}
+	months := (endYear-startYear)*12 + int(endMonth) - int(startMonth)
+	return months, months > 0
+}
+
+func (p Period) String() string {
+	return fmt.Sprintf("%s - %s", p.Start.Format("2006-01-02"), p.LastDay().Format("2006-01-02"))
+}
@@ -0,0 +1,144 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+const testTimeFormat = "2006-01-02 15:04:05 MST"
+
+// prague changes to summer time on 2024-03-31 02:00 and back on 2024-10-27
+// 03:00, those days have 23 and 25 hours.
+func prague(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	return location
+}
+
+func TestPeriodContains(t *testing.T) {
+	location := prague(t)
+	march := MonthPeriod(time.Date(2024, 3, 15, 12, 0, 0, 0, location))
+	summerDay := DaysPeriod(time.Date(2024, 3, 31, 0, 0, 0, 0, location), time.Date(2024, 3, 31, 0, 0, 0, 0, location))
+	winterDay := DaysPeriod(time.Date(2024, 10, 27, 0, 0, 0, 0, location), time.Date(2024, 10, 27, 0, 0, 0, 0, location))
+
+	tests := []struct {
+		name   string
+		period Period
+		t      time.Time
+		want   bool
+	}{
+		{"start", march, time.Date(2024, 3, 1, 0, 0, 0, 0, location), true},
+		{"before start", march, time.Date(2024, 2, 29, 23, 59, 59, 0, location), false},
+		{"last second", march, time.Date(2024, 3, 31, 23, 59, 59, 0, location), true},
+		{"last nanosecond", march, time.Date(2024, 3, 31, 23, 59, 59, 999999999, location), true},
+		{"end", march, time.Date(2024, 4, 1, 0, 0, 0, 0, location), false},
+		{"start in UTC", march, time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC), true},
+		{"before start in UTC", march, time.Date(2024, 2, 29, 22, 59, 59, 0, time.UTC), false},
+		{"last second in UTC", march, time.Date(2024, 3, 31, 21, 59, 59, 0, time.UTC), true},
+		{"end in UTC", march, time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC), false},
+		{"before summer time", summerDay, time.Date(2024, 3, 31, 0, 59, 59, 0, time.UTC), true},
+		{"after summer time", summerDay, time.Date(2024, 3, 31, 21, 59, 59, 0, time.UTC), true},
+		{"after short day", summerDay, time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC), false},
+		{"winter time start", winterDay, time.Date(2024, 10, 26, 22, 0, 0, 0, time.UTC), true},
+		{"repeated hour", winterDay, time.Date(2024, 10, 27, 1, 30, 0, 0, time.UTC), true},
+		{"last second of long day", winterDay, time.Date(2024, 10, 27, 22, 59, 59, 0, time.UTC), true},
+		{"after long day", winterDay, time.Date(2024, 10, 27, 23, 0, 0, 0, time.UTC), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.period.Contains(test.t); got != test.want {
+				t.Errorf("expected %s contains %s to be %t", test.period.Start.Format(testTimeFormat), test.t.Format(testTimeFormat), test.want)
+			}
+		})
+	}
+}
+
+func TestMonthPeriod(t *testing.T) {
+	location := prague(t)
+	tests := []struct {
+		name      string
+		t         time.Time
+		wantStart string
+		wantEnd   string
+		wantHours float64
+	}{
+		{"summer time change", time.Date(2024, 3, 15, 12, 0, 0, 0, location), "2024-03-01 00:00:00 CET", "2024-04-01 00:00:00 CEST", 31*24 - 1},
+		{"winter time change", time.Date(2024, 10, 1, 0, 0, 0, 0, location), "2024-10-01 00:00:00 CEST", "2024-11-01 00:00:00 CET", 31*24 + 1},
+		{"first moment", time.Date(2024, 4, 1, 0, 0, 0, 0, location), "2024-04-01 00:00:00 CEST", "2024-05-01 00:00:00 CEST", 30 * 24},
+		{"last second", time.Date(2024, 2, 29, 23, 59, 59, 0, location), "2024-02-01 00:00:00 CET", "2024-03-01 00:00:00 CET", 29 * 24},
+		{"december", time.Date(2023, 12, 31, 23, 59, 59, 0, time.UTC), "2023-12-01 00:00:00 UTC", "2024-01-01 00:00:00 UTC", 31 * 24},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			period := MonthPeriod(test.t)
+			if got := period.Start.Format(testTimeFormat); got != test.wantStart {
+				t.Errorf("expected start %s, got %s", test.wantStart, got)
+			}
+			if got := period.End.Format(testTimeFormat); got != test.wantEnd {
+				t.Errorf("expected end %s, got %s", test.wantEnd, got)
+			}
+			if got := period.End.Sub(period.Start).Hours(); got != test.wantHours {
+				t.Errorf("expected %v hours, got %v", test.wantHours, got)
+			}
+			if !period.Contains(test.t) {
+				t.Errorf("expected month to contain %s", test.t.Format(testTimeFormat))
+			}
+		})
+	}
+}
+
+func TestPeriodLastDay(t *testing.T) {
+	location := prague(t)
+	tests := []struct {
+		name   string
+		period Period
+		want   string
+	}{
+		{"month ending with summer time change", MonthPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, location)), "2024-03-31 00:00:00 CET"},
+		{"month ending after winter time change", MonthPeriod(time.Date(2024, 10, 1, 0, 0, 0, 0, location)), "2024-10-31 00:00:00 CET"},
+		{"short day", DaysPeriod(time.Date(2024, 3, 31, 0, 0, 0, 0, location), time.Date(2024, 3, 31, 0, 0, 0, 0, location)), "2024-03-31 00:00:00 CET"},
+		{"long day", DaysPeriod(time.Date(2024, 10, 27, 0, 0, 0, 0, location), time.Date(2024, 10, 27, 23, 59, 59, 0, location)), "2024-10-27 00:00:00 CEST"},
+		{"end one second after midnight", NewPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, location), time.Date(2024, 3, 5, 0, 0, 1, 0, location)), "2024-03-05 00:00:00 CET"},
+		{"end at midnight in UTC", NewPeriod(time.Date(2024, 3, 1, 0, 0, 0, 0, location), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)), "2024-03-05 00:00:00 CET"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.period.LastDay().Format(testTimeFormat); got != test.want {
+				t.Errorf("expected last day %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPeriodDays(t *testing.T) {
+	location := prague(t)
+	period := DaysPeriod(time.Date(2024, 3, 30, 0, 0, 0, 0, location), time.Date(2024, 4, 1, 0, 0, 0, 0, location))
+
+	days := period.Days()
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days, got %v", days)
+	}
+	tests := []struct {
+		t    time.Time
+		want int
+	}{
+		{time.Date(2024, 3, 30, 0, 0, 0, 0, location), 0},
+		{time.Date(2024, 3, 30, 23, 59, 59, 0, location), 0},
+		{time.Date(2024, 3, 31, 0, 0, 0, 0, location), 1},
+		{time.Date(2024, 3, 31, 23, 59, 59, 0, location), 1},
+		{time.Date(2024, 4, 1, 0, 0, 0, 0, location), 2},
+		{time.Date(2024, 4, 1, 23, 59, 59, 0, location), 2},
+	}
+	for _, test := range tests {
+		day, ok := period.Day(test.t)
+		if !ok || day != test.want {
+			t.Errorf("expected %s to be day %d, got %d %t", test.t.Format(testTimeFormat), test.want, day, ok)
+		}
+	}
+	if _, ok := period.Day(time.Date(2024, 4, 2, 0, 0, 0, 0, location)); ok {
+		t.Error("expected end of the period to be outside of it")
+	}
+}
@@ -0,0 +1,12 @@
+package entity
+
+type (
+	ClientId      int32   /* ID of the other party of the market transaction */
+	IsBuy         bool    /* True when the corporation bought the items */
+	JournalRefId  int64   /* ID of the corresponding wallet journal record, -1 if there is none */
+	LocationId    int64   /* ID of the station or structure where the transaction happened */
+	Quantity      int32   /* Number of items bought or sold */
+	TransactionId int64   /* Unique transaction ID */
+	TypeId        int32   /* ID of the item type */
+	UnitPrice     float64 /* Amount paid per unit */
+)
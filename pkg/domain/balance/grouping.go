@@ -0,0 +1,239 @@
+package balance
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	marketTransactionType = entity.RefType("Market Transaction")
+	contractPriceType     = entity.RefType("Contracts")
+	playerWalletAction    = entity.RefType("Player Wallet Action")
+	cloneTaxType          = entity.RefType("Clone Tax")
+	industryTaxType       = entity.RefType("Industry Tax")
+	piTaxType             = entity.RefType("PI Tax")
+	rewardType            = entity.RefType("Krab Tax")
+	jobCostType           = entity.RefType("Job Costs")
+	feeType               = entity.RefType("Fee")
+
+	// defaultRefTypeGroups are used when no grouping file is configured.
+	defaultRefTypeGroups = map[entity.RefType]entity.RefType{
+		entity.RefType("market_transaction"):                marketTransactionType,
+		entity.RefType("contract_price"):                    contractPriceType,
+		entity.RefType("player_donation"):                   playerWalletAction,
+		entity.RefType("corporation_account_withdrawal"):    playerWalletAction,
+		entity.RefType("jump_clone_activation_fee"):         cloneTaxType,
+		entity.RefType("jump_clone_installation_fee"):       cloneTaxType,
+		entity.RefType("industry_job_tax"):                  industryTaxType,
+		entity.RefType("reprocessing_tax"):                  industryTaxType,
+		entity.RefType("planetary_export_tax"):              piTaxType,
+		entity.RefType("planetary_import_tax"):              piTaxType,
+		entity.RefType("contract_deposit_refund"):           contractPriceType,
+		entity.RefType("contract_auction_bid_refund"):       contractPriceType,
+		entity.RefType("contract_auction_sold"):             contractPriceType,
+		entity.RefType("insurance"):                         rewardType,
+		entity.RefType("bounty_prizes"):                     rewardType,
+		entity.RefType("corporate_reward_payout"):           rewardType,
+		entity.RefType("project_discovery_reward"):          rewardType,
+		entity.RefType("agent_mission_reward"):              rewardType,
+		entity.RefType("agent_mission_time_bonus_reward"):   rewardType,
+		entity.RefType("ess_escrow_transfer"):               rewardType,
+		entity.RefType("researching_technology"):            jobCostType,
+		entity.RefType("researching_time_productivity"):     jobCostType,
+		entity.RefType("copying"):                           jobCostType,
+		entity.RefType("researching_material_productivity"): jobCostType,
+		entity.RefType("reaction"):                          jobCostType,
+		entity.RefType("manufacturing"):                     jobCostType,
+		entity.RefType("alliance_maintainance_fee"):         feeType,
+		entity.RefType("office_rental_fee"):                 feeType,
+		entity.RefType("contract_sales_tax"):                contractPriceType,
+		entity.RefType("contract_brokers_fee_corp"):         contractPriceType,
+		entity.RefType("contract_auction_bid_corp"):         contractPriceType,
+		entity.RefType("contract_deposit_corp"):             contractPriceType,
+		entity.RefType("contract_reward_deposited_corp"):    contractPriceType,
+		entity.RefType("contract_price_payment_corp"):       contractPriceType,
+		entity.RefType("contract_reward_refund"):            contractPriceType,
+		entity.RefType("brokers_fee"):                       marketTransactionType,
+		entity.RefType("transaction_tax"):                   marketTransactionType,
+		entity.RefType("market_escrow"):                     marketTransactionType,
+	}
+)
+
+// RefTypeGroupsConfig is the format of the grouping file, each group lists raw
+// ESI ref types that belong to it, for example:
+//
+//	groups:
+//	  Market Transaction:
+//	    - market_transaction
+//	    - brokers_fee
+//
+// JSON files use the same structure.
+type RefTypeGroupsConfig struct {
+	Groups map[string][]string `yaml:"groups" json:"groups"`
+}
+
+// RefTypeGroups maps raw ESI ref types into groups shown in reports. Groups are
+// either the built-in default or loaded from a file which can be reloaded at
+// runtime.
+type RefTypeGroups struct {
+	path string
+
+	mu     sync.RWMutex
+	groups map[entity.RefType]entity.RefType
+}
+
+// NewRefTypeGroups returns groups loaded from path, or the built-in default
+// when path is empty.
+func NewRefTypeGroups(path string) (*RefTypeGroups, error) {
+	g := &RefTypeGroups{
+		path:   path,
+		groups: defaultRefTypeGroups,
+	}
+	if path == "" {
+		return g, nil
+	}
+	err := g.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Path returns file the groups are loaded from, empty for built-in default.
+func (g *RefTypeGroups) Path() string {
+	return g.path
+}
+
+// Reload reads the grouping file again, current groups are kept when the file
+// is invalid.
+func (g *RefTypeGroups) Reload() error {
+	if g.path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(g.path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading ref type groups file: %s", g.path)
+	}
+	groups, err := ParseRefTypeGroups(data, filepath.Ext(g.path))
+	if err != nil {
+		return errors.Wrapf(err, "error loading ref type groups file: %s", g.path)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.groups = groups
+	return nil
+}
+
+// Group returns group the ref type belongs to, or the ref type itself when it
+// is not grouped.
+func (g *RefTypeGroups) Group(refType entity.RefType) entity.RefType {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	group, ok := g.groups[refType]
+	if !ok {
+		return refType
+	}
+	return group
+}
+
+// RefTypes returns raw ref types of the group named like name ignoring case,
+// or name itself as raw ref type when there is no such group.
+func (g *RefTypeGroups) RefTypes(name entity.RefType) []entity.RefType {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var refTypes []entity.RefType
+	for refType, group := range g.groups {
+		if strings.EqualFold(string(group), string(name)) {
+			refTypes = append(refTypes, refType)
+		}
+	}
+	if len(refTypes) == 0 {
+		return []entity.RefType{name}
+	}
+	sort.Slice(refTypes, func(i, j int) bool {
+		return refTypes[i] < refTypes[j]
+	})
+	return refTypes
+}
+
+// IsGrouped returns true when the ref type belongs to some group.
+func (g *RefTypeGroups) IsGrouped(refType entity.RefType) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	_, ok := g.groups[refType]
+	return ok
+}
+
+// ParseRefTypeGroups parses and validates grouping config, ext selects the
+// format (".json", ".yaml" or ".yml").
+func ParseRefTypeGroups(data []byte, ext string) (map[entity.RefType]entity.RefType, error) {
+	var config RefTypeGroupsConfig
+	switch strings.ToLower(ext) {
+	// JSON is a subset of YAML, strict YAML decoder rejects unknown fields and
+	// duplicate keys in both.
+	case ".json", ".yaml", ".yml":
+		err := yaml.UnmarshalStrict(data, &config)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid ref type groups")
+		}
+	default:
+		return nil, errors.Errorf("unsupported ref type groups format: %q, use .yaml, .yml or .json", ext)
+	}
+
+	return config.refTypeGroups()
+}
+
+// refTypeGroups validates the config and inverts it into ref type -> group map.
+func (c RefTypeGroupsConfig) refTypeGroups() (map[entity.RefType]entity.RefType, error) {
+	if len(c.Groups) == 0 {
+		return nil, errors.New("no ref type groups defined")
+	}
+
+	groupNames := make([]string, 0, len(c.Groups))
+	for group := range c.Groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+
+	var (
+		problems []string
+		groups   = make(map[entity.RefType]entity.RefType)
+	)
+	for _, group := range groupNames {
+		if strings.TrimSpace(group) == "" {
+			problems = append(problems, "group name must not be empty")
+			continue
+		}
+		if len(c.Groups[group]) == 0 {
+			problems = append(problems, fmt.Sprintf("group %q has no ref types", group))
+		}
+		for _, refType := range c.Groups[group] {
+			if strings.TrimSpace(refType) == "" || refType != strings.TrimSpace(refType) {
+				problems = append(problems, fmt.Sprintf("group %q contains invalid ref type %q", group, refType))
+				continue
+			}
+			if other, ok := groups[entity.RefType(refType)]; ok {
+				problems = append(problems, fmt.Sprintf("ref type %q is in both groups %q and %q", refType, other, group))
+				continue
+			}
+			groups[entity.RefType(refType)] = entity.RefType(group)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("invalid ref type groups: %s", strings.Join(problems, "; "))
+	}
+	return groups, nil
+}
@@ -11,6 +11,17 @@ import (
 type Repository interface {
 	CharacterID() entity.CharacterID
 	CorporationID() entity.CorporationID
+	UpdatedAt(ctx context.Context) (time.Time, error)
 	WalletDivisions(ctx context.Context) ([]aggregate.Division, error)
-	WalletJournal(ctx context.Context, division aggregate.Division, from, to time.Time) (chan aggregate.JournalRecord, error)
+	WalletJournal(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.JournalRecord, error)
+	// WalletBalance returns balance of the division wallet before at, zero when
+	// there are no older records.
+	WalletBalance(ctx context.Context, division aggregate.Division, at time.Time) (entity.Balance, error)
+	WalletTransactions(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.Transaction, error)
+	// WalletTransaction returns market transaction of the division by ID, nil
+	// when it is not stored.
+	WalletTransaction(ctx context.Context, division aggregate.Division, id entity.TransactionId) (*aggregate.Transaction, error)
+	// SearchJournal returns journal records of the division matching the
+	// query, none when the division does not match it.
+	SearchJournal(ctx context.Context, division aggregate.Division, query aggregate.JournalQuery) ([]aggregate.JournalRecord, error)
 }
@@ -9,7 +9,6 @@ import (
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
 	authService "github.com/lunemec/eve-bot-pkg/services/auth"
-	"gopkg.in/tomb.v2"
 
 	"github.com/antihax/goesi"
 	"github.com/antihax/goesi/esi"
@@ -84,56 +83,112 @@ func (r *repository) WalletDivisions(ctx context.Context) ([]aggregate.Division,
 	return divisions, nil
 }
 
-func (r *repository) WalletJournal(ctx context.Context, division aggregate.Division, _, _ time.Time) (chan aggregate.JournalRecord, error) {
+// WalletJournalPages calls f with every page of the division journal, starting
+// with the newest records. Pagination stops when f returns false. Returned time
+// is when ESI cache of the journal expires.
+func (r *repository) WalletJournalPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.JournalRecord) bool) (time.Time, error) {
 	ctx = r.ctx(ctx)
-	t, ctx := tomb.WithContext(ctx)
-	journals := make(chan aggregate.JournalRecord)
-
-	t.Go(func() error {
-		defer close(journals)
+	journalPage, resp, err := r.esi.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionJournal(
+		ctx,
+		int32(r.corporationID),
+		int32(division.ID),
+		nil,
+	)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to get wallet journal for division: %s (%d)", division.Name, division.ID)
+	}
+	expires := goesi.CacheExpires(resp)
+	if !f(mapJournalPageToSliceAggregateJournalRecord(journalPage)) {
+		return expires, nil
+	}
 
-		journalPage, resp, err := r.esi.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionJournal(
+	pages, err := strconv.Atoi(resp.Header.Get("X-Pages"))
+	if err != nil {
+		return expires, errors.Wrap(err, "error converting X-Pages to integer")
+	}
+	// Fetch additional pages if any (starting page above is 1).
+	for i := 2; i <= pages; i++ {
+		journalPage, _, err := r.esi.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionJournal(
 			ctx,
 			int32(r.corporationID),
 			int32(division.ID),
-			nil,
+			&esi.GetCorporationsCorporationIdWalletsDivisionJournalOpts{
+				Page: optional.NewInt32(int32(i)),
+			},
 		)
 		if err != nil {
-			return errors.Wrapf(err, "unable to get wallet journal for division: %s (%d)", division.Name, division.ID)
+			return expires, errors.Wrapf(err, "unable to get wallet journal page: %d for division: %s (%d)", i, division.Name, division.ID)
 		}
-		sendJournalPageToSliceAggregateJournalRecord(journalPage, journals)
+		if !f(mapJournalPageToSliceAggregateJournalRecord(journalPage)) {
+			return expires, nil
+		}
+	}
+	return expires, nil
+}
 
-		pages, err := strconv.Atoi(resp.Header.Get("X-Pages"))
+// WalletTransactionsPages calls f with pages of the division market transactions,
+// starting with the newest ones. ESI returns transactions older than from_id,
+// pagination stops when f returns false or there are no older transactions.
+// Returned time is when ESI cache of the transactions expires.
+func (r *repository) WalletTransactionsPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.Transaction) bool) (time.Time, error) {
+	ctx = r.ctx(ctx)
+	var (
+		expires time.Time
+		opts    esi.GetCorporationsCorporationIdWalletsDivisionTransactionsOpts
+		fromID  int64
+	)
+	for {
+		transactionsPage, resp, err := r.esi.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionTransactions(
+			ctx,
+			int32(r.corporationID),
+			int32(division.ID),
+			&opts,
+		)
 		if err != nil {
-			return errors.Wrap(err, "error converting X-Pages to integer")
+			return expires, errors.Wrapf(err, "unable to get wallet transactions from id: %d for division: %s (%d)", fromID, division.Name, division.ID)
+		}
+		if expires.IsZero() {
+			expires = goesi.CacheExpires(resp)
 		}
-		// Fetch additional pages if any (starting page above is 1).
-		for i := 2; i <= pages; i++ {
-			journalPage, _, err := r.esi.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionJournal(
-				ctx,
-				int32(r.corporationID),
-				int32(division.ID),
-				&esi.GetCorporationsCorporationIdWalletsDivisionJournalOpts{
-					Page: optional.NewInt32(int32(i)),
-				},
-			)
-			if err != nil {
-				return errors.Wrapf(err, "unable to get wallet journal page: %d for division: %s (%d)", i, division.Name, division.ID)
+
+		var (
+			page  = make([]aggregate.Transaction, 0, len(transactionsPage))
+			older = false
+		)
+		for _, transaction := range transactionsPage {
+			if fromID == 0 || transaction.TransactionId < fromID {
+				fromID = transaction.TransactionId
+				older = true
 			}
-			sendJournalPageToSliceAggregateJournalRecord(journalPage, journals)
+			page = append(page, mapWalletsDivisionTransactionToAggregateTransaction(transaction))
+		}
+		if !older || !f(page) {
+			return expires, nil
 		}
-		return nil
-	})
-	return journals, nil
+		opts.FromId = optional.NewInt64(fromID)
+	}
 }
 
-func sendJournalPageToSliceAggregateJournalRecord(in []esi.GetCorporationsCorporationIdWalletsDivisionJournal200Ok, out chan aggregate.JournalRecord) {
-	if len(in) == 0 {
-		return
+func mapWalletsDivisionTransactionToAggregateTransaction(in esi.GetCorporationsCorporationIdWalletsDivisionTransactions200Ok) aggregate.Transaction {
+	return aggregate.Transaction{
+		ClientId:     entity.ClientId(in.ClientId),
+		Date:         in.Date,
+		IsBuy:        entity.IsBuy(in.IsBuy),
+		JournalRefId: entity.JournalRefId(in.JournalRefId),
+		LocationId:   entity.LocationId(in.LocationId),
+		Quantity:     entity.Quantity(in.Quantity),
+		Id:           entity.TransactionId(in.TransactionId),
+		TypeId:       entity.TypeId(in.TypeId),
+		UnitPrice:    entity.UnitPrice(in.UnitPrice),
 	}
+}
+
+func mapJournalPageToSliceAggregateJournalRecord(in []esi.GetCorporationsCorporationIdWalletsDivisionJournal200Ok) []aggregate.JournalRecord {
+	out := make([]aggregate.JournalRecord, 0, len(in))
 	for _, inJournalRecord := range in {
-		out <- mapWalletsDivisionJournalToAggregateJournalRecord(inJournalRecord)
+		out = append(out, mapWalletsDivisionJournalToAggregateJournalRecord(inJournalRecord))
 	}
+	return out
 }
 
 func mapWalletsDivisionJournalToAggregateJournalRecord(in esi.GetCorporationsCorporationIdWalletsDivisionJournal200Ok) aggregate.JournalRecord {
@@ -3,36 +3,72 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
-	"github.com/lunemec/eve-accountant/pkg/domain/balance"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
 	"github.com/pkg/errors"
 	"gopkg.in/tomb.v2"
 
 	"github.com/asdine/storm/v3"
+	"go.uber.org/zap"
 )
 
+// esiRepository is the source of journal records, pages are returned newest
+// first so that synchronization can stop at already stored records.
+type esiRepository interface {
+	CharacterID() entity.CharacterID
+	CorporationID() entity.CorporationID
+	WalletDivisions(ctx context.Context) ([]aggregate.Division, error)
+	WalletJournalPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.JournalRecord) bool) (time.Time, error)
+	WalletTransactionsPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.Transaction) bool) (time.Time, error)
+}
+
 type persistentRepository struct {
+	log           *zap.Logger
 	db            *storm.DB
 	corpNode      storm.Node
-	esiRepository balance.Repository
+	esiRepository esiRepository
 }
 
 const (
-	journalNodeKey = "journal"
+	journalNodeKey      = "journal"
+	transactionsNodeKey = "transactions"
 
-	metadataKey = "metadata"
+	metadataKey  = "metadata"
+	divisionsKey = "divisions"
+
+	// rangePageSize is how many records are read from DB at once.
+	rangePageSize = 1000
 )
 
+// Metadata of the last synchronization, stored for the corporation and for
+// each of its divisions.
 type Metadata struct {
-	Metadata  string `storm:"id,unique"`
-	UpdatedAt time.Time
+	Metadata          string `storm:"id,unique"`
+	UpdatedAt         time.Time
+	ExpiresAt         time.Time            // When ESI cache of the division journal expires.
+	LastJournalID     entity.Id            // Highest journal ID stored in the division.
+	LastTransactionID entity.TransactionId // Highest market transaction ID stored in the division.
+}
+
+// Divisions are wallet divisions of the corporation as of the last synchronization.
+type Divisions struct {
+	Divisions string `storm:"id,unique"`
+	Wallet    []aggregate.Division
+}
+
+// syncStats are logged after each division synchronization.
+type syncStats struct {
+	Pages    int
+	Inserted int
+	Skipped  int
 }
 
-func New(db *storm.DB, esiRepository balance.Repository) *persistentRepository {
+func New(log *zap.Logger, db *storm.DB, esiRepository esiRepository) *persistentRepository {
 	return &persistentRepository{
+		log:           log,
 		db:            db,
 		corpNode:      corporationNode(db, esiRepository.CorporationID()),
 		esiRepository: esiRepository,
@@ -47,90 +83,401 @@ func (r *persistentRepository) CorporationID() entity.CorporationID {
 	return r.esiRepository.CorporationID()
 }
 
+// WalletDivisions returns divisions stored by the last synchronization, there
+// are none until the first one finishes.
 func (r *persistentRepository) WalletDivisions(ctx context.Context) ([]aggregate.Division, error) {
-	return r.esiRepository.WalletDivisions(ctx)
+	var divisions Divisions
+	err := r.corpNode.One("Divisions", divisionsKey, &divisions)
+	if err != nil {
+		if errors.Is(err, storm.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "error loading divisions")
+	}
+	return divisions.Wallet, nil
+}
+
+// UpdatedAt returns when the corporation was last synchronized with ESI.
+func (r *persistentRepository) UpdatedAt(ctx context.Context) (time.Time, error) {
+	metadata, err := r.metadata(r.corpNode)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error loading metadata")
+	}
+	return metadata.UpdatedAt, nil
+}
+
+// WalletJournal reads journal records from local DB only, use Sync to download
+// new records from ESI. Records are read by pages of rangePageSize, so long
+// periods are not held in memory.
+func (r *persistentRepository) WalletJournal(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.JournalRecord, error) {
+	journalNode := r.divisionNode(division).From(journalNodeKey)
+
+	journalsChan := make(chan aggregate.JournalRecord)
+	t, _ := tomb.WithContext(ctx)
+	t.Go(func() error {
+		defer close(journalsChan)
+
+		min, max := rangeBounds(period)
+		for skip := 0; ; skip += rangePageSize {
+			var journals []aggregate.JournalRecord
+			err := journalNode.Range("Date", min, max, &journals, storm.Skip(skip), storm.Limit(rangePageSize))
+			if err != nil {
+				return errors.Wrap(err, "error fetching journals from DB")
+			}
+			for _, journal := range journals {
+				if !period.Contains(journal.Date) {
+					continue
+				}
+				select {
+				case journalsChan <- journal:
+				case <-t.Dying():
+					return nil
+				}
+			}
+			if len(journals) < rangePageSize {
+				return nil
+			}
+		}
+	})
+
+	return journalsChan, nil
 }
 
-func (r *persistentRepository) WalletJournal(ctx context.Context, division aggregate.Division, from, to time.Time) (chan aggregate.JournalRecord, error) {
-	divisionNode := r.divisionNode(division)
-	journalNode := divisionNode.From(journalNodeKey)
+// WalletBalance returns balance after the last journal record stored before at.
+func (r *persistentRepository) WalletBalance(ctx context.Context, division aggregate.Division, at time.Time) (entity.Balance, error) {
+	journalNode := r.divisionNode(division).From(journalNodeKey)
 
-	lastUpdatedAt, err := r.updatedAt(divisionNode)
+	var journals []aggregate.JournalRecord
+	_, max := rangeBounds(entity.NewPeriod(time.Time{}, at))
+	err := journalNode.Range("Date", time.Time{}, max, &journals, storm.Reverse(), storm.Limit(1))
 	if err != nil {
-		return nil, errors.Wrap(err, "error checking last update date for journal")
+		if errors.Is(err, storm.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "error fetching last journal from DB")
+	}
+	if len(journals) == 0 {
+		return 0, nil
 	}
-	if time.Since(lastUpdatedAt) > 30*time.Minute {
-		err = r.updateFromESI(ctx, journalNode, division)
+	return journals[0].Balance, nil
+}
+
+// SearchJournal reads journal records matching the query from local DB only,
+// newest first. Records of the ref types are looked up by RefType index,
+// without ref types records of the period are read by Date index. Records are
+// read in pages of rangePageSize until query.Limit records match.
+func (r *persistentRepository) SearchJournal(ctx context.Context, division aggregate.Division, query aggregate.JournalQuery) ([]aggregate.JournalRecord, error) {
+	if !query.MatchesDivision(division) {
+		return nil, nil
+	}
+	journalNode := r.divisionNode(division).From(journalNodeKey)
+
+	if len(query.RefTypes) == 0 {
+		min, max := rangeBounds(query.Period)
+		matching, err := searchPages(ctx, query, func(skip int, records *[]aggregate.JournalRecord) error {
+			return journalNode.Range("Date", min, max, records, storm.Skip(skip), storm.Limit(rangePageSize), storm.Reverse())
+		})
 		if err != nil {
-			return nil, errors.Wrap(err, "error updating local DB from ESI")
+			return nil, errors.Wrap(err, "error fetching journals from DB")
 		}
-		err = r.recordUpdatedAt(divisionNode)
+		return matching, nil
+	}
+
+	var matching []aggregate.JournalRecord
+	for _, refType := range query.RefTypes {
+		refType := refType
+		refTypeMatching, err := searchPages(ctx, query, func(skip int, records *[]aggregate.JournalRecord) error {
+			err := journalNode.Find("RefType", refType, records, storm.Skip(skip), storm.Limit(rangePageSize), storm.Reverse())
+			if errors.Is(err, storm.ErrNotFound) {
+				return nil
+			}
+			return err
+		})
 		if err != nil {
-			return nil, errors.Wrap(err, "error saving current update date")
+			return nil, errors.Wrapf(err, "error fetching journals of ref type: %s from DB", refType)
+		}
+		matching = append(matching, refTypeMatching...)
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		if matching[i].Date.Equal(matching[j].Date) {
+			return matching[i].Id > matching[j].Id
 		}
+		return matching[i].Date.After(matching[j].Date)
+	})
+	if query.Limit > 0 && len(matching) > query.Limit {
+		matching = matching[:query.Limit]
 	}
+	return matching, nil
+}
 
-	journalsChan := make(chan aggregate.JournalRecord)
+// searchPages reads pages of records with read until a page is not full or
+// query.Limit records match.
+func searchPages(ctx context.Context, query aggregate.JournalQuery, read func(skip int, records *[]aggregate.JournalRecord) error) ([]aggregate.JournalRecord, error) {
+	var matching []aggregate.JournalRecord
+	for skip := 0; ; skip += rangePageSize {
+		var records []aggregate.JournalRecord
+		err := read(skip, &records)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if !query.Matches(record) {
+				continue
+			}
+			matching = append(matching, record)
+			if query.Limit > 0 && len(matching) >= query.Limit {
+				return matching, nil
+			}
+		}
+		if len(records) < rangePageSize {
+			return matching, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WalletTransactions reads market transactions from local DB only by pages
+// like WalletJournal, use Sync to download new transactions from ESI.
+func (r *persistentRepository) WalletTransactions(ctx context.Context, division aggregate.Division, period entity.Period) (chan aggregate.Transaction, error) {
+	transactionsNode := r.divisionNode(division).From(transactionsNodeKey)
+
+	transactionsChan := make(chan aggregate.Transaction)
 	t, _ := tomb.WithContext(ctx)
 	t.Go(func() error {
-		defer close(journalsChan)
+		defer close(transactionsChan)
 
-		var journals []aggregate.JournalRecord
-		err := journalNode.Range("Date", from, to, &journals)
+		min, max := rangeBounds(period)
+		for skip := 0; ; skip += rangePageSize {
+			var transactions []aggregate.Transaction
+			err := transactionsNode.Range("Date", min, max, &transactions, storm.Skip(skip), storm.Limit(rangePageSize))
+			if err != nil {
+				return errors.Wrap(err, "error fetching transactions from DB")
+			}
+			for _, transaction := range transactions {
+				if !period.Contains(transaction.Date) {
+					continue
+				}
+				select {
+				case transactionsChan <- transaction:
+				case <-t.Dying():
+					return nil
+				}
+			}
+			if len(transactions) < rangePageSize {
+				return nil
+			}
+		}
+	})
+
+	return transactionsChan, nil
+}
+
+// WalletTransaction reads market transaction by ID from local DB only.
+func (r *persistentRepository) WalletTransaction(ctx context.Context, division aggregate.Division, id entity.TransactionId) (*aggregate.Transaction, error) {
+	var transaction aggregate.Transaction
+	err := r.divisionNode(division).From(transactionsNodeKey).One("Id", id, &transaction)
+	if err != nil {
+		if errors.Is(err, storm.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error fetching transaction: %d from DB", id)
+	}
+	return &transaction, nil
+}
+
+// Sync downloads wallet divisions and new journal records and market
+// transactions of every division whose ESI cache expired.
+func (r *persistentRepository) Sync(ctx context.Context) error {
+	divisions, err := r.esiRepository.WalletDivisions(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error listing divisions from ESI")
+	}
+	err = r.corpNode.Save(&Divisions{Divisions: divisionsKey, Wallet: divisions})
+	if err != nil {
+		return errors.Wrap(err, "error saving divisions")
+	}
+
+	for _, division := range divisions {
+		divisionNode := r.divisionNode(division)
+		metadata, err := r.metadata(divisionNode)
 		if err != nil {
-			return errors.Wrap(err, "error fetching journals from DB")
+			return errors.Wrap(err, "error checking last update date for journal")
 		}
-		for _, journal := range journals {
-			journalsChan <- journal
+		if time.Now().Before(metadata.ExpiresAt) {
+			continue
 		}
-		return nil
-	})
+		metadata, err = r.updateJournalFromESI(ctx, divisionNode, division, metadata)
+		if err != nil {
+			return errors.Wrapf(err, "error updating local DB from ESI for division: %s (%d)", division.Name, division.ID)
+		}
+		_, err = r.updateTransactionsFromESI(ctx, divisionNode, division, metadata)
+		if err != nil {
+			return errors.Wrapf(err, "error updating local DB from ESI for division: %s (%d)", division.Name, division.ID)
+		}
+	}
 
-	return journalsChan, nil
+	err = r.corpNode.Save(&Metadata{Metadata: metadataKey, UpdatedAt: time.Now()})
+	if err != nil {
+		return errors.Wrap(err, "unable to update metadata")
+	}
+	return nil
 }
 
-func (r *persistentRepository) updatedAt(node storm.Node) (time.Time, error) {
+func (r *persistentRepository) metadata(node storm.Node) (Metadata, error) {
 	var metadata Metadata
 	err := node.One("Metadata", metadataKey, &metadata)
 	if err != nil {
 		if errors.Is(err, storm.ErrNotFound) {
-			err = node.Save(&Metadata{Metadata: metadataKey})
+			metadata = Metadata{Metadata: metadataKey}
+			err = node.Save(&metadata)
 			if err != nil {
-				return metadata.UpdatedAt, errors.Wrap(err, "error saving metadata")
+				return metadata, errors.Wrap(err, "error saving metadata")
 			}
-			return metadata.UpdatedAt, nil
+			return metadata, nil
 		}
-		return metadata.UpdatedAt, errors.Wrap(err, "error loading metadata")
+		return metadata, errors.Wrap(err, "error loading metadata")
 	}
-	return metadata.UpdatedAt, nil
+	return metadata, nil
 }
 
-func (r *persistentRepository) recordUpdatedAt(node storm.Node) error {
-	err := node.Save(&Metadata{Metadata: metadataKey, UpdatedAt: time.Now()})
+// updateJournalFromESI downloads journal pages until it reaches records that are
+// already stored, and saves only the new ones. Metadata is updated in the same
+// transaction so a failed sync is retried from the same point.
+func (r *persistentRepository) updateJournalFromESI(ctx context.Context, divisionNode storm.Node, division aggregate.Division, metadata Metadata) (Metadata, error) {
+	var (
+		stats      syncStats
+		newRecords []aggregate.JournalRecord
+	)
+	lastJournalID := metadata.LastJournalID
+	expiresAt, err := r.esiRepository.WalletJournalPages(ctx, division, func(page []aggregate.JournalRecord) bool {
+		stats.Pages++
+		reachedStored := false
+		for _, journalRecord := range page {
+			if journalRecord.Id <= metadata.LastJournalID {
+				stats.Skipped++
+				reachedStored = true
+				continue
+			}
+			if journalRecord.Id > lastJournalID {
+				lastJournalID = journalRecord.Id
+			}
+			newRecords = append(newRecords, journalRecord)
+		}
+		return !reachedStored
+	})
 	if err != nil {
-		return errors.Wrap(err, "unable to update metadata")
+		return metadata, errors.Wrap(err, "error calling esi")
 	}
-	return nil
+
+	tx, err := divisionNode.Begin(true)
+	if err != nil {
+		return metadata, errors.Wrap(err, "unable to begin tx")
+	}
+	defer tx.Rollback()
+	journalNode := tx.From(journalNodeKey)
+	for i := range newRecords {
+		err = journalNode.Save(&newRecords[i])
+		if err != nil {
+			return metadata, errors.Wrap(err, "error updating journal data")
+		}
+	}
+	stats.Inserted = len(newRecords)
+
+	metadata.UpdatedAt = time.Now()
+	metadata.ExpiresAt = expiresAt
+	metadata.LastJournalID = lastJournalID
+	err = tx.Save(&metadata)
+	if err != nil {
+		return metadata, errors.Wrap(err, "unable to update metadata")
+	}
+	err = tx.Commit()
+	if err != nil {
+		return metadata, errors.Wrap(err, "error commiting tx")
+	}
+
+	r.log.Info("Journal synchronized",
+		zap.Int32("corporation_id", int32(r.CorporationID())),
+		zap.Int32("division_id", int32(division.ID)),
+		zap.Int("pages", stats.Pages),
+		zap.Int("inserted", stats.Inserted),
+		zap.Int("skipped", stats.Skipped),
+	)
+	return metadata, nil
 }
 
-func (r *persistentRepository) updateFromESI(ctx context.Context, node storm.Node, division aggregate.Division) error {
-	esiJournals, err := r.esiRepository.WalletJournal(ctx, division, time.Time{}, time.Time{})
+// updateTransactionsFromESI downloads market transactions until it reaches
+// already stored ones, the same way as updateJournalFromESI.
+func (r *persistentRepository) updateTransactionsFromESI(ctx context.Context, divisionNode storm.Node, division aggregate.Division, metadata Metadata) (Metadata, error) {
+	var (
+		stats           syncStats
+		newTransactions []aggregate.Transaction
+	)
+	lastTransactionID := metadata.LastTransactionID
+	expiresAt, err := r.esiRepository.WalletTransactionsPages(ctx, division, func(page []aggregate.Transaction) bool {
+		stats.Pages++
+		reachedStored := false
+		for _, transaction := range page {
+			if transaction.Id <= metadata.LastTransactionID {
+				stats.Skipped++
+				reachedStored = true
+				continue
+			}
+			if transaction.Id > lastTransactionID {
+				lastTransactionID = transaction.Id
+			}
+			newTransactions = append(newTransactions, transaction)
+		}
+		return !reachedStored
+	})
 	if err != nil {
-		return errors.Wrap(err, "error calling esi")
+		return metadata, errors.Wrap(err, "error calling esi")
 	}
-	tx, err := node.Begin(true)
+
+	tx, err := divisionNode.Begin(true)
 	if err != nil {
-		return errors.Wrap(err, "unable to begin tx")
+		return metadata, errors.Wrap(err, "unable to begin tx")
 	}
 	defer tx.Rollback()
-	for esiJournal := range esiJournals {
-		err = tx.Save(&esiJournal)
+	transactionsNode := tx.From(transactionsNodeKey)
+	for i := range newTransactions {
+		err = transactionsNode.Save(&newTransactions[i])
 		if err != nil {
-			return errors.Wrap(err, "error updating journal data")
+			return metadata, errors.Wrap(err, "error updating transactions data")
 		}
 	}
+	stats.Inserted = len(newTransactions)
+
+	if expiresAt.After(metadata.ExpiresAt) {
+		metadata.ExpiresAt = expiresAt
+	}
+	metadata.LastTransactionID = lastTransactionID
+	err = tx.Save(&metadata)
+	if err != nil {
+		return metadata, errors.Wrap(err, "unable to update metadata")
+	}
+	err = tx.Commit()
+	if err != nil {
+		return metadata, errors.Wrap(err, "error commiting tx")
+	}
+
+	r.log.Info("Market transactions synchronized",
+		zap.Int32("corporation_id", int32(r.CorporationID())),
+		zap.Int32("division_id", int32(division.ID)),
+		zap.Int("pages", stats.Pages),
+		zap.Int("inserted", stats.Inserted),
+		zap.Int("skipped", stats.Skipped),
+	)
+	return metadata, nil
+}
 
-	return errors.Wrap(tx.Commit(), "error commiting tx")
+// rangeBounds returns inclusive bounds of storm Range covering the period.
+// Dates are indexed as JSON strings compared byte by byte, so the bounds must
+// be in UTC like the stored ESI dates, and the upper bound is the last whole
+// second because "23:59:59.999Z" sorts before "23:59:59Z".
+func rangeBounds(period entity.Period) (time.Time, time.Time) {
+	return period.Start.UTC(), period.End.UTC().Add(-1 * time.Second)
 }
 
 func corporationNode(db *storm.DB, id entity.CorporationID) storm.Node {
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+	"github.com/asdine/storm/v3"
+	"go.uber.org/zap"
+)
+
+func prague(t *testing.T) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation("Europe/Prague")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	return location
+}
+
+func TestRangeBounds(t *testing.T) {
+	location := prague(t)
+	tests := []struct {
+		name    string
+		period  entity.Period
+		wantMin string
+		wantMax string
+	}{
+		{
+			name:    "winter time day",
+			period:  entity.DaysPeriod(time.Date(2024, 3, 15, 0, 0, 0, 0, location), time.Date(2024, 3, 15, 0, 0, 0, 0, location)),
+			wantMin: "2024-03-14T23:00:00Z",
+			wantMax: "2024-03-15T22:59:59Z",
+		},
+		{
+			name:    "summer time change",
+			period:  entity.DaysPeriod(time.Date(2024, 3, 31, 0, 0, 0, 0, location), time.Date(2024, 3, 31, 0, 0, 0, 0, location)),
+			wantMin: "2024-03-30T23:00:00Z",
+			wantMax: "2024-03-31T21:59:59Z",
+		},
+		{
+			name:    "winter time change",
+			period:  entity.DaysPeriod(time.Date(2024, 10, 27, 0, 0, 0, 0, location), time.Date(2024, 10, 27, 0, 0, 0, 0, location)),
+			wantMin: "2024-10-26T22:00:00Z",
+			wantMax: "2024-10-27T22:59:59Z",
+		},
+		{
+			name:    "month in UTC",
+			period:  entity.MonthPeriod(time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)),
+			wantMin: "2024-02-01T00:00:00Z",
+			wantMax: "2024-02-29T23:59:59Z",
+		},
+		{
+			name:    "whole history",
+			period:  entity.NewPeriod(time.Time{}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			wantMin: "0001-01-01T00:00:00Z",
+			wantMax: "2023-12-31T23:59:59Z",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			min, max := rangeBounds(test.period)
+			if min.Location() != time.UTC || max.Location() != time.UTC {
+				t.Errorf("expected bounds in UTC, got %s and %s", min.Location(), max.Location())
+			}
+			if got := min.Format(time.RFC3339); got != test.wantMin {
+				t.Errorf("expected min %s, got %s", test.wantMin, got)
+			}
+			if got := max.Format(time.RFC3339); got != test.wantMax {
+				t.Errorf("expected max %s, got %s", test.wantMax, got)
+			}
+		})
+	}
+}
+
+// journalESI only identifies the corporation, records are saved directly.
+type journalESI struct {
+	esiRepository
+}
+
+func (journalESI) CorporationID() entity.CorporationID { return 98000001 }
+
+// testRepository returns repository with empty DB removed after the test.
+func testRepository(t *testing.T) *persistentRepository {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "eve-accountant-repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	db, err := storm.Open(filepath.Join(dir, "accountant.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return New(zap.NewNop(), db, journalESI{})
+}
+
+func TestWalletJournalBoundaries(t *testing.T) {
+	location := prague(t)
+	r := testRepository(t)
+	division := aggregate.Division{ID: 1}
+	// ESI dates are in UTC with whole seconds, 2024-03-31 has 23 hours in
+	// Prague.
+	dates := []string{
+		"2024-03-30T22:59:59Z", // 23:59:59 on the 30th.
+		"2024-03-30T23:00:00Z", // 00:00:00 on the 31st.
+		"2024-03-31T00:59:59Z", // Last second of winter time.
+		"2024-03-31T01:00:00Z", // 03:00:00 summer time.
+		"2024-03-31T21:59:59Z", // 23:59:59 on the 31st.
+		"2024-03-31T22:00:00Z", // 00:00:00 on the 1st.
+		"2024-04-01T21:59:59Z", // 23:59:59 on the 1st.
+		"2024-04-01T22:00:00Z", // 00:00:00 on the 2nd.
+	}
+	journalNode := r.divisionNode(division).From(journalNodeKey)
+	for i, date := range dates {
+		parsed, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = journalNode.Save(&aggregate.JournalRecord{Id: entity.Id(i + 1), Date: parsed, Amount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, location) }
+	tests := []struct {
+		name   string
+		period entity.Period
+		want   string
+	}{
+		{"day before summer time", entity.DaysPeriod(day(30), day(30)), "[1]"},
+		{"summer time change", entity.DaysPeriod(day(31), day(31)), "[2 3 4 5]"},
+		{"day after summer time", entity.DaysPeriod(day(32), day(32)), "[6 7]"},
+		{"two days", entity.DaysPeriod(day(31), day(32)), "[2 3 4 5 6 7]"},
+		{"month", entity.MonthPeriod(day(15)), "[1 2 3 4 5]"},
+		{"half open hour", entity.NewPeriod(time.Date(2024, 3, 31, 0, 59, 59, 0, time.UTC), time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC)), "[3]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := r.WalletJournal(context.Background(), division, test.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []entity.Id
+			for record := range records {
+				ids = append(ids, record.Id)
+			}
+			if got := fmt.Sprint(ids); got != test.want {
+				t.Errorf("expected records %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSearchJournal(t *testing.T) {
+	r := testRepository(t)
+	var (
+		day      = func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+		master   = aggregate.Division{ID: 1}
+		srp      = aggregate.Division{ID: 2, Name: "SRP"}
+		masterDB = r.divisionNode(master).From(journalNodeKey)
+	)
+	records := []aggregate.JournalRecord{
+		{Id: 1, Date: day(1), RefType: "player_donation", Amount: 100, Description: "Donation for fuel"},
+		{Id: 2, Date: day(2), RefType: "bounty_prizes", Amount: 5000},
+		{Id: 3, Date: day(3), RefType: "player_donation", Amount: -2000, Reason: "FUEL refund"},
+		{Id: 4, Date: day(20), RefType: "player_donation", Amount: 300},
+	}
+	for i := range records {
+		err := masterDB.Save(&records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := r.divisionNode(srp).From(journalNodeKey).Save(&aggregate.JournalRecord{Id: 5, Date: day(2), RefType: "player_donation", Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	period := entity.DaysPeriod(day(1), day(10))
+	tests := []struct {
+		name     string
+		division aggregate.Division
+		query    aggregate.JournalQuery
+		want     string
+	}{
+		{name: "period", division: master, query: aggregate.JournalQuery{Period: period}, want: "[3 2 1]"},
+		{name: "ref type", division: master, query: aggregate.JournalQuery{Period: period, RefTypes: []entity.RefType{"player_donation"}}, want: "[3 1]"},
+		{name: "ref types", division: master, query: aggregate.JournalQuery{Period: period, RefTypes: []entity.RefType{"player_donation", "bounty_prizes"}}, want: "[3 2 1]"},
+		{name: "amount either way", division: master, query: aggregate.JournalQuery{Period: period, MinAmount: 1000, MaxAmount: 3000}, want: "[3]"},
+		{name: "description and reason", division: master, query: aggregate.JournalQuery{Period: period, Description: "fuel"}, want: "[3 1]"},
+		{name: "master division", division: master, query: aggregate.JournalQuery{Period: period, Division: "main"}, want: "[3 2 1]"},
+		{name: "other division", division: master, query: aggregate.JournalQuery{Period: period, Division: "SRP"}, want: "[]"},
+		{name: "named division", division: srp, query: aggregate.JournalQuery{Period: period, Division: "srp"}, want: "[5]"},
+		{name: "limit", division: master, query: aggregate.JournalQuery{Period: period, Limit: 2}, want: "[3 2]"},
+		{name: "limit of ref types", division: master, query: aggregate.JournalQuery{Period: period, RefTypes: []entity.RefType{"player_donation", "bounty_prizes"}, Limit: 2}, want: "[3 2]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := r.SearchJournal(context.Background(), test.division, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []entity.Id{}
+			for _, record := range found {
+				ids = append(ids, record.Id)
+			}
+			if got := fmt.Sprint(ids); got != test.want {
+				t.Errorf("expected records %s, got %s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestStoredCorporations(t *testing.T) {
+	r := testRepository(t)
+	divisions := []aggregate.Division{{ID: 1}, {ID: 2, Name: "SRP"}}
+	err := r.corpNode.Save(&Divisions{Divisions: divisionsKey, Wallet: divisions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Names are not corporations.
+	err = r.db.From("names").Set("test", "key", "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := Corporations(r.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[98000001]" {
+		t.Fatalf("expected corporation 98000001, got %v", ids)
+	}
+	stored, err := NewStored(zap.NewNop(), r.db, ids[0]).WalletDivisions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != len(divisions) {
+		t.Errorf("expected stored divisions %v, got %v", divisions, stored)
+	}
+	err = NewStored(zap.NewNop(), r.db, ids[0]).Sync(context.Background())
+	if err == nil {
+		t.Error("expected stored corporation not to synchronize")
+	}
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/pkg/errors"
+
+	"github.com/asdine/storm/v3"
+	bolt "go.etcd.io/bbolt"
+	"go.uber.org/zap"
+)
+
+var errStored = errors.New("corporation is only read from DB, it can not be synchronized")
+
+// storedCorporation stands in for ESI of corporation read from DB without
+// authentication, synchronization fails.
+type storedCorporation entity.CorporationID
+
+func (c storedCorporation) CharacterID() entity.CharacterID {
+	return 0
+}
+
+func (c storedCorporation) CorporationID() entity.CorporationID {
+	return entity.CorporationID(c)
+}
+
+func (c storedCorporation) WalletDivisions(ctx context.Context) ([]aggregate.Division, error) {
+	return nil, errStored
+}
+
+func (c storedCorporation) WalletJournalPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.JournalRecord) bool) (time.Time, error) {
+	return time.Time{}, errStored
+}
+
+func (c storedCorporation) WalletTransactionsPages(ctx context.Context, division aggregate.Division, f func(page []aggregate.Transaction) bool) (time.Time, error) {
+	return time.Time{}, errStored
+}
+
+// NewStored returns repository reading corporation from DB only, ESI is never
+// called so it needs neither login nor network.
+func NewStored(log *zap.Logger, db *storm.DB, corporationID entity.CorporationID) *persistentRepository {
+	return New(log, db, storedCorporation(corporationID))
+}
+
+// Corporations returns IDs of corporations whose divisions were synchronized
+// to DB.
+func Corporations(db *storm.DB) ([]entity.CorporationID, error) {
+	var ids []entity.CorporationID
+	err := db.Bolt.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			// Storm keeps Divisions in bucket named after the type.
+			id, err := strconv.ParseInt(string(name), 10, 64)
+			if err != nil || bucket.Bucket([]byte("Divisions")) == nil {
+				return nil
+			}
+			ids = append(ids, entity.CorporationID(id))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing corporations in DB")
+	}
+	return ids, nil
+}
@@ -2,6 +2,7 @@ package balance
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
@@ -10,269 +11,346 @@ import (
 )
 
 type Service interface {
-	Balance(ctx context.Context, from, to time.Time) (*aggregate.Balance, error)
-	BalanceByDivision(ctx context.Context, from, to time.Time) (*aggregate.BalanceByDivision, error)
-	BalanceByType(ctx context.Context, from, to time.Time) (*aggregate.BalanceByType, error)
-	BalanceByDayByDivisionByType(ctx context.Context, from, to time.Time) ([]*aggregate.BalanceByDivisionByType, error)
+	Aggregate(ctx context.Context, period entity.Period, accumulators ...Accumulator) error
+	Balance(ctx context.Context, period entity.Period) (*aggregate.Balance, error)
+	BalanceByDivision(ctx context.Context, period entity.Period) (*aggregate.BalanceByDivision, error)
+	BalanceByType(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error)
+	BalanceByParty(ctx context.Context, period entity.Period) (*aggregate.BalanceByParty, error)
+	Summary(ctx context.Context, period entity.Period) (*aggregate.BalanceSummary, error)
+	TaxLedger(ctx context.Context, period entity.Period) (*aggregate.TaxLedger, error)
+	BalanceByDayByDivisionByType(ctx context.Context, period entity.Period) ([]*aggregate.BalanceByDivisionByType, error)
+	WalletBalanceByDay(ctx context.Context, period entity.Period) ([]*aggregate.WalletBalanceByDay, error)
+	DailyAmountsByType(ctx context.Context, period entity.Period) (*aggregate.DailyAmounts, error)
+	MarketByItem(ctx context.Context, period entity.Period) (aggregate.MarketByItem, error)
+	Sales(ctx context.Context, period entity.Period, typeIDs []entity.TypeId) ([]aggregate.Transaction, error)
+	UnmappedTypes(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error)
+	SearchJournal(ctx context.Context, query aggregate.JournalQuery) ([]aggregate.WalletRecord, error)
+	RefTypes(name entity.RefType) []entity.RefType
+	Group(refType entity.RefType) entity.RefType
+	DataAsOf(ctx context.Context) (time.Time, error)
 }
 
 type balanceService struct {
+	workers      int
+	groups       *RefTypeGroups
 	repositories []Repository
 }
 
-func NewService(repositories ...Repository) *balanceService {
+// NewService returns balance service reading from all repositories, with at most
+// workers journals being fetched concurrently. Ref types are reported grouped
+// by groups.
+func NewService(workers int, groups *RefTypeGroups, repositories ...Repository) *balanceService {
+	if workers < 1 {
+		workers = 1
+	}
 	return &balanceService{
+		workers:      workers,
+		groups:       groups,
 		repositories: repositories,
 	}
 }
 
-func (s *balanceService) Balance(ctx context.Context, from, to time.Time) (*aggregate.Balance, error) {
-	totalBalance := aggregate.NewBalance()
-
-	for _, repository := range s.repositories {
-		balance, err := s.balanceForRepository(ctx, repository, from, to)
+// DataAsOf returns time of the oldest synchronization of all corporations,
+// zero time means some corporation was never synchronized.
+func (s *balanceService) DataAsOf(ctx context.Context) (time.Time, error) {
+	var dataAsOf time.Time
+	for i, repository := range s.repositories {
+		updatedAt, err := repository.UpdatedAt(ctx)
 		if err != nil {
-			return totalBalance, errors.Wrapf(err, "error loading balance for corporation: %d", repository.CorporationID())
+			return dataAsOf, errors.Wrapf(err, "error loading last update time for corporation: %d", repository.CorporationID())
+		}
+		if i == 0 || updatedAt.Before(dataAsOf) {
+			dataAsOf = updatedAt
 		}
-		totalBalance.Sum(balance)
 	}
-	return totalBalance, nil
+	return dataAsOf, nil
 }
 
-func (s *balanceService) balanceForRepository(ctx context.Context, repository Repository, from, to time.Time) (*aggregate.Balance, error) {
-	balance := aggregate.NewBalance()
+func (s *balanceService) Balance(ctx context.Context, period entity.Period) (*aggregate.Balance, error) {
+	accumulator := newBalanceAccumulator()
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.balance, err
+}
 
-	divisions, err := repository.WalletDivisions(ctx)
-	if err != nil {
-		return balance, errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
+func (s *balanceService) BalanceByDayByDivisionByType(ctx context.Context, period entity.Period) ([]*aggregate.BalanceByDivisionByType, error) {
+	accumulator := newBalanceByDayAccumulator(s.groups, period)
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.balance, err
+}
+
+// DailyAmountsByType sums journal by raw ref type for each day of the period.
+func (s *balanceService) DailyAmountsByType(ctx context.Context, period entity.Period) (*aggregate.DailyAmounts, error) {
+	accumulator := newDailyAmountsAccumulator(period)
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.amounts, err
+}
+
+// WalletBalanceByDay returns balance of every wallet at the end of each day,
+// days without records carry the balance over from the previous day.
+// Corporations whose journal or balance failed to load are missing and
+// reported by CorporationsError.
+func (s *balanceService) WalletBalanceByDay(ctx context.Context, period entity.Period) ([]*aggregate.WalletBalanceByDay, error) {
+	accumulator := newWalletBalanceAccumulator(period)
+	aggregateErr := s.Aggregate(ctx, period, accumulator)
+	if aggregateErr != nil && !IsPartial(aggregateErr) {
+		return nil, aggregateErr
 	}
-	for _, division := range divisions {
-		journalRecords, err := repository.WalletJournal(ctx, division, from, to)
-		if err != nil {
-			return balance, errors.Wrap(err, "unable to list journal records")
+	failed := make(map[entity.CorporationID]error)
+	addFailed := func(err error) {
+		var corporationsErr *CorporationsError
+		if errors.As(err, &corporationsErr) {
+			for corporationID, err := range corporationsErr.Errors {
+				failed[corporationID] = err
+			}
 		}
+	}
+	addFailed(aggregateErr)
 
-		for journalRecord := range journalRecords {
-			if journalRecord.Amount > 0 {
-				balance.Income += journalRecord.Amount
+	// Balance before the first day.
+	balances := make([]map[aggregate.Wallet]entity.Balance, len(s.repositories))
+	balanceErr := s.eachCorporation(ctx, func(i int, repository Repository) error {
+		if _, ok := failed[repository.CorporationID()]; ok {
+			return nil
+		}
+		divisions, err := repository.WalletDivisions(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
+		}
+		corporationBalances := make(map[aggregate.Wallet]entity.Balance, len(divisions))
+		for _, division := range divisions {
+			source := Source{CorporationID: repository.CorporationID(), Division: division}
+			balance, err := repository.WalletBalance(ctx, division, period.Start)
+			if err != nil {
+				return errors.Wrapf(err, "error loading wallet balance for corporation: %d", repository.CorporationID())
 			}
-			if journalRecord.Amount < 0 {
-				balance.Expenses += journalRecord.Amount
+			corporationBalances[aggregate.Wallet{CorporationID: source.CorporationID, Division: source.DivisionName()}] = balance
+		}
+		balances[i] = corporationBalances
+		return nil
+	})
+	addFailed(balanceErr)
+
+	current := make(map[aggregate.Wallet]entity.Balance)
+	for i, repository := range s.repositories {
+		if _, ok := failed[repository.CorporationID()]; ok {
+			continue
+		}
+		for wallet, balance := range balances[i] {
+			current[wallet] = balance
+		}
+	}
+	days := make([]*aggregate.WalletBalanceByDay, len(accumulator.last))
+	for i, last := range accumulator.last {
+		for wallet, record := range last {
+			if _, ok := failed[wallet.CorporationID]; ok {
+				continue
 			}
+			current[wallet] = record.Balance
+		}
+		days[i] = aggregate.NewWalletBalanceByDay(accumulator.days[i])
+		for wallet, balance := range current {
+			days[i].ByWallet[wallet] = balance
 		}
 	}
+	err := s.corporationsError(failed)
+	if err != nil && !IsPartial(err) {
+		return nil, err
+	}
+	return days, err
+}
 
-	return balance, nil
+func (s *balanceService) BalanceByDivision(ctx context.Context, period entity.Period) (*aggregate.BalanceByDivision, error) {
+	accumulator := newBalanceByDivisionAccumulator()
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.balance, err
 }
 
-func (s *balanceService) BalanceByDayByDivisionByType(ctx context.Context, from, to time.Time) ([]*aggregate.BalanceByDivisionByType, error) {
-	var dailyBalance []*aggregate.BalanceByDivisionByType
+func (s *balanceService) BalanceByType(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error) {
+	accumulator := newBalanceByTypeAccumulator(s.groups)
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.balance, err
+}
 
-	for d := from; d.After(to) == false; d = d.AddDate(0, 0, 1) {
-		fromTime := d
-		toTime := d.Add(24*time.Hour - 1*time.Nanosecond)
-		dayBalance := aggregate.NewBalanceByDivisionByType(fromTime)
-		for _, repository := range s.repositories {
-			balance, err := s.balanceByDayByDivisionByType(ctx, repository, fromTime, toTime)
-			if err != nil {
-				return nil, errors.Wrapf(err, "error loading balance for corporation: %d", repository.CorporationID())
-			}
-			dayBalance.Sum(balance)
-		}
-		dailyBalance = append(dailyBalance, dayBalance)
-	}
+// UnmappedTypes sums journal by raw ref types which do not belong to any group.
+func (s *balanceService) UnmappedTypes(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error) {
+	accumulator := newUnmappedTypeAccumulator(s.groups)
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.balance, err
+}
 
-	return dailyBalance, nil
+// Summary returns balance in total, by division and by type from a single
+// pass over the journal.
+func (s *balanceService) Summary(ctx context.Context, period entity.Period) (*aggregate.BalanceSummary, error) {
+	var (
+		balance    = newBalanceAccumulator()
+		byDivision = newBalanceByDivisionAccumulator()
+		byType     = newBalanceByTypeAccumulator(s.groups)
+	)
+	err := s.Aggregate(ctx, period, balance, byDivision, byType)
+	summary := aggregate.NewBalanceSummary(period)
+	summary.Balance = balance.balance
+	summary.ByDivision = byDivision.balance
+	summary.ByType = byType.balance
+	return summary, err
 }
 
-func (s *balanceService) balanceByDayByDivisionByType(ctx context.Context, repository Repository, from, to time.Time) (*aggregate.BalanceByDivisionByType, error) {
-	balanceByDivisionByType := aggregate.NewBalanceByDivisionByType(from)
+// BalanceByParty sums journal by the other party of each transaction.
+func (s *balanceService) BalanceByParty(ctx context.Context, period entity.Period) (*aggregate.BalanceByParty, error) {
+	accumulator := newBalanceByPartyAccumulator()
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.balance, err
+}
 
-	divisions, err := repository.WalletDivisions(ctx)
-	if err != nil {
-		return balanceByDivisionByType, errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
-	}
-	for _, division := range divisions {
-		journalRecords, err := repository.WalletJournal(ctx, division, from, to)
-		if err != nil {
-			return balanceByDivisionByType, errors.Wrap(err, "unable to list journal records")
-		}
+// TaxLedger sums ratting and mission tax by the pilot who generated it.
+func (s *balanceService) TaxLedger(ctx context.Context, period entity.Period) (*aggregate.TaxLedger, error) {
+	accumulator := newTaxLedgerAccumulator(period.Location())
+	err := s.Aggregate(ctx, period, accumulator)
+	return accumulator.ledger, err
+}
 
-		divisionName := division.Name
-		if divisionName == "" {
-			divisionName = "Main"
+// MarketByItem sums market transactions by item type, all purchases before
+// the period are read as well to calculate average buy price of sold items.
+// Corporations that failed to load are missing and reported by
+// CorporationsError.
+func (s *balanceService) MarketByItem(ctx context.Context, period entity.Period) (aggregate.MarketByItem, error) {
+	markets := make([]aggregate.MarketByItem, len(s.repositories))
+	err := s.eachCorporation(ctx, func(i int, repository Repository) error {
+		divisions, err := repository.WalletDivisions(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
 		}
-
-		for journalRecord := range journalRecords {
-			typeOut, ok := refTypeGroups[journalRecord.RefType]
-			if !ok {
-				typeOut = journalRecord.RefType
-			}
-
-			if journalRecord.Amount > 0 {
-				balanceByDivisionByType.Income.Record(divisionName, typeOut, journalRecord.Amount)
+		market := aggregate.NewMarketByItem()
+		for _, division := range divisions {
+			transactions, err := repository.WalletTransactions(ctx, division, entity.NewPeriod(time.Time{}, period.End))
+			if err != nil {
+				return errors.Wrapf(err, "unable to list market transactions for corporation: %d", repository.CorporationID())
 			}
-			if journalRecord.Amount < 0 {
-				balanceByDivisionByType.Expenses.Record(divisionName, typeOut, journalRecord.Amount)
+			for transaction := range transactions {
+				market.Record(transaction, period.Contains(transaction.Date))
 			}
 		}
-	}
+		markets[i] = market
+		return nil
+	})
 
-	return balanceByDivisionByType, nil
-}
-
-func (s *balanceService) BalanceByDivision(ctx context.Context, from, to time.Time) (*aggregate.BalanceByDivision, error) {
-	totalBalance := aggregate.NewBalanceByDivision()
-
-	for _, repository := range s.repositories {
-		balance, err := s.balanceByDivisionForRepository(ctx, repository, from, to)
-		if err != nil {
-			return totalBalance, errors.Wrapf(err, "error loading balance for corporation: %d", repository.CorporationID())
-		}
-		totalBalance.Sum(balance)
+	market := aggregate.NewMarketByItem()
+	for _, corporationMarket := range markets {
+		market.Add(corporationMarket)
 	}
-	return totalBalance, nil
+	return market, err
 }
 
-func (s *balanceService) balanceByDivisionForRepository(ctx context.Context, repository Repository, from, to time.Time) (*aggregate.BalanceByDivision, error) {
-	balance := aggregate.NewBalanceByDivision()
-
-	divisions, err := repository.WalletDivisions(ctx)
-	if err != nil {
-		return balance, errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
+// Sales returns market sell transactions of the item types within the period,
+// oldest first. Corporations that failed to load are missing and reported by
+// CorporationsError.
+func (s *balanceService) Sales(ctx context.Context, period entity.Period, typeIDs []entity.TypeId) ([]aggregate.Transaction, error) {
+	types := make(map[entity.TypeId]struct{}, len(typeIDs))
+	for _, typeID := range typeIDs {
+		types[typeID] = struct{}{}
 	}
-	for _, division := range divisions {
-		divisionName := division.Name
-		if divisionName == "" {
-			divisionName = "Main"
-		}
-		journalRecords, err := repository.WalletJournal(ctx, division, from, to)
+
+	corporationSales := make([][]aggregate.Transaction, len(s.repositories))
+	err := s.eachCorporation(ctx, func(i int, repository Repository) error {
+		divisions, err := repository.WalletDivisions(ctx)
 		if err != nil {
-			return balance, errors.Wrap(err, "unable to list journal records")
+			return errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
 		}
-
-		for journalRecord := range journalRecords {
-			if journalRecord.Amount > 0 {
-				balance.IncomeByDivision[divisionName] += journalRecord.Amount
+		var sales []aggregate.Transaction
+		for _, division := range divisions {
+			transactions, err := repository.WalletTransactions(ctx, division, period)
+			if err != nil {
+				return errors.Wrapf(err, "unable to list market transactions for corporation: %d", repository.CorporationID())
 			}
-			if journalRecord.Amount < 0 {
-				balance.ExpensesByDivision[divisionName] += journalRecord.Amount
+			for transaction := range transactions {
+				if _, ok := types[transaction.TypeId]; ok && !bool(transaction.IsBuy) {
+					sales = append(sales, transaction)
+				}
 			}
 		}
+		corporationSales[i] = sales
+		return nil
+	})
+
+	var sales []aggregate.Transaction
+	for _, corporation := range corporationSales {
+		sales = append(sales, corporation...)
 	}
+	sort.SliceStable(sales, func(i, j int) bool {
+		return sales[i].Date.Before(sales[j].Date)
+	})
+	return sales, err
+}
 
-	return balance, nil
+// Group returns group of the raw ref type as shown in reports.
+func (s *balanceService) Group(refType entity.RefType) entity.RefType {
+	return s.groups.Group(refType)
 }
 
-func (s *balanceService) BalanceByType(ctx context.Context, from, to time.Time) (*aggregate.BalanceByType, error) {
-	totalBalance := aggregate.NewBalanceByType()
+// RefTypes returns raw ref types of the group, or the ref type itself.
+func (s *balanceService) RefTypes(name entity.RefType) []entity.RefType {
+	return s.groups.RefTypes(name)
+}
 
-	for _, repository := range s.repositories {
-		balance, err := s.balanceByTypeForRepository(ctx, repository, from, to)
-		if err != nil {
-			return totalBalance, errors.Wrapf(err, "error loading balance for corporation: %d", repository.CorporationID())
+// SearchJournal returns journal records of all corporations matching the
+// query, newest first and at most query.Limit of them. Ref type groups of the query match records of any of
+// their raw ref types. Records of corporations that failed to load are
+// missing and reported by CorporationsError.
+func (s *balanceService) SearchJournal(ctx context.Context, query aggregate.JournalQuery) ([]aggregate.WalletRecord, error) {
+	var refTypes []entity.RefType
+	for _, refType := range query.RefTypes {
+		refTypes = append(refTypes, s.groups.RefTypes(refType)...)
+	}
+	query.RefTypes = refTypes
+
+	corporationRecords := make([][]aggregate.WalletRecord, len(s.repositories))
+	err := s.eachCorporation(ctx, func(i int, repository Repository) error {
+		var err error
+		corporationRecords[i], err = s.searchRepository(ctx, repository, query)
+		return err
+	})
+
+	var records []aggregate.WalletRecord
+	for _, corporation := range corporationRecords {
+		records = append(records, corporation...)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Record.Date.Equal(records[j].Record.Date) {
+			return records[i].Record.Id > records[j].Record.Id
 		}
-		totalBalance.Sum(balance)
+		return records[i].Record.Date.After(records[j].Record.Date)
+	})
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[:query.Limit]
 	}
-	return s.groupTypes(totalBalance), nil
+	return records, err
 }
 
-func (s *balanceService) balanceByTypeForRepository(ctx context.Context, repository Repository, from, to time.Time) (*aggregate.BalanceByType, error) {
-	balance := aggregate.NewBalanceByType()
-
+func (s *balanceService) searchRepository(ctx context.Context, repository Repository, query aggregate.JournalQuery) ([]aggregate.WalletRecord, error) {
 	divisions, err := repository.WalletDivisions(ctx)
 	if err != nil {
-		return balance, errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
+		return nil, errors.Wrapf(err, "error listing divisions for corporation: %d", repository.CorporationID())
 	}
+
+	var records []aggregate.WalletRecord
 	for _, division := range divisions {
-		journalRecords, err := repository.WalletJournal(ctx, division, from, to)
+		source := Source{CorporationID: repository.CorporationID(), Division: division}
+		journal, err := repository.SearchJournal(ctx, division, query)
 		if err != nil {
-			return balance, errors.Wrap(err, "unable to list journal records")
+			return nil, errors.Wrapf(err, "error searching journal of division: %s", source.DivisionName())
 		}
-
-		for journalRecord := range journalRecords {
-			if journalRecord.Amount > 0 {
-				balance.IncomeByType[journalRecord.RefType] += journalRecord.Amount
+		for _, record := range journal {
+			walletRecord := aggregate.WalletRecord{
+				Wallet: aggregate.Wallet{CorporationID: source.CorporationID, Division: source.DivisionName()},
+				Record: record,
 			}
-			if journalRecord.Amount < 0 {
-				balance.ExpensesByType[journalRecord.RefType] += journalRecord.Amount
+			if transactionID, ok := record.TransactionId(); ok {
+				walletRecord.Transaction, err = repository.WalletTransaction(ctx, division, transactionID)
+				if err != nil {
+					return nil, errors.Wrapf(err, "error loading transaction of journal record: %d", record.Id)
+				}
 			}
+			records = append(records, walletRecord)
 		}
 	}
-
-	return balance, nil
-}
-
-var (
-	marketTransactionType = entity.RefType("Market Transaction")
-	contractPriceType     = entity.RefType("Contracts")
-	playerWalletAction    = entity.RefType("Player Wallet Action")
-	cloneTaxType          = entity.RefType("Clone Tax")
-	industryTaxType       = entity.RefType("Industry Tax")
-	piTaxType             = entity.RefType("PI Tax")
-	rewardType            = entity.RefType("Krab Tax")
-	jobCostType           = entity.RefType("Job Costs")
-	feeType               = entity.RefType("Fee")
-
-	refTypeGroups = map[entity.RefType]entity.RefType{
-		entity.RefType("market_transaction"):                marketTransactionType,
-		entity.RefType("contract_price"):                    contractPriceType,
-		entity.RefType("player_donation"):                   playerWalletAction,
-		entity.RefType("corporation_account_withdrawal"):    playerWalletAction,
-		entity.RefType("jump_clone_activation_fee"):         cloneTaxType,
-		entity.RefType("jump_clone_installation_fee"):       cloneTaxType,
-		entity.RefType("industry_job_tax"):                  industryTaxType,
-		entity.RefType("reprocessing_tax"):                  industryTaxType,
-		entity.RefType("planetary_export_tax"):              piTaxType,
-		entity.RefType("planetary_import_tax"):              piTaxType,
-		entity.RefType("contract_deposit_refund"):           contractPriceType,
-		entity.RefType("contract_auction_bid_refund"):       contractPriceType,
-		entity.RefType("contract_auction_sold"):             contractPriceType,
-		entity.RefType("insurance"):                         rewardType,
-		entity.RefType("bounty_prizes"):                     rewardType,
-		entity.RefType("corporate_reward_payout"):           rewardType,
-		entity.RefType("project_discovery_reward"):          rewardType,
-		entity.RefType("agent_mission_reward"):              rewardType,
-		entity.RefType("agent_mission_time_bonus_reward"):   rewardType,
-		entity.RefType("ess_escrow_transfer"):               rewardType,
-		entity.RefType("researching_technology"):            jobCostType,
-		entity.RefType("researching_time_productivity"):     jobCostType,
-		entity.RefType("copying"):                           jobCostType,
-		entity.RefType("researching_material_productivity"): jobCostType,
-		entity.RefType("reaction"):                          jobCostType,
-		entity.RefType("manufacturing"):                     jobCostType,
-		entity.RefType("alliance_maintainance_fee"):         feeType,
-		entity.RefType("office_rental_fee"):                 feeType,
-		entity.RefType("contract_sales_tax"):                contractPriceType,
-		entity.RefType("contract_brokers_fee_corp"):         contractPriceType,
-		entity.RefType("contract_auction_bid_corp"):         contractPriceType,
-		entity.RefType("contract_deposit_corp"):             contractPriceType,
-		entity.RefType("contract_reward_deposited_corp"):    contractPriceType,
-		entity.RefType("contract_price_payment_corp"):       contractPriceType,
-		entity.RefType("contract_reward_refund"):            contractPriceType,
-		entity.RefType("brokers_fee"):                       marketTransactionType,
-		entity.RefType("transaction_tax"):                   marketTransactionType,
-		entity.RefType("market_escrow"):                     marketTransactionType,
-	}
-)
-
-func (s *balanceService) groupTypes(balance *aggregate.BalanceByType) *aggregate.BalanceByType {
-	balanceOut := aggregate.NewBalanceByType()
-
-	for typeIn, amountIn := range balance.IncomeByType {
-		typeOut, ok := refTypeGroups[typeIn]
-		if !ok {
-			typeOut = typeIn
-		}
-		balanceOut.IncomeByType[typeOut] += amountIn
-	}
-	for typeIn, amountIn := range balance.ExpensesByType {
-		typeOut, ok := refTypeGroups[typeIn]
-		if !ok {
-			typeOut = typeIn
-		}
-		balanceOut.ExpensesByType[typeOut] += amountIn
-	}
-
-	return balanceOut
+	return records, nil
 }
@@ -0,0 +1,199 @@
+package aggregate
+
+import (
+	"time"
+
+	balanceAggregate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/entity"
+)
+
+// BuybackItem sums what the corporation bought of one item type and what it
+// later sold the items for.
+type BuybackItem struct {
+	TypeId        balanceEntity.TypeId
+	Quantity      int64
+	Paid          balanceEntity.Amount
+	MarketValue   balanceEntity.Amount // Value of the items according to the price source.
+	SoldQuantity  int64                // Sold on the market, at most Quantity.
+	ResaleRevenue balanceEntity.Amount
+	SoldCost      balanceEntity.Amount // Price paid for the sold items.
+}
+
+// Margin returns resale revenue minus the price paid for the sold items.
+func (i *BuybackItem) Margin() balanceEntity.Amount {
+	return i.ResaleRevenue - i.SoldCost
+}
+
+// BuybackMember sums what one member sold to the corporation.
+type BuybackMember struct {
+	MemberId    entity.MemberId
+	Quantity    int64
+	Paid        balanceEntity.Amount
+	MarketValue balanceEntity.Amount
+	// Margin the corporation made reselling items bought from this member.
+	Margin balanceEntity.Amount
+
+	QuantityByItem map[balanceEntity.TypeId]int64
+}
+
+// lot is quantity of one item type bought by one contract and not sold yet.
+type lot struct {
+	date      time.Time
+	member    *BuybackMember
+	item      *BuybackItem // nil when the contract was completed before the period.
+	quantity  int64
+	unitPrice balanceEntity.Amount
+}
+
+type Buyback struct {
+	ByItem   map[balanceEntity.TypeId]*BuybackItem
+	ByMember map[entity.MemberId]*BuybackMember
+
+	period balanceEntity.Period
+	lots   map[balanceEntity.TypeId][]*lot
+}
+
+// NewBuyback returns buyback of the contracts completed within the period.
+func NewBuyback(period balanceEntity.Period) *Buyback {
+	return &Buyback{
+		ByItem:   make(map[balanceEntity.TypeId]*BuybackItem),
+		ByMember: make(map[entity.MemberId]*BuybackMember),
+		period:   period,
+		lots:     make(map[balanceEntity.TypeId][]*lot),
+	}
+}
+
+// Record adds buyback contract, price paid is split between the items by their
+// market value, or by quantity when market value is not known. Contracts must
+// be recorded oldest first, including the ones completed before the period, so
+// sales are matched with the items bought first.
+func (b *Buyback) Record(contract Contract, prices map[balanceEntity.TypeId]balanceEntity.Amount) {
+	var (
+		contractValue    balanceEntity.Amount
+		contractQuantity int64
+	)
+	for _, item := range contract.Items {
+		if !item.IsIncluded {
+			continue
+		}
+		contractValue += prices[item.TypeId] * balanceEntity.Amount(item.Quantity)
+		contractQuantity += item.Quantity
+	}
+	if contractQuantity == 0 {
+		return
+	}
+
+	var member *BuybackMember
+	inPeriod := b.period.Contains(contract.DateCompleted)
+	if inPeriod {
+		var ok bool
+		member, ok = b.ByMember[contract.IssuerId]
+		if !ok {
+			member = &BuybackMember{
+				MemberId:       contract.IssuerId,
+				QuantityByItem: make(map[balanceEntity.TypeId]int64),
+			}
+			b.ByMember[contract.IssuerId] = member
+		}
+		member.Paid += contract.Price
+		member.MarketValue += contractValue
+	}
+
+	for _, item := range contract.Items {
+		if !item.IsIncluded || item.Quantity <= 0 {
+			continue
+		}
+		itemValue := prices[item.TypeId] * balanceEntity.Amount(item.Quantity)
+		share := balanceEntity.Amount(item.Quantity) / balanceEntity.Amount(contractQuantity)
+		if contractValue > 0 {
+			share = itemValue / contractValue
+		}
+		itemLot := &lot{
+			date:      contract.DateCompleted,
+			member:    member,
+			quantity:  item.Quantity,
+			unitPrice: contract.Price * share / balanceEntity.Amount(item.Quantity),
+		}
+		b.lots[item.TypeId] = append(b.lots[item.TypeId], itemLot)
+		if !inPeriod {
+			continue
+		}
+
+		buybackItem, ok := b.ByItem[item.TypeId]
+		if !ok {
+			buybackItem = &BuybackItem{TypeId: item.TypeId}
+			b.ByItem[item.TypeId] = buybackItem
+		}
+		buybackItem.Quantity += item.Quantity
+		buybackItem.Paid += contract.Price * share
+		buybackItem.MarketValue += itemValue
+		itemLot.item = buybackItem
+
+		member.Quantity += item.Quantity
+		member.QuantityByItem[item.TypeId] += item.Quantity
+	}
+}
+
+// RecordSale matches market sale with the oldest bought items not sold yet,
+// sales of items that were not bought by contract (e.g. mined by the
+// corporation) are ignored. Sales must be recorded oldest first, after all
+// contracts.
+func (b *Buyback) RecordSale(transaction balanceAggregate.Transaction) {
+	if transaction.IsBuy {
+		return
+	}
+	var (
+		quantity = int64(transaction.Quantity)
+		lots     = b.lots[transaction.TypeId]
+	)
+	for len(lots) > 0 && quantity > 0 {
+		oldest := lots[0]
+		if oldest.date.After(transaction.Date) {
+			break
+		}
+		sold := oldest.quantity
+		if sold > quantity {
+			sold = quantity
+		}
+		oldest.quantity -= sold
+		quantity -= sold
+		if oldest.quantity == 0 {
+			lots = lots[1:]
+		}
+		if oldest.item == nil {
+			continue
+		}
+
+		revenue := balanceEntity.Amount(transaction.UnitPrice) * balanceEntity.Amount(sold)
+		cost := oldest.unitPrice * balanceEntity.Amount(sold)
+		oldest.item.SoldQuantity += sold
+		oldest.item.ResaleRevenue += revenue
+		oldest.item.SoldCost += cost
+		oldest.member.Margin += revenue - cost
+	}
+	b.lots[transaction.TypeId] = lots
+}
+
+// TypeIDs returns item types bought by recorded contracts.
+func (b *Buyback) TypeIDs() []balanceEntity.TypeId {
+	typeIDs := make([]balanceEntity.TypeId, 0, len(b.lots))
+	for typeID := range b.lots {
+		typeIDs = append(typeIDs, typeID)
+	}
+	return typeIDs
+}
+
+// Since returns completion date of the oldest recorded contract, sales before
+// it can not resell any bought items.
+func (b *Buyback) Since() time.Time {
+	var since time.Time
+	for _, lots := range b.lots {
+		for _, lot := range lots {
+			if since.IsZero() || lot.date.Before(since) {
+				since = lot.date
+			}
+		}
+	}
+	return since
+}
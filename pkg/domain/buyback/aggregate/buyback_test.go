@@ -0,0 +1,119 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	balanceAggregate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/entity"
+)
+
+const ore balanceEntity.TypeId = 1230
+
+func day(d int) time.Time {
+	return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC)
+}
+
+func contract(issuer entity.MemberId, completed time.Time, quantity int64, price balanceEntity.Amount) Contract {
+	return Contract{
+		IssuerId:      issuer,
+		Price:         price,
+		DateCompleted: completed,
+		Items:         []ContractItem{{TypeId: ore, Quantity: quantity, IsIncluded: true}},
+	}
+}
+
+func sale(date time.Time, quantity balanceEntity.Quantity, unitPrice balanceEntity.UnitPrice) balanceAggregate.Transaction {
+	return balanceAggregate.Transaction{
+		Date:      date,
+		TypeId:    ore,
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+	}
+}
+
+func TestBuybackResale(t *testing.T) {
+	tests := []struct {
+		name       string
+		contracts  []Contract
+		sales      []balanceAggregate.Transaction
+		wantSold   int64
+		wantMargin balanceEntity.Amount
+		byMember   map[entity.MemberId]balanceEntity.Amount
+	}{
+		{
+			name:       "sold within the period",
+			contracts:  []Contract{contract(1, day(10), 100, 1000)},
+			sales:      []balanceAggregate.Transaction{sale(day(11), 40, 15)},
+			wantSold:   40,
+			wantMargin: 40*15 - 400,
+			byMember:   map[entity.MemberId]balanceEntity.Amount{1: 200},
+		},
+		{
+			name:       "sold after the period",
+			contracts:  []Contract{contract(1, day(10), 100, 1000)},
+			sales:      []balanceAggregate.Transaction{sale(day(25), 100, 12)},
+			wantSold:   100,
+			wantMargin: 200,
+			byMember:   map[entity.MemberId]balanceEntity.Amount{1: 200},
+		},
+		{
+			name:       "sales of other items are not resale",
+			contracts:  []Contract{contract(1, day(10), 100, 1000)},
+			sales:      []balanceAggregate.Transaction{sale(day(9), 500, 12), sale(day(11), 500, 12)},
+			wantSold:   100,
+			wantMargin: 200,
+			byMember:   map[entity.MemberId]balanceEntity.Amount{1: 200},
+		},
+		{
+			name: "items bought before the period are sold first",
+			contracts: []Contract{
+				contract(2, day(1), 50, 250),
+				contract(1, day(10), 100, 1000),
+			},
+			sales:      []balanceAggregate.Transaction{sale(day(11), 80, 20)},
+			wantSold:   30,
+			wantMargin: 30*20 - 300,
+			byMember:   map[entity.MemberId]balanceEntity.Amount{1: 300},
+		},
+		{
+			name: "oldest contract is sold first",
+			contracts: []Contract{
+				contract(1, day(10), 10, 100),
+				contract(2, day(12), 10, 50),
+			},
+			sales:      []balanceAggregate.Transaction{sale(day(13), 15, 10)},
+			wantSold:   15,
+			wantMargin: 150 - 100 - 25,
+			byMember:   map[entity.MemberId]balanceEntity.Amount{1: 0, 2: 25},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buyback := NewBuyback(balanceEntity.NewPeriod(day(5), day(20)))
+			for _, c := range test.contracts {
+				buyback.Record(c, nil)
+			}
+			for _, s := range test.sales {
+				buyback.RecordSale(s)
+			}
+
+			item := buyback.ByItem[ore]
+			if item.SoldQuantity != test.wantSold {
+				t.Errorf("expected %d sold, got %d", test.wantSold, item.SoldQuantity)
+			}
+			if item.Margin() != test.wantMargin {
+				t.Errorf("expected margin %v, got %v", test.wantMargin, item.Margin())
+			}
+			if len(buyback.ByMember) != len(test.byMember) {
+				t.Errorf("expected members %v, got %d members", test.byMember, len(buyback.ByMember))
+			}
+			for memberID, margin := range test.byMember {
+				if member := buyback.ByMember[memberID]; member == nil || member.Margin != margin {
+					t.Errorf("expected margin %v of member %d, got %+v", margin, memberID, member)
+				}
+			}
+		})
+	}
+}
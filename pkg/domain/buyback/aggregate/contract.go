@@ -0,0 +1,41 @@
+package aggregate
+
+import (
+	"time"
+
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/entity"
+)
+
+const (
+	itemExchangeContractType = entity.ContractType("item_exchange")
+	finishedContractStatus   = entity.ContractStatus("finished")
+)
+
+type ContractItem struct {
+	TypeId     balanceEntity.TypeId
+	Quantity   int64
+	IsIncluded bool // True when the issuer gives the item, false when the issuer asks for it.
+}
+
+type Contract struct {
+	Id             entity.ContractId `storm:"id"`
+	Type           entity.ContractType
+	Status         entity.ContractStatus
+	AssigneeId     int32
+	IssuerId       entity.MemberId
+	ForCorporation bool
+	Price          balanceEntity.Amount
+	DateCompleted  time.Time `storm:"index"`
+	Items          []ContractItem
+}
+
+// IsBuyback returns true for finished item exchange contracts in which a member
+// sold items to the corporation.
+func (c Contract) IsBuyback(corporationID balanceEntity.CorporationID) bool {
+	return c.Type == itemExchangeContractType &&
+		c.Status == finishedContractStatus &&
+		balanceEntity.CorporationID(c.AssigneeId) == corporationID &&
+		!c.ForCorporation &&
+		c.Price > 0
+}
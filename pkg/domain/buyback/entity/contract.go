@@ -0,0 +1,8 @@
+package entity
+
+type (
+	ContractId     int32  /* Unique contract ID */
+	ContractType   string /* Type of the contract, buyback contracts are item_exchange */
+	ContractStatus string /* Status of the contract, only finished contracts are counted */
+	MemberId       int32  /* ID of the character who sold items to the corporation */
+)
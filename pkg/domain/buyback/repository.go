@@ -0,0 +1,18 @@
+package buyback
+
+import (
+	"context"
+
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/aggregate"
+)
+
+type Repository interface {
+	CorporationID() balanceEntity.CorporationID
+	Contracts(ctx context.Context, period balanceEntity.Period) ([]aggregate.Contract, error)
+}
+
+// PriceSource values items bought by the buyback program.
+type PriceSource interface {
+	Prices(ctx context.Context) (map[balanceEntity.TypeId]balanceEntity.Amount, error)
+}
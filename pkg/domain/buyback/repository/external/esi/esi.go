@@ -0,0 +1,116 @@
+package esi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/entity"
+	authService "github.com/lunemec/eve-bot-pkg/services/auth"
+
+	"github.com/antihax/goesi"
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/pkg/errors"
+)
+
+type repository struct {
+	authService authService.Service
+
+	esi *goesi.APIClient
+
+	corporationID balanceEntity.CorporationID
+}
+
+func New(client *http.Client, authService authService.Service, corporationID balanceEntity.CorporationID) *repository {
+	return &repository{
+		authService:   authService,
+		esi:           goesi.NewAPIClient(client, "EVE Accountant"),
+		corporationID: corporationID,
+	}
+}
+
+func (r *repository) ctx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, goesi.ContextOAuth2, r.authService)
+}
+
+func (r *repository) CorporationID() balanceEntity.CorporationID {
+	return r.corporationID
+}
+
+// Contracts returns all contracts of the corporation ESI knows about, without
+// items. Returned time is when ESI cache of the contracts expires.
+func (r *repository) Contracts(ctx context.Context) ([]aggregate.Contract, time.Time, error) {
+	ctx = r.ctx(ctx)
+	esiContracts, resp, err := r.esi.ESI.ContractsApi.GetCorporationsCorporationIdContracts(
+		ctx,
+		int32(r.corporationID),
+		nil,
+	)
+	if err != nil {
+		return nil, time.Time{}, errors.Wrap(err, "unable to get corporation contracts")
+	}
+	expires := goesi.CacheExpires(resp)
+	contracts := mapContractsToSliceAggregateContract(esiContracts)
+
+	pages, err := strconv.Atoi(resp.Header.Get("X-Pages"))
+	if err != nil {
+		return nil, expires, errors.Wrap(err, "error converting X-Pages to integer")
+	}
+	// Fetch additional pages if any (starting page above is 1).
+	for i := 2; i <= pages; i++ {
+		esiContracts, _, err := r.esi.ESI.ContractsApi.GetCorporationsCorporationIdContracts(
+			ctx,
+			int32(r.corporationID),
+			&esi.GetCorporationsCorporationIdContractsOpts{
+				Page: optional.NewInt32(int32(i)),
+			},
+		)
+		if err != nil {
+			return nil, expires, errors.Wrapf(err, "unable to get corporation contracts page: %d", i)
+		}
+		contracts = append(contracts, mapContractsToSliceAggregateContract(esiContracts)...)
+	}
+	return contracts, expires, nil
+}
+
+func (r *repository) ContractItems(ctx context.Context, contractID entity.ContractId) ([]aggregate.ContractItem, error) {
+	esiItems, _, err := r.esi.ESI.ContractsApi.GetCorporationsCorporationIdContractsContractIdItems(
+		r.ctx(ctx),
+		int32(contractID),
+		int32(r.corporationID),
+		nil,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get items of contract: %d", contractID)
+	}
+	items := make([]aggregate.ContractItem, 0, len(esiItems))
+	for _, esiItem := range esiItems {
+		items = append(items, aggregate.ContractItem{
+			TypeId:     balanceEntity.TypeId(esiItem.TypeId),
+			Quantity:   int64(esiItem.Quantity),
+			IsIncluded: esiItem.IsIncluded,
+		})
+	}
+	return items, nil
+}
+
+func mapContractsToSliceAggregateContract(in []esi.GetCorporationsCorporationIdContracts200Ok) []aggregate.Contract {
+	out := make([]aggregate.Contract, 0, len(in))
+	for _, inContract := range in {
+		out = append(out, aggregate.Contract{
+			Id:             entity.ContractId(inContract.ContractId),
+			Type:           entity.ContractType(inContract.Type_),
+			Status:         entity.ContractStatus(inContract.Status),
+			AssigneeId:     inContract.AssigneeId,
+			IssuerId:       entity.MemberId(inContract.IssuerId),
+			ForCorporation: inContract.ForCorporation,
+			Price:          balanceEntity.Amount(inContract.Price),
+			DateCompleted:  inContract.DateCompleted,
+		})
+	}
+	return out
+}
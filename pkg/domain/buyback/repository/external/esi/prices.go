@@ -0,0 +1,51 @@
+package esi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+	"github.com/antihax/goesi"
+	"github.com/pkg/errors"
+)
+
+const (
+	AveragePriceSource  = "average"
+	AdjustedPriceSource = "adjusted"
+)
+
+type priceSource struct {
+	esi  *goesi.APIClient
+	kind string
+}
+
+// NewPriceSource returns price source using public /markets/prices/ ESI route,
+// kind selects either average or adjusted price.
+func NewPriceSource(client *http.Client, kind string) (*priceSource, error) {
+	if kind != AveragePriceSource && kind != AdjustedPriceSource {
+		return nil, fmt.Errorf("unknown price source: %s, use %s or %s", kind, AveragePriceSource, AdjustedPriceSource)
+	}
+	return &priceSource{
+		esi:  goesi.NewAPIClient(client, "EVE Accountant"),
+		kind: kind,
+	}, nil
+}
+
+func (p *priceSource) Prices(ctx context.Context) (map[balanceEntity.TypeId]balanceEntity.Amount, error) {
+	esiPrices, _, err := p.esi.ESI.MarketApi.GetMarketsPrices(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get market prices")
+	}
+
+	prices := make(map[balanceEntity.TypeId]balanceEntity.Amount, len(esiPrices))
+	for _, esiPrice := range esiPrices {
+		price := esiPrice.AveragePrice
+		if p.kind == AdjustedPriceSource {
+			price = esiPrice.AdjustedPrice
+		}
+		prices[balanceEntity.TypeId(esiPrice.TypeId)] = balanceEntity.Amount(price)
+	}
+	return prices, nil
+}
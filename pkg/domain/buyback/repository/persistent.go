@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/entity"
+	"github.com/pkg/errors"
+
+	"github.com/asdine/storm/v3"
+	"go.uber.org/zap"
+)
+
+// esiRepository is the source of corporation contracts.
+type esiRepository interface {
+	CorporationID() balanceEntity.CorporationID
+	Contracts(ctx context.Context) ([]aggregate.Contract, time.Time, error)
+	ContractItems(ctx context.Context, contractID entity.ContractId) ([]aggregate.ContractItem, error)
+}
+
+type persistentRepository struct {
+	log           *zap.Logger
+	contractsNode storm.Node
+	esiRepository esiRepository
+}
+
+const (
+	contractsNodeKey = "contracts"
+
+	metadataKey = "metadata"
+)
+
+// Metadata of the last contracts synchronization.
+type Metadata struct {
+	Metadata  string `storm:"id,unique"`
+	UpdatedAt time.Time
+	ExpiresAt time.Time // When ESI cache of the contracts expires.
+}
+
+func New(log *zap.Logger, db *storm.DB, esiRepository esiRepository) *persistentRepository {
+	return &persistentRepository{
+		log:           log,
+		contractsNode: db.From(fmt.Sprintf("%d", esiRepository.CorporationID()), contractsNodeKey),
+		esiRepository: esiRepository,
+	}
+}
+
+func (r *persistentRepository) CorporationID() balanceEntity.CorporationID {
+	return r.esiRepository.CorporationID()
+}
+
+// Contracts reads buyback contracts completed within the period from local DB
+// only, use Sync to download new contracts from ESI.
+func (r *persistentRepository) Contracts(ctx context.Context, period balanceEntity.Period) ([]aggregate.Contract, error) {
+	var (
+		stored    []aggregate.Contract
+		contracts []aggregate.Contract
+	)
+	// Dates are indexed as UTC JSON strings, see balance repository rangeBounds.
+	err := r.contractsNode.Range("DateCompleted", period.Start.UTC(), period.End.UTC().Add(-1*time.Second), &stored)
+	if err != nil {
+		return nil, errors.Wrap(err, "error fetching contracts from DB")
+	}
+	for _, contract := range stored {
+		if period.Contains(contract.DateCompleted) {
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts, nil
+}
+
+// Sync downloads items of new buyback contracts when ESI cache expired. Only
+// buyback contracts are stored.
+func (r *persistentRepository) Sync(ctx context.Context) error {
+	var metadata Metadata
+	err := r.contractsNode.One("Metadata", metadataKey, &metadata)
+	if err != nil && !errors.Is(err, storm.ErrNotFound) {
+		return errors.Wrap(err, "error loading metadata")
+	}
+	if time.Now().Before(metadata.ExpiresAt) {
+		return nil
+	}
+
+	contracts, expiresAt, err := r.esiRepository.Contracts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error listing contracts from ESI")
+	}
+
+	var inserted, skipped int
+	for _, contract := range contracts {
+		if !contract.IsBuyback(r.CorporationID()) {
+			continue
+		}
+		var stored aggregate.Contract
+		err = r.contractsNode.One("Id", contract.Id, &stored)
+		if err == nil {
+			skipped++
+			continue
+		}
+		if !errors.Is(err, storm.ErrNotFound) {
+			return errors.Wrapf(err, "error loading contract: %d", contract.Id)
+		}
+
+		contract.Items, err = r.esiRepository.ContractItems(ctx, contract.Id)
+		if err != nil {
+			return errors.Wrap(err, "error listing contract items from ESI")
+		}
+		err = r.contractsNode.Save(&contract)
+		if err != nil {
+			return errors.Wrapf(err, "error saving contract: %d", contract.Id)
+		}
+		inserted++
+	}
+
+	err = r.contractsNode.Save(&Metadata{
+		Metadata:  metadataKey,
+		UpdatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to update metadata")
+	}
+
+	r.log.Info("Buyback contracts synchronized",
+		zap.Int32("corporation_id", int32(r.CorporationID())),
+		zap.Int("contracts", len(contracts)),
+		zap.Int("inserted", inserted),
+		zap.Int("skipped", skipped),
+	)
+	return nil
+}
@@ -0,0 +1,71 @@
+package buyback
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	balanceEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback/aggregate"
+	"github.com/pkg/errors"
+)
+
+type Service interface {
+	Buyback(ctx context.Context, period balanceEntity.Period) (*aggregate.Buyback, error)
+}
+
+type buybackService struct {
+	balanceSvc   balance.Service
+	priceSource  PriceSource
+	repositories []Repository
+}
+
+func NewService(balanceSvc balance.Service, priceSource PriceSource, repositories ...Repository) *buybackService {
+	return &buybackService{
+		balanceSvc:   balanceSvc,
+		priceSource:  priceSource,
+		repositories: repositories,
+	}
+}
+
+// Buyback sums items members sold to the corporation by contract within the
+// period, and what the corporation sold these items for on the market. Sales
+// are matched with bought items first in first out, contracts completed before
+// the period are sold first and sales after the period count too.
+func (s *buybackService) Buyback(ctx context.Context, period balanceEntity.Period) (*aggregate.Buyback, error) {
+	buyback := aggregate.NewBuyback(period)
+
+	prices, err := s.priceSource.Prices(ctx)
+	if err != nil {
+		return buyback, errors.Wrap(err, "error loading item prices")
+	}
+	var contracts []aggregate.Contract
+	for _, repository := range s.repositories {
+		corporationContracts, err := repository.Contracts(ctx, balanceEntity.NewPeriod(time.Time{}, period.End))
+		if err != nil {
+			return buyback, errors.Wrapf(err, "error loading buyback contracts for corporation: %d", repository.CorporationID())
+		}
+		contracts = append(contracts, corporationContracts...)
+	}
+	sort.SliceStable(contracts, func(i, j int) bool {
+		return contracts[i].DateCompleted.Before(contracts[j].DateCompleted)
+	})
+	for _, contract := range contracts {
+		buyback.Record(contract, prices)
+	}
+	if len(contracts) == 0 {
+		return buyback, nil
+	}
+
+	// Sales of corporations that failed to load are missing, the partial error
+	// is returned with the rest.
+	sales, salesErr := s.balanceSvc.Sales(ctx, balanceEntity.NewPeriod(buyback.Since(), time.Now()), buyback.TypeIDs())
+	if salesErr != nil && !balance.IsPartial(salesErr) {
+		return buyback, errors.Wrap(salesErr, "error loading market transactions")
+	}
+	for _, sale := range sales {
+		buyback.RecordSale(sale)
+	}
+	return buyback, salesErr
+}
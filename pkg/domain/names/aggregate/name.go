@@ -0,0 +1,14 @@
+package aggregate
+
+import "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+type Name struct {
+	ID       entity.ID `storm:"id"`
+	Name     entity.Name
+	Category entity.Category
+}
+
+// IsCharacter returns true when the ID belongs to a character.
+func (n Name) IsCharacter() bool {
+	return n.Category == entity.CharacterCategory
+}
@@ -0,0 +1,10 @@
+package entity
+
+type (
+	ID       int32  /* ID of a character, corporation, alliance, item type or any other entity known to /universe/names/ */
+	Name     string /* Name of the entity */
+	Category string /* Category of the entity, e.g. character, corporation, inventory_type */
+)
+
+// CharacterCategory is the Category of player and NPC characters.
+const CharacterCategory = Category("character")
@@ -0,0 +1,12 @@
+package names
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+)
+
+type Repository interface {
+	Names(ctx context.Context, ids []entity.ID) ([]aggregate.Name, error)
+}
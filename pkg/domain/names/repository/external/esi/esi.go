@@ -0,0 +1,83 @@
+package esi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/antihax/goesi"
+	"github.com/pkg/errors"
+)
+
+// ESI accepts at most this many IDs in one /universe/names/ call.
+const maxIDsPerCall = 1000
+
+type repository struct {
+	esi *goesi.APIClient
+}
+
+// New returns repository resolving names with public /universe/names/ ESI route.
+func New(client *http.Client) *repository {
+	return &repository{
+		esi: goesi.NewAPIClient(client, "EVE Accountant"),
+	}
+}
+
+// Names resolves IDs in batches, IDs ESI does not know are left out of the
+// result.
+func (r *repository) Names(ctx context.Context, ids []entity.ID) ([]aggregate.Name, error) {
+	var names []aggregate.Name
+	for start := 0; start < len(ids); start += maxIDsPerCall {
+		end := start + maxIDsPerCall
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batchNames, err := r.names(ctx, ids[start:end])
+		if err != nil {
+			return names, err
+		}
+		names = append(names, batchNames...)
+	}
+	return names, nil
+}
+
+// names resolves one batch, ESI fails the whole call with 404 when any of the
+// IDs is unknown, so such batch is split in halves until the unknown IDs are
+// isolated.
+func (r *repository) names(ctx context.Context, ids []entity.ID) ([]aggregate.Name, error) {
+	esiIDs := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		esiIDs = append(esiIDs, int32(id))
+	}
+	esiNames, resp, err := r.esi.ESI.UniverseApi.PostUniverseNames(ctx, esiIDs, nil)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return nil, errors.Wrap(err, "unable to resolve names")
+		}
+		if len(ids) == 1 {
+			return nil, nil
+		}
+		half := len(ids) / 2
+		names, err := r.names(ctx, ids[:half])
+		if err != nil {
+			return nil, err
+		}
+		otherNames, err := r.names(ctx, ids[half:])
+		if err != nil {
+			return nil, err
+		}
+		return append(names, otherNames...), nil
+	}
+
+	names := make([]aggregate.Name, 0, len(esiNames))
+	for _, esiName := range esiNames {
+		names = append(names, aggregate.Name{
+			ID:       entity.ID(esiName.Id),
+			Name:     entity.Name(esiName.Name),
+			Category: entity.Category(esiName.Category),
+		})
+	}
+	return names, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/names"
+	"github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+	"github.com/pkg/errors"
+
+	"github.com/asdine/storm/v3"
+)
+
+const (
+	namesNodeKey = "names"
+
+	// unknownCategory marks IDs ESI could not resolve, so they are not
+	// requested again.
+	unknownCategory = entity.Category("unknown")
+)
+
+type persistentRepository struct {
+	namesNode     storm.Node
+	esiRepository names.Repository
+}
+
+func New(db *storm.DB, esiRepository names.Repository) *persistentRepository {
+	return &persistentRepository{
+		namesNode:     db.From(namesNodeKey),
+		esiRepository: esiRepository,
+	}
+}
+
+// NewCached returns repository of names cached in DB only, names missing from
+// it are left out and not resolved with ESI.
+func NewCached(db *storm.DB) *persistentRepository {
+	return &persistentRepository{
+		namesNode: db.From(namesNodeKey),
+	}
+}
+
+// Names returns cached names and resolves the rest with ESI, names never change
+// so the cache is never invalidated.
+func (r *persistentRepository) Names(ctx context.Context, ids []entity.ID) ([]aggregate.Name, error) {
+	var (
+		names      = make([]aggregate.Name, 0, len(ids))
+		missingIDs []entity.ID
+	)
+	for _, id := range ids {
+		var name aggregate.Name
+		err := r.namesNode.One("ID", id, &name)
+		if err != nil {
+			if errors.Is(err, storm.ErrNotFound) {
+				missingIDs = append(missingIDs, id)
+				continue
+			}
+			return nil, errors.Wrap(err, "error loading name from DB")
+		}
+		if name.Category != unknownCategory {
+			names = append(names, name)
+		}
+	}
+	if len(missingIDs) == 0 || r.esiRepository == nil {
+		return names, nil
+	}
+
+	esiNames, err := r.esiRepository.Names(ctx, missingIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "error resolving names with ESI")
+	}
+
+	tx, err := r.namesNode.Begin(true)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to begin tx")
+	}
+	defer tx.Rollback()
+	resolved := make(map[entity.ID]struct{}, len(esiNames))
+	for i := range esiNames {
+		resolved[esiNames[i].ID] = struct{}{}
+		err = tx.Save(&esiNames[i])
+		if err != nil {
+			return nil, errors.Wrap(err, "error saving name")
+		}
+	}
+	for _, id := range missingIDs {
+		if _, ok := resolved[id]; ok {
+			continue
+		}
+		err = tx.Save(&aggregate.Name{ID: id, Category: unknownCategory})
+		if err != nil {
+			return nil, errors.Wrap(err, "error saving unknown name")
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Wrap(err, "error commiting tx")
+	}
+
+	return append(names, esiNames...), nil
+}
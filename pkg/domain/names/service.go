@@ -0,0 +1,54 @@
+package names
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+	"github.com/pkg/errors"
+)
+
+type Service interface {
+	Names(ctx context.Context, ids []entity.ID) (map[entity.ID]aggregate.Name, error)
+}
+
+type namesService struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *namesService {
+	return &namesService{
+		repository: repository,
+	}
+}
+
+// Names returns names of unique non-zero IDs.
+func (s *namesService) Names(ctx context.Context, ids []entity.ID) (map[entity.ID]aggregate.Name, error) {
+	var (
+		seen      = make(map[entity.ID]struct{}, len(ids))
+		uniqueIDs = make([]entity.ID, 0, len(ids))
+		out       = make(map[entity.ID]aggregate.Name, len(ids))
+	)
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		uniqueIDs = append(uniqueIDs, id)
+	}
+	if len(uniqueIDs) == 0 {
+		return out, nil
+	}
+
+	names, err := s.repository.Names(ctx, uniqueIDs)
+	if err != nil {
+		return out, errors.Wrap(err, "error resolving names")
+	}
+	for _, name := range names {
+		out[name.ID] = name
+	}
+	return out, nil
+}
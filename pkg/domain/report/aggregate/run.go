@@ -0,0 +1,14 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/report/entity"
+)
+
+// Run is the last run of a scheduled report, persisted so runs are neither
+// repeated nor missed across restarts.
+type Run struct {
+	Name entity.Name `storm:"id"`
+	At   time.Time
+}
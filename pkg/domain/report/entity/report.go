@@ -0,0 +1,5 @@
+package entity
+
+type (
+	Name string /* Name of the scheduled report, unique within the reports file */
+)
@@ -0,0 +1,14 @@
+package report
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/report/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/report/entity"
+)
+
+type Repository interface {
+	// LastRun returns nil when report with the name never ran.
+	LastRun(ctx context.Context, name entity.Name) (*aggregate.Run, error)
+	SaveRun(ctx context.Context, run aggregate.Run) error
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/report/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/report/entity"
+	"github.com/pkg/errors"
+
+	"github.com/asdine/storm/v3"
+)
+
+const runsNodeKey = "report_runs"
+
+type persistentRepository struct {
+	runsNode storm.Node
+}
+
+func New(db *storm.DB) *persistentRepository {
+	return &persistentRepository{
+		runsNode: db.From(runsNodeKey),
+	}
+}
+
+func (r *persistentRepository) LastRun(ctx context.Context, name entity.Name) (*aggregate.Run, error) {
+	var run aggregate.Run
+	err := r.runsNode.One("Name", name, &run)
+	if err != nil {
+		if errors.Is(err, storm.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "error loading report run from DB")
+	}
+	return &run, nil
+}
+
+func (r *persistentRepository) SaveRun(ctx context.Context, run aggregate.Run) error {
+	err := r.runsNode.Save(&run)
+	if err != nil {
+		return errors.Wrap(err, "error saving report run")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/report/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/report/entity"
+	"github.com/pkg/errors"
+)
+
+type Service interface {
+	LastRun(ctx context.Context, name entity.Name) (time.Time, error)
+	MarkRun(ctx context.Context, name entity.Name, at time.Time) error
+}
+
+type reportService struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *reportService {
+	return &reportService{
+		repository: repository,
+	}
+}
+
+// LastRun returns time of the last run of scheduled report, zero when it never
+// ran.
+func (s *reportService) LastRun(ctx context.Context, name entity.Name) (time.Time, error) {
+	run, err := s.repository.LastRun(ctx, name)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "error loading last run of report: %s", name)
+	}
+	if run == nil {
+		return time.Time{}, nil
+	}
+	return run.At, nil
+}
+
+// MarkRun remembers that scheduled report ran at.
+func (s *reportService) MarkRun(ctx context.Context, name entity.Name, at time.Time) error {
+	err := s.repository.SaveRun(ctx, aggregate.Run{Name: name, At: at})
+	if err != nil {
+		return errors.Wrapf(err, "error marking run of report: %s", name)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// writeCSV writes header with column names and one line per row.
+func writeCSV(w io.Writer, table *Table) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(table.Columns)
+	if err != nil {
+		return errors.Wrap(err, "error writing CSV header")
+	}
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = text(row[i])
+			}
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return errors.Wrap(err, "error writing CSV row")
+		}
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "error writing CSV")
+}
@@ -0,0 +1,127 @@
+// Package export writes journal records and reports as CSV, JSON lines or
+// XLSX, shared by the export command and Discord.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Format of exported file.
+type Format string
+
+const (
+	CSV       Format = "csv"
+	JSONLines Format = "jsonl"
+	XLSX      Format = "xlsx"
+)
+
+// Formats lists all supported formats.
+var Formats = []Format{CSV, JSONLines, XLSX}
+
+// ParseFormat returns format by name, "json" means JSON lines.
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimPrefix(name, "."))
+	if name == "json" {
+		return JSONLines, nil
+	}
+	for _, format := range Formats {
+		if Format(name) == format {
+			return format, nil
+		}
+	}
+	return "", errors.Errorf("unknown export format: %q, use %s", name, joinFormats())
+}
+
+func joinFormats() string {
+	names := make([]string, 0, len(Formats))
+	for _, format := range Formats {
+		names = append(names, string(format))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Extension returns file extension of the format including the dot.
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// ContentType returns MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv"
+	case JSONLines:
+		return "application/jsonl"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Table is exported as one file, or one sheet of XLSX. Cells are strings,
+// float64, int64 or time.Time.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// NewTable returns empty table with the columns.
+func NewTable(name string, columns ...string) *Table {
+	return &Table{
+		Name:    name,
+		Columns: columns,
+	}
+}
+
+// Append adds row of cells in order of columns.
+func (t *Table) Append(cells ...interface{}) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Write writes the table to w in the format.
+func Write(w io.Writer, format Format, table *Table) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, table)
+	case JSONLines:
+		return writeJSONLines(w, table)
+	case XLSX:
+		return writeXLSX(w, table)
+	}
+	return errors.Errorf("unknown export format: %q", format)
+}
+
+// timeFormat of time cells in text formats, times keep their location.
+const timeFormat = time.RFC3339
+
+// text returns cell as text.
+func text(cell interface{}) string {
+	switch value := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format(timeFormat)
+	}
+	return fmt.Sprint(cell)
+}
+
+// FileName returns name of exported file like `journal-2024-03-01-2024-03-31.csv`.
+func FileName(report Report, format Format, firstDay, lastDay time.Time) string {
+	return fmt.Sprintf("%s-%s-%s%s", report, firstDay.Format("2006-01-02"), lastDay.Format("2006-01-02"), format.Extension())
+}
@@ -0,0 +1,52 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// writeJSONLines writes one JSON object per row, keys are column names in
+// order of columns. Numbers stay numbers, times are RFC 3339 strings.
+func writeJSONLines(w io.Writer, table *Table) error {
+	writer := bufio.NewWriter(w)
+	var line bytes.Buffer
+	for _, row := range table.Rows {
+		line.Reset()
+		line.WriteByte('{')
+		for i, column := range table.Columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			key, err := json.Marshal(column)
+			if err != nil {
+				return errors.Wrap(err, "error encoding column name")
+			}
+			line.Write(key)
+			line.WriteByte(':')
+
+			var cell interface{}
+			if i < len(row) {
+				cell = row[i]
+			}
+			if value, ok := cell.(time.Time); ok {
+				cell = text(value)
+			}
+			value, err := json.Marshal(cell)
+			if err != nil {
+				return errors.Wrapf(err, "error encoding column: %s", column)
+			}
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+		_, err := writer.Write(line.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "error writing JSON lines")
+		}
+	}
+	return errors.Wrap(writer.Flush(), "error writing JSON lines")
+}
@@ -0,0 +1,51 @@
+package export
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Report is what is exported, raw journal or one of the aggregate reports.
+type Report string
+
+const (
+	JournalReport       Report = "journal"
+	BalanceReport       Report = "balance"
+	ByDivisionReport    Report = "by_division"
+	ByTypeReport        Report = "by_type"
+	ByMemberReport      Report = "by_member"
+	DailyReport         Report = "daily"
+	WalletBalanceReport Report = "wallet_balance"
+	MarketReport        Report = "market"
+	KrabReport          Report = "krab"
+)
+
+// Reports lists all reports which can be exported.
+var Reports = []Report{
+	JournalReport,
+	BalanceReport,
+	ByDivisionReport,
+	ByTypeReport,
+	ByMemberReport,
+	DailyReport,
+	WalletBalanceReport,
+	MarketReport,
+	KrabReport,
+}
+
+// ParseReport returns report by name, spaces may be used instead of
+// underscores like `by division`.
+func ParseReport(name string) (Report, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	for _, report := range Reports {
+		if Report(name) == report {
+			return report, nil
+		}
+	}
+	names := make([]string, 0, len(Reports))
+	for _, report := range Reports {
+		names = append(names, string(report))
+	}
+	return "", errors.Errorf("unknown report: %q, use %s", name, strings.Join(names, ", "))
+}
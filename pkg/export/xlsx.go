@@ -0,0 +1,157 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Styles of XLSX cells, indexes into cellXfs of xlsxStyles.
+const (
+	xlsxDefaultStyle = 0
+	xlsxTimeStyle    = 1
+	xlsxHeaderStyle  = 2
+)
+
+// Sheet names are limited to 31 characters without []:*?/\.
+const maxSheetNameLength = 31
+
+var sheetNameReplacer = strings.NewReplacer("[", "(", "]", ")", ":", "-", "*", "-", "?", "", "/", "-", "\\", "-")
+
+// xlsxEpoch is day zero of Excel dates.
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`
+
+// writeXLSX writes workbook with one sheet, header row is bold and frozen.
+// Numbers are numeric cells and times are dates in their location.
+func writeXLSX(w io.Writer, table *Table) error {
+	archive := zip.NewWriter(w)
+	for _, file := range []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escapeXML(sheetName(table.Name)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	} {
+		fileWriter, err := archive.Create(file.name)
+		if err != nil {
+			return errors.Wrapf(err, "error creating XLSX part: %s", file.name)
+		}
+		_, err = io.WriteString(fileWriter, file.content)
+		if err != nil {
+			return errors.Wrapf(err, "error writing XLSX part: %s", file.name)
+		}
+	}
+
+	fileWriter, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return errors.Wrap(err, "error creating XLSX sheet")
+	}
+	err = writeSheet(fileWriter, table)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(archive.Close(), "error writing XLSX")
+}
+
+func writeSheet(w io.Writer, table *Table) error {
+	writer := bufio.NewWriter(w)
+	writer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	writer.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	writer.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	writer.WriteString(`<sheetData>`)
+
+	header := make([]interface{}, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column
+	}
+	writeRow(writer, 1, header, xlsxHeaderStyle)
+	for i, row := range table.Rows {
+		writeRow(writer, i+2, row, xlsxDefaultStyle)
+	}
+
+	writer.WriteString(`</sheetData></worksheet>`)
+	return errors.Wrap(writer.Flush(), "error writing XLSX sheet")
+}
+
+func writeRow(w *bufio.Writer, number int, cells []interface{}, style int) {
+	w.WriteString(fmt.Sprintf(`<row r="%d">`, number))
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(number)
+		switch value := cell.(type) {
+		case nil:
+		case float64:
+			w.WriteString(fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(value, 'f', -1, 64)))
+		case int64:
+			w.WriteString(fmt.Sprintf(`<c r="%s" s="%d"><v>%d</v></c>`, ref, style, value))
+		case time.Time:
+			if value.IsZero() {
+				continue
+			}
+			w.WriteString(fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxTimeStyle, strconv.FormatFloat(serialDate(value), 'f', -1, 64)))
+		default:
+			w.WriteString(fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escapeXML(text(cell))))
+		}
+	}
+	w.WriteString(`</row>`)
+}
+
+// serialDate returns Excel date of wall clock of t, as days since xlsxEpoch.
+func serialDate(t time.Time) float64 {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	wall := time.Date(year, month, day, hour, minute, second, t.Nanosecond(), time.UTC)
+	return wall.Sub(xlsxEpoch).Hours() / 24
+}
+
+// columnName returns name of zero based column, A to Z, AA and so on.
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+func sheetName(name string) string {
+	name = sheetNameReplacer.Replace(name)
+	if name == "" {
+		return "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > maxSheetNameLength {
+		name = string(runes[:maxSheetNameLength])
+	}
+	return name
+}
+
+func escapeXML(s string) string {
+	var escaped strings.Builder
+	// EscapeText only fails when writing fails, strings.Builder never does.
+	_ = xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
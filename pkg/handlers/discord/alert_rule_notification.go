@@ -0,0 +1,72 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	alertDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// AlertMessage returns alert of a rule, Discord notifiers send it to the
+// channel of the rule when it has one.
+func (h *discordHandler) AlertMessage(ctx context.Context, alert alertDomainAggregate.Alert) (notify.Message, error) {
+	embed, err := h.alertMessage(ctx, alert)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	h.setDataAsOf(embed)
+	message := notify.FromEmbed(notify.RuleKind, embed)
+	message.Channel = string(alert.Rule.Channel)
+	return message, nil
+}
+
+func (h *discordHandler) alertMessage(ctx context.Context, alert alertDomainAggregate.Alert) (*discordgo.MessageEmbed, error) {
+	format := func(amount balanceDomainEntity.Amount) string {
+		return humanize.FormatFloat(floatFormat, float64(amount))
+	}
+	rule := alert.Rule
+
+	var description string
+	switch rule.Kind {
+	case alertDomainAggregate.DivisionBalanceFloor:
+		description = fmt.Sprintf("Balance of division %s is `%s` < `%s`.", rule.Division, format(alert.Value), format(rule.Amount))
+	case alertDomainAggregate.TypeSpendingCap:
+		description = fmt.Sprintf("Spent `%s` > `%s` on %s in the last %s.", format(alert.Value), format(rule.Amount), rule.RefType, rule.Window)
+	case alertDomainAggregate.NetLossLimit:
+		description = fmt.Sprintf("Lost `%s` > `%s` in the last %s.", format(alert.Value), format(rule.Amount), rule.Window)
+	case alertDomainAggregate.LargeTransaction:
+		record := alert.Record.Record
+		names, err := h.accountantSvc.Names(ctx, []namesDomainEntity.ID{
+			namesDomainEntity.ID(alert.Record.Wallet.CorporationID),
+			namesDomainEntity.ID(record.FirstPartyId),
+			namesDomainEntity.ID(record.SecondPartyId),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "error resolving names")
+		}
+		description = fmt.Sprintf(
+			"`%s` %s from %s to %s, %s %s at %s.\n%s",
+			format(record.Amount),
+			record.RefType,
+			partyName(names, balanceDomainEntity.PartyID(record.FirstPartyId)),
+			partyName(names, balanceDomainEntity.PartyID(record.SecondPartyId)),
+			partyName(names, balanceDomainEntity.PartyID(alert.Record.Wallet.CorporationID)),
+			alert.Record.Wallet.Division,
+			record.Date.In(h.location).Format("2006-01-02 15:04"),
+			record.Description,
+		)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", alertRuleMsg, rule.Name),
+		Description: description,
+		Color:       0xff0000,
+	}, nil
+}
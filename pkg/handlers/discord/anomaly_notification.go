@@ -0,0 +1,77 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// AnomalyMessage returns alert of unusual journal record with resolved names.
+func (h *discordHandler) AnomalyMessage(ctx context.Context, anomaly balanceDomainAggrgate.Anomaly) (notify.Message, error) {
+	ids := []namesDomainEntity.ID{
+		namesDomainEntity.ID(anomaly.CorporationID),
+		namesDomainEntity.ID(anomaly.Record.FirstPartyId),
+		namesDomainEntity.ID(anomaly.Record.SecondPartyId),
+	}
+	names, err := h.accountantSvc.Names(ctx, ids)
+	if err != nil {
+		return notify.Message{}, errors.Wrap(err, "error resolving names")
+	}
+
+	message := h.anomalyMessage(anomaly, names)
+	h.setDataAsOf(message)
+	return notify.FromEmbed(notify.AnomalyKind, message), nil
+}
+
+func (h *discordHandler) anomalyMessage(anomaly balanceDomainAggrgate.Anomaly, names map[namesDomainEntity.ID]namesDomainAggregate.Name) *discordgo.MessageEmbed {
+	format := func(amount balanceDomainEntity.Amount) string {
+		return humanize.FormatFloat(floatFormat, float64(amount))
+	}
+	record := anomaly.Record
+
+	var description strings.Builder
+	for _, reason := range anomaly.Reasons {
+		switch reason {
+		case balanceDomainAggrgate.LargeWithdrawal:
+			description.WriteString(fmt.Sprintf("Withdrawal is %.1fx the typical `%s`.\n", float64(record.Amount/anomaly.Typical), format(anomaly.Typical)))
+		case balanceDomainAggrgate.NewRecipient:
+			description.WriteString(fmt.Sprintf("%s received no ISK in the last 90 days.\n", partyName(names, anomaly.Party)))
+		case balanceDomainAggrgate.NewRefType:
+			description.WriteString(fmt.Sprintf("Ref type `%s` was not seen in the last 90 days.\n", record.RefType))
+		}
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Amount", Value: fmt.Sprintf("`%s`", format(record.Amount)), Inline: true},
+		{Name: "Ref type", Value: string(record.RefType), Inline: true},
+		{Name: "Date", Value: record.Date.In(h.location).Format("2006-01-02 15:04"), Inline: true},
+		{Name: "Corporation", Value: partyName(names, balanceDomainEntity.PartyID(anomaly.CorporationID)), Inline: true},
+		{Name: "Division", Value: string(anomaly.Division), Inline: true},
+		{Name: "Journal ID", Value: fmt.Sprintf("%d", record.Id), Inline: true},
+		{Name: "First party", Value: partyName(names, balanceDomainEntity.PartyID(record.FirstPartyId)), Inline: true},
+		{Name: "Second party", Value: partyName(names, balanceDomainEntity.PartyID(record.SecondPartyId)), Inline: true},
+	}
+	if record.Description != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Description", Value: string(record.Description)})
+	}
+	if record.Reason != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Reason", Value: string(record.Reason)})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       anomalyMsg,
+		Description: description.String(),
+		Fields:      fields,
+		Color:       0xffa500,
+	}
+}
@@ -6,6 +6,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lunemec/eve-accountant/pkg/chart"
+	"github.com/lunemec/eve-accountant/pkg/daterange"
+	balanceDomain "github.com/lunemec/eve-accountant/pkg/domain/balance"
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
 	"github.com/lunemec/eve-accountant/pkg/services/accountant"
 	"github.com/pkg/errors"
 
@@ -19,67 +24,174 @@ var (
 	incomeMsg                     = ":chart_with_upwards_trend: Income"
 	expensesMsg                   = ":chart_with_downwards_trend: Expenses"
 	monthlyBalanceNotificationMsg = ":exclamation: Monthly Balance Low"
-	forMoreDetailsMsg             = "For more details run:\n\n`!isk by division`\n`!isk by type`\n`!isk graph`\n\n`!isk YYYY-MM-DD YYYY-MM-DD`\n`!isk by division YYYY-MM-DD YYYY-MM-DD`\n`!isk by type YYYY-MM-DD YYYY-MM-DD`\n`!isk graph YYYY-MM-DD YYYY-MM-DD`"
+	marketRevenueMsg              = ":moneybag: Top Items by Revenue"
+	marketVolumeMsg               = ":package: Top Items by Volume"
+	marketMarginMsg               = ":scales: Top Items by Realised Margin"
+	buybackByItemMsg              = ":shopping_cart: Buyback by Item"
+	buybackByMemberMsg            = ":busts_in_silhouette: Buyback by Member"
+	topContributorsMsg            = ":trophy: Top Contributors"
+	topRecipientsMsg              = ":money_with_wings: Top Recipients"
+	krabLeaderboardMsg            = ":crab: Krab Tax Leaderboard"
+	krabStoppedMsg                = ":zzz: Stopped Krabbing, paid tax"
+	krabHistoryMsg                = ":crab: Krab Tax History of"
+	walletBalanceMsg              = ":bank: Wallet Balance at End of Day"
+	movementByMsg                 = ":bar_chart: Income and Expenses by"
+	compareToPreviousMsg          = ":scales: Compared to Previous Period"
+	compareToYearAgoMsg           = ":scales: Compared to Last Year"
+	compareByDivisionMsg          = "By division"
+	compareTopMoversMsg           = "Biggest movers by type"
+	forecastMsg                   = ":crystal_ball: Forecast"
+	forecastNotificationMsg       = ":warning: Balance Projected Below Threshold"
+	forecastBelowThresholdMsg     = "Balance is projected to end the month below the threshold."
+	forecastMayFallBelowMsg       = "Balance may end the month below the threshold."
+	anomalyMsg                    = ":rotating_light: Unusual Transaction"
+	alertRuleMsg                  = ":bell: Alert"
+	scheduledReportMsg            = ":calendar:"
+	journalMsg                    = ":mag: Journal"
+	noJournalRecordsMsg           = "No journal records match."
+	journalSearchExpiredMsg       = "This search expired, run it again."
+	journalSearchTruncatedMsg     = "Only the newest %d can be browsed, narrow the filters to see older ones."
+	exportMsg                     = ":floppy_disk: Export"
+	exportTooLargeMsg             = "export has %s, Discord accepts files up to %s, shorten the period or export with `eve-accountant export`"
+	alertResolvedMsg              = ":white_check_mark: Resolved:"
+	alertAcknowledgedMsg          = ":mute: Alert Acknowledged"
+	firingAlertsMsg               = ":bell: Firing Alerts"
+	noFiringAlertsMsg             = "No alerts are firing."
+	walletTotalMsg                = "Total"
+	unmappedTypesMsg              = ":grey_question: Ref Types without Group"
+	allTypesMappedMsg             = "All ref types belong to some group."
+	dataAsOfMsg                   = "Data as of"
+	dataNotSynchronizedMsg        = "Data not synchronized yet"
+	forMoreDetailsMsg             = "For more details run:\n\n`!isk by division`\n`!isk by type`\n`!isk by member`\n`!isk graph`\n`!isk graph balance`\n`!isk graph by type`\n`!isk graph by division`\n`!isk compare`\n`!isk forecast`\n`!isk market`\n`!isk buyback`\n`!isk krab`\n`!isk krab member <name>`\n`!isk journal type:<type> min:1b`\n`!isk export journal xlsx`\n`!isk ack`\n\n`!isk YYYY-MM-DD YYYY-MM-DD`\n`!isk by division YYYY-MM-DD YYYY-MM-DD`\n`!isk by type YYYY-MM-DD YYYY-MM-DD`\n`!isk by member YYYY-MM-DD YYYY-MM-DD`\n`!isk graph YYYY-MM-DD YYYY-MM-DD`\n`!isk graph balance YYYY-MM-DD YYYY-MM-DD`\n`!isk graph by type [daily|weekly|monthly] YYYY-MM-DD YYYY-MM-DD`\n`!isk compare last month`\n`!isk market YYYY-MM-DD YYYY-MM-DD`\n`!isk buyback YYYY-MM-DD YYYY-MM-DD`\n`!isk krab YYYY-MM-DD YYYY-MM-DD`"
 )
 
 type discordHandler struct {
-	ctx       context.Context
-	log       *zap.Logger
-	discord   *discordgo.Session
-	channelID string
+	ctx     context.Context
+	log     *zap.Logger
+	discord *discordgo.Session
+	// guildID to register slash commands in, global when empty.
+	guildID string
+	// prefixCommands enables legacy !isk commands read from messages.
+	prefixCommands bool
 
 	accountantSvc accountant.Service
+	chartRenderer chart.Renderer
+	// location of reporting days, periods typed by users are parsed in it.
+	location *time.Location
+	// journalSearches can be browsed by page buttons.
+	journalSearches *journalSearches
 }
 
 func New(
 	ctx context.Context,
 	log *zap.Logger,
 	discord *discordgo.Session,
-	channelID string,
+	guildID string,
+	prefixCommands bool,
 	accountantSvc accountant.Service,
+	chartRenderer chart.Renderer,
+	location *time.Location,
 ) *discordHandler {
 	return &discordHandler{
-		ctx:           ctx,
-		log:           log,
-		discord:       discord,
-		channelID:     channelID,
-		accountantSvc: accountantSvc,
+		ctx:             ctx,
+		log:             log,
+		discord:         discord,
+		guildID:         guildID,
+		prefixCommands:  prefixCommands,
+		accountantSvc:   accountantSvc,
+		chartRenderer:   chartRenderer,
+		location:        location,
+		journalSearches: newJournalSearches(),
 	}
 }
 
 func (h *discordHandler) Start() {
 	h.log.Info("Discord handler started.")
-	h.discord.AddHandler(h.router)
+	h.discord.AddHandler(h.interactionRouter)
+	if h.prefixCommands {
+		h.discord.AddHandler(h.router)
+	}
+	err := h.registerCommands()
+	if err != nil {
+		h.log.Error("error registering slash commands", zap.Error(err))
+	}
 	<-h.ctx.Done()
 }
 
+// router handles prefix commands, message content is only available with
+// Message Content intent.
 func (h *discordHandler) router(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Ignore all messages created by the bot itself.
 	if m.Author.ID == s.State.User.ID {
 		return
 	}
+	r := &messageReply{h: h, m: m}
 	if ok, _ := h.command("!help", m.Content); ok {
-		h.helpHandler(s, m, nil)
+		h.helpHandler(r, nil)
 	}
 	if ok, args := h.command("!isk by division", m.Content); ok {
-		h.iskByDivisionHandler(s, m, args)
+		h.iskByDivisionHandler(r, args)
 		return
 	}
 	if ok, args := h.command("!isk by type", m.Content); ok {
-		h.iskByTypeHandler(s, m, args)
+		h.iskByTypeHandler(r, args)
+		return
+	}
+
+	if ok, args := h.command("!isk by member", m.Content); ok {
+		h.iskByMemberHandler(r, args)
+		return
+	}
+	if ok, args := h.command("!isk market", m.Content); ok {
+		h.iskMarketHandler(r, args)
+		return
+	}
+
+	if ok, args := h.command("!isk buyback", m.Content); ok {
+		h.iskBuybackHandler(r, args)
+		return
+	}
+
+	if ok, args := h.command("!isk krab", m.Content); ok {
+		h.iskKrabHandler(r, args)
+		return
+	}
+
+	if ok, args := h.command("!isk forecast", m.Content); ok {
+		h.iskForecastHandler(r, args)
+		return
+	}
+	if ok, args := h.command("!isk compare", m.Content); ok {
+		h.iskCompareHandler(r, args)
+		return
+	}
+	if ok, args := h.command("!isk journal", m.Content); ok {
+		h.iskJournalHandler(r, args)
+		return
+	}
+	if ok, args := h.command("!isk export", m.Content); ok {
+		h.iskExportHandler(r, args)
+		return
+	}
+	if ok, args := h.command("!isk ack", m.Content); ok {
+		h.iskAckHandler(r, args)
+		return
+	}
+	if ok, args := h.command("!isk types", m.Content); ok {
+		h.iskTypesHandler(r, args)
 		return
 	}
 
 	if ok, args := h.command("!isk chart", m.Content); ok {
-		h.iskGraphHandler(s, m, args)
+		h.iskGraphHandler(r, args)
 		return
 	}
 	if ok, args := h.command("!isk graph", m.Content); ok {
-		h.iskGraphHandler(s, m, args)
+		h.iskGraphHandler(r, args)
 		return
 	}
 	if ok, args := h.command("!isk", m.Content); ok {
-		h.iskHandler(s, m, args)
+		h.iskHandler(r, args)
 		return
 	}
 }
@@ -93,6 +205,38 @@ func (h *discordHandler) error(errIn error, channelID string) {
 	}
 }
 
+// balanceError reports error returned when calculating balance, returns true
+// when there are no results to show. Results with some corporations missing
+// are still shown after the error.
+func (h *discordHandler) balanceError(err error, r reply) bool {
+	if err == nil {
+		return false
+	}
+	if balanceDomain.IsPartial(err) {
+		r.Error(errors.Wrap(err, "some corporations are missing from the results"))
+		return false
+	}
+	r.Error(errors.Wrap(err, "error calculating balance"))
+	return true
+}
+
+// setDataAsOf marks embeds with time of the last synchronization with ESI.
+func (h *discordHandler) setDataAsOf(embeds ...*discordgo.MessageEmbed) {
+	dataAsOf, err := h.accountantSvc.DataAsOf(h.ctx)
+	if err != nil {
+		h.log.Error("error loading last synchronization time", zap.Error(err))
+		return
+	}
+	for _, embed := range embeds {
+		if dataAsOf.IsZero() {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: dataNotSynchronizedMsg}
+			continue
+		}
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: dataAsOfMsg}
+		embed.Timestamp = dataAsOf.Format(time.RFC3339) // Discord wants ISO8601; RFC3339 is an extension of ISO8601 and should be completely compatible.
+	}
+}
+
 func (h *discordHandler) command(command string, messageContent string) (bool, []string) {
 	if !strings.HasPrefix(messageContent, command) {
 		return false, nil
@@ -104,39 +248,28 @@ func (h *discordHandler) command(command string, messageContent string) (bool, [
 	return true, params
 }
 
-func (h *discordHandler) parseDateStartDateEnd(params []string) (time.Time, time.Time, error) {
-	var (
-		err                error
-		dateStart, dateEnd time.Time
-	)
-	if len(params) == 2 {
-		format := "2006-01-02"
-		dateStart, err = time.Parse(format, params[0])
-		if err != nil {
-			return dateStart, dateEnd, errors.Wrap(err, "unknown date format, use YYYY-MM-DD")
-		}
-		dateEnd, err = time.Parse(format, params[1])
-		if err != nil {
-			return dateStart, dateEnd, errors.Wrap(err, "unknown date format, use YYYY-MM-DD")
-		}
-	} else {
-		now := time.Now()
-		currentYear, currentMonth, _ := now.Date()
-
-		dateStart = time.Date(currentYear, currentMonth, 1, 0, 0, 0, 0, time.UTC)
-		dateEnd = dateStart.AddDate(0, 1, -1)
-	}
-	return dateStart, dateEnd, nil
+// parsePeriod returns period given by command arguments in reporting
+// location, see daterange.Parse.
+func (h *discordHandler) parsePeriod(params []string) (balanceDomainEntity.Period, error) {
+	return daterange.Parse(params, time.Now().In(h.location))
 }
 
-func titleWithDate(dateStart, dateEnd time.Time) string {
-	var title string
-	if dateStart.Month() == dateEnd.Month() {
-		currentYear, currentMonth, _ := dateStart.Date()
-		title = fmt.Sprintf("for %s %d", currentMonth.String(), currentYear)
-	} else {
-		startYear, startMonth, _ := dateStart.Date()
-		endYear, endMonth, _ := dateEnd.Date()
+func titleWithDate(period balanceDomainEntity.Period) string {
+	var (
+		title                    string
+		startYear, startMonth, _ = period.Start.Date()
+		lastDay                  = period.LastDay()
+		endYear, endMonth, _     = lastDay.Date()
+		wholeMonths              = period.Start.Equal(balanceDomainAggrgate.Month(period.Start)) && period.End.Equal(balanceDomainAggrgate.Month(period.End))
+	)
+	switch {
+	case !wholeMonths && period.Start.Equal(lastDay):
+		title = fmt.Sprintf("for %s", period.Start.Format("2006-01-02"))
+	case !wholeMonths:
+		title = fmt.Sprintf("for %s - %s", period.Start.Format("2006-01-02"), lastDay.Format("2006-01-02"))
+	case startYear == endYear && startMonth == endMonth:
+		title = fmt.Sprintf("for %s %d", startMonth.String(), startYear)
+	default:
 		title = fmt.Sprintf("for %s %d - %s %d", startMonth.String(), startYear, endMonth.String(), endYear)
 	}
 
@@ -9,15 +9,31 @@ import (
 
 // helpHandler will be called every time a new
 // message is created on any channel that the autenticated bot has access to.
-func (h *discordHandler) helpHandler(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
+func (h *discordHandler) helpHandler(r reply, args []string) {
 	msg := "I'll keep track of your transactions and help with corporation ISK. \n\n" +
 		"Here is the list of commands you can use:\n" +
 		"`!help` - shows this help message\n" +
 		"`!isk` - top level balance overview\n" +
 		"`!isk by division` - balance overview grouped by each division\n" +
-		"`!isk by type` - balance overview grouped by transaction type"
+		"`!isk by type` - balance overview grouped by transaction type\n" +
+		"`!isk by member` - characters contributing and receiving most ISK, market transactions excluded\n" +
+		"`!isk graph` - daily movement of each division\n" +
+		"`!isk graph balance` - wallet balance of each division and total at the end of each day\n" +
+		"`!isk graph by type` / `!isk graph by division` - stacked income and expenses, add `weekly` or `monthly` for longer periods\n" +
+		"`!isk compare` - change against the previous period and the same period last year, with the biggest movers\n" +
+		"`!isk forecast` - projected balance at the end of this month\n" +
+		"`!isk market` - top traded items by revenue, volume and realised margin\n" +
+		"`!isk buyback` - items bought from members by contract and their resale\n" +
+		"`!isk krab` - leaderboard of ratting and mission tax paid by pilots\n" +
+		"`!isk krab member <name>` - monthly tax history of one pilot\n" +
+		"`!isk types unmapped` - raw transaction types which do not belong to any group\n" +
+		"`!isk journal [period] [division:<name>] [type:<type or group>] [party:<name>] [min:<isk>] [max:<isk>] [text:<text>]` - journal records matching all filters, e.g. `!isk journal last month type:player_donation min:1b`\n" +
+		"`!isk export <report> [csv|jsonl|xlsx] [period]` - journal or report as a file for spreadsheets, reports: " + reportNames() + "\n" +
+		"`!isk ack` - firing alerts, `!isk ack <alert> [12h|3d|YYYY-MM-DD]` stops repeating one until it clears or until given time\n\n" +
+		"Reports show this month by default, add a period to any command: `today`, `yesterday`, `this week`, `last week`, `last month`, `this year`, `ytd`, `last 30d`, `Q2 2024`, `2024-03`, `YYYY-MM-DD` or `YYYY-MM-DD YYYY-MM-DD`.\n\n" +
+		"Same commands are available as `/isk` and `/help` slash commands."
 
-	_, err := h.discord.ChannelMessageSendEmbed(m.ChannelID, &discordgo.MessageEmbed{
+	err := r.SendEmbed(&discordgo.MessageEmbed{
 		Title: "Hello, I'm your accountant.",
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
 			URL: "https://i.imgur.com/ZwUn8DI.jpg",
@@ -3,9 +3,9 @@ package discord
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/dustin/go-humanize"
@@ -14,35 +14,32 @@ import (
 
 // iskHandler will be called every time a new
 // message is created on any channel that the autenticated bot has access to.
-func (h *discordHandler) iskHandler(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	// React before starting the balance calculation (it takes quite few seconds to fetch everything).
-	err := h.discord.MessageReactionAdd(m.ChannelID, m.ID, `⏱️`)
-	if err != nil {
-		h.error(errors.Wrap(err, "error reacting with :stopwatch: emoji"), m.ChannelID)
-	}
+func (h *discordHandler) iskHandler(r reply, args []string) {
+	r.Working()
 
-	dateStart, dateEnd, err := h.parseDateStartDateEnd(args)
+	period, err := h.parsePeriod(args)
 	if err != nil {
-		h.error(err, m.ChannelID)
+		r.Error(err)
 		return
 	}
 
-	balance, err := h.accountantSvc.Balance(h.ctx, dateStart, dateEnd)
-	if err != nil {
-		h.error(errors.Wrap(err, "error calculating balance"), m.ChannelID)
+	balance, err := h.accountantSvc.Balance(h.ctx, period)
+	if h.balanceError(err, r) {
 		return
 	}
 
-	for _, messages := range h.iskMessages(dateStart, dateEnd, balance) {
-		_, err = h.discord.ChannelMessageSendEmbed(m.ChannelID, messages)
+	messages := h.iskMessages(period, balance)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
 		if err != nil {
-			h.error(errors.Wrap(err, "error sending balance message"), m.ChannelID)
+			r.Error(errors.Wrap(err, "error sending balance message"))
 			return
 		}
 	}
 }
 
-func (h *discordHandler) iskMessages(dateStart, dateEnd time.Time, balance *balanceDomainAggrgate.Balance) []*discordgo.MessageEmbed {
+func (h *discordHandler) iskMessages(period balanceDomainEntity.Period, balance *balanceDomainAggrgate.Balance) []*discordgo.MessageEmbed {
 	var (
 		balanceDescription strings.Builder
 	)
@@ -59,7 +56,7 @@ func (h *discordHandler) iskMessages(dateStart, dateEnd time.Time, balance *bala
 		),
 	)
 
-	title := fmt.Sprintf("%s %s", balanceMsg, titleWithDate(dateStart, dateEnd))
+	title := fmt.Sprintf("%s %s", balanceMsg, titleWithDate(period))
 	var messages = []*discordgo.MessageEmbed{
 		{
 			Title:       title,
@@ -0,0 +1,167 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	alertDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	alertDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// iskAckHandler lists firing alerts, or acknowledges one of them with
+// `!isk ack <key> [until]`, until being a duration like 12h or 3d or a date.
+func (h *discordHandler) iskAckHandler(r reply, args []string) {
+	// Prefix commands without arguments have one empty argument.
+	args = strings.Fields(strings.Join(args, " "))
+	firing, err := h.accountantSvc.FiringAlerts(h.ctx)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error listing firing alerts"))
+		return
+	}
+	if len(args) == 0 {
+		err = r.SendEmbed(h.firingAlertsMessage(firing))
+		if err != nil {
+			r.Error(errors.Wrap(err, "error sending firing alerts message"))
+		}
+		return
+	}
+
+	// Keys of rule alerts contain rule names which may have spaces, the
+	// longest matching key wins.
+	var (
+		key   alertDomainEntity.Key
+		until []string
+	)
+	for i := len(args); i > 0; i-- {
+		candidate := alertDomainEntity.Key(strings.Join(args[:i], " "))
+		for _, state := range firing {
+			if state.Key == candidate {
+				key = candidate
+				until = args[i:]
+				break
+			}
+		}
+		if key != "" {
+			break
+		}
+	}
+	if key == "" {
+		r.Error(errors.Errorf("no firing alert: %s, run `!isk ack` to list them", strings.Join(args, " ")))
+		return
+	}
+	untilTime, err := h.parseAckUntil(until)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	h.acknowledge(r, key, untilTime)
+}
+
+// alertButtonHandler acknowledges alert whose button was clicked.
+func (h *discordHandler) alertButtonHandler(r reply, customID string) {
+	switch {
+	case strings.HasPrefix(customID, notify.AckButtonPrefix):
+		h.acknowledge(r, alertDomainEntity.Key(strings.TrimPrefix(customID, notify.AckButtonPrefix)), time.Time{})
+	case strings.HasPrefix(customID, notify.SnoozeButtonPrefix):
+		h.acknowledge(r, alertDomainEntity.Key(strings.TrimPrefix(customID, notify.SnoozeButtonPrefix)), time.Now().Add(notify.SnoozeDuration))
+	default:
+		r.Error(errors.Errorf("unknown button: %s", customID))
+	}
+}
+
+// acknowledge silences alert until given time, or until it clears when until
+// is zero.
+func (h *discordHandler) acknowledge(r reply, key alertDomainEntity.Key, until time.Time) {
+	err := h.accountantSvc.AcknowledgeAlert(h.ctx, key, until, r.User())
+	if err != nil {
+		r.Error(errors.Wrap(err, "error acknowledging alert"))
+		return
+	}
+
+	description := fmt.Sprintf("`%s` acknowledged by %s until it clears.", key, r.User())
+	if !until.IsZero() {
+		description = fmt.Sprintf("`%s` acknowledged by %s until %s.", key, r.User(), until.In(h.location).Format("2006-01-02 15:04"))
+	}
+	err = r.SendEmbed(&discordgo.MessageEmbed{
+		Title:       alertAcknowledgedMsg,
+		Description: description,
+		Color:       0x00ff00,
+	})
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending acknowledgement message"))
+	}
+}
+
+// parseAckUntil returns end of acknowledgement, zero means until the alert
+// clears. Dates mean end of that day.
+func (h *discordHandler) parseAckUntil(args []string) (time.Time, error) {
+	if len(args) == 0 {
+		return time.Time{}, nil
+	}
+	if len(args) > 1 {
+		return time.Time{}, errors.Errorf("unable to parse acknowledgement end: %s", strings.Join(args, " "))
+	}
+	arg := args[0]
+	if date, err := time.ParseInLocation("2006-01-02", arg, h.location); err == nil {
+		return date.AddDate(0, 0, 1), nil
+	}
+	if len(arg) > 1 {
+		n, err := strconv.Atoi(arg[:len(arg)-1])
+		if err == nil && n > 0 {
+			switch arg[len(arg)-1] {
+			case 'h':
+				return time.Now().Add(time.Duration(n) * time.Hour), nil
+			case 'd':
+				return time.Now().AddDate(0, 0, n), nil
+			}
+		}
+	}
+	return time.Time{}, errors.Errorf("unable to parse acknowledgement end: %s, use hours like 12h, days like 3d or date YYYY-MM-DD", arg)
+}
+
+func (h *discordHandler) firingAlertsMessage(firing []alertDomainAggregate.State) *discordgo.MessageEmbed {
+	if len(firing) == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       firingAlertsMsg,
+			Description: noFiringAlertsMsg,
+			Color:       0x00ff00,
+		}
+	}
+
+	var description strings.Builder
+	for _, state := range firing {
+		description.WriteString(fmt.Sprintf("`%s` %s\nsince %s", state.Key, state.Title, state.SentAt.In(h.location).Format("2006-01-02 15:04")))
+		if state.Snoozed(time.Now()) {
+			description.WriteString(fmt.Sprintf(", acknowledged by %s", state.AcknowledgedBy))
+			if !state.SnoozedUntil.IsZero() {
+				description.WriteString(fmt.Sprintf(" until %s", state.SnoozedUntil.In(h.location).Format("2006-01-02 15:04")))
+			}
+		}
+		description.WriteString("\n\n")
+	}
+	description.WriteString("Acknowledge with `!isk ack <alert> [12h|3d|YYYY-MM-DD]`.")
+	return &discordgo.MessageEmbed{
+		Title:       firingAlertsMsg,
+		Description: description.String(),
+		Color:       0xff0000,
+	}
+}
+
+// ResolvedMessage returns notification that condition of firing alert no
+// longer holds.
+func (h *discordHandler) ResolvedMessage(ctx context.Context, state alertDomainAggregate.State) notify.Message {
+	message := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", alertResolvedMsg, state.Title),
+		Description: fmt.Sprintf("`%s` no longer fires, it started at %s.", state.Key, state.SentAt.In(h.location).Format("2006-01-02 15:04")),
+		Color:       0x00ff00,
+	}
+	h.setDataAsOf(message)
+	return notify.FromEmbed("", message)
+}
@@ -0,0 +1,137 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	buybackDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/buyback/aggregate"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// How many items and members to list in buyback messages.
+const buybackTopRows = 15
+
+// iskBuybackHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskBuybackHandler(r reply, args []string) {
+	r.Working()
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	buyback, err := h.accountantSvc.Buyback(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+	ids := make([]namesDomainEntity.ID, 0, len(buyback.ByItem)+len(buyback.ByMember))
+	for typeID := range buyback.ByItem {
+		ids = append(ids, namesDomainEntity.ID(typeID))
+	}
+	for memberID := range buyback.ByMember {
+		ids = append(ids, namesDomainEntity.ID(memberID))
+	}
+	names, err := h.accountantSvc.Names(h.ctx, ids)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error resolving names"))
+		return
+	}
+
+	messages := h.iskBuybackMessages(period, buyback, names)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
+		if err != nil {
+			r.Error(errors.Wrap(err, "error sending buyback message"))
+			return
+		}
+	}
+}
+
+func (h *discordHandler) iskBuybackMessages(
+	period balanceDomainEntity.Period,
+	buyback *buybackDomainAggregate.Buyback,
+	names map[namesDomainEntity.ID]namesDomainAggregate.Name,
+) []*discordgo.MessageEmbed {
+	name := func(id namesDomainEntity.ID) string {
+		name, ok := names[id]
+		if !ok {
+			return fmt.Sprintf("%d", id)
+		}
+		return string(name.Name)
+	}
+	title := titleWithDate(period)
+
+	items := make([]*buybackDomainAggregate.BuybackItem, 0, len(buyback.ByItem))
+	for _, item := range buyback.ByItem {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Paid > items[j].Paid
+	})
+	var byItem strings.Builder
+	byItem.WriteString("```")
+	for i, item := range items {
+		if i == buybackTopRows {
+			break
+		}
+		byItem.WriteString(fmt.Sprintf(
+			"%s\n  bought: %s paid: %s value: %s\n  sold: %s revenue: %s margin: %s\n",
+			name(namesDomainEntity.ID(item.TypeId)),
+			humanize.Comma(item.Quantity),
+			humanize.FormatFloat(floatFormat, float64(item.Paid)),
+			humanize.FormatFloat(floatFormat, float64(item.MarketValue)),
+			humanize.Comma(item.SoldQuantity),
+			humanize.FormatFloat(floatFormat, float64(item.ResaleRevenue)),
+			humanize.FormatFloat(floatFormat, float64(item.Margin())),
+		))
+	}
+	byItem.WriteString("```")
+
+	members := make([]*buybackDomainAggregate.BuybackMember, 0, len(buyback.ByMember))
+	for _, member := range buyback.ByMember {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Paid > members[j].Paid
+	})
+	var byMember strings.Builder
+	byMember.WriteString("```")
+	for i, member := range members {
+		if i == buybackTopRows {
+			break
+		}
+		byMember.WriteString(fmt.Sprintf(
+			"%s\n  items: %s paid: %s value: %s margin: %s\n",
+			name(namesDomainEntity.ID(member.MemberId)),
+			humanize.Comma(member.Quantity),
+			humanize.FormatFloat(floatFormat, float64(member.Paid)),
+			humanize.FormatFloat(floatFormat, float64(member.MarketValue)),
+			humanize.FormatFloat(floatFormat, float64(member.Margin)),
+		))
+	}
+	byMember.WriteString("```")
+
+	var messages = []*discordgo.MessageEmbed{
+		{
+			Title:       fmt.Sprintf("%s %s", buybackByItemMsg, title),
+			Description: byItem.String(),
+			Color:       0x00ff00,
+		},
+		{
+			Title:       fmt.Sprintf("%s %s", buybackByMemberMsg, title),
+			Description: byMember.String(),
+			Color:       0xffffff,
+		},
+	}
+
+	return messages
+}
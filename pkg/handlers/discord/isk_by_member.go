@@ -0,0 +1,124 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// How many contributors and recipients to list.
+const memberTopRows = 20
+
+// iskByMemberHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskByMemberHandler(r reply, args []string) {
+	r.Working()
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	balance, err := h.accountantSvc.BalanceByParty(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+	ids := make([]namesDomainEntity.ID, 0, len(balance.IncomeByParty)+len(balance.ExpensesByParty))
+	for partyID := range balance.IncomeByParty {
+		ids = append(ids, namesDomainEntity.ID(partyID))
+	}
+	for partyID := range balance.ExpensesByParty {
+		ids = append(ids, namesDomainEntity.ID(partyID))
+	}
+	names, err := h.accountantSvc.Names(h.ctx, ids)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error resolving names"))
+		return
+	}
+
+	messages := h.iskByMemberMessages(period, balance, names)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
+		if err != nil {
+			r.Error(errors.Wrap(err, "error sending balance message"))
+			return
+		}
+	}
+}
+
+type balanceByPartyRow struct {
+	Party  balanceDomainEntity.PartyID
+	Amount balanceDomainEntity.Amount
+}
+
+func (h *discordHandler) iskByMemberMessages(
+	period balanceDomainEntity.Period,
+	balance *balanceDomainAggrgate.BalanceByParty,
+	names map[namesDomainEntity.ID]namesDomainAggregate.Name,
+) []*discordgo.MessageEmbed {
+	table := func(amountByParty balanceDomainAggrgate.AmountByParty, less func(a, b balanceDomainEntity.Amount) bool) string {
+		rows := make([]balanceByPartyRow, 0, len(amountByParty))
+		for party, amount := range amountByParty {
+			rows = append(rows, balanceByPartyRow{
+				Party:  party,
+				Amount: amount,
+			})
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			return less(rows[i].Amount, rows[j].Amount)
+		})
+
+		var out strings.Builder
+		out.WriteString("```")
+		for i, row := range rows {
+			if i == memberTopRows {
+				break
+			}
+			out.WriteString(fmt.Sprintf("%s  %s\n", humanize.FormatFloat(floatFormat, float64(row.Amount)), partyName(names, row.Party)))
+		}
+		out.WriteString("```")
+		return out.String()
+	}
+
+	var messages = []*discordgo.MessageEmbed{
+		{
+			Title: fmt.Sprintf("%s %s", topContributorsMsg, titleWithDate(period)),
+			Description: table(balance.IncomeByParty, func(a, b balanceDomainEntity.Amount) bool {
+				return a > b
+			}),
+			Color: 0x00ff00,
+		},
+		{
+			Title: fmt.Sprintf("%s %s", topRecipientsMsg, titleWithDate(period)),
+			Description: table(balance.ExpensesByParty, func(a, b balanceDomainEntity.Amount) bool {
+				return a < b
+			}),
+			Color: 0xff0000,
+		},
+	}
+
+	return messages
+}
+
+// partyName returns resolved name of the party, or its ID when ESI does not
+// know it.
+func partyName(names map[namesDomainEntity.ID]namesDomainAggregate.Name, party balanceDomainEntity.PartyID) string {
+	if party == 0 {
+		return "Unknown"
+	}
+	name, ok := names[namesDomainEntity.ID(party)]
+	if !ok {
+		return fmt.Sprintf("%d", party)
+	}
+	return string(name.Name)
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"sort"
 	"strings"
-	"time"
 
 	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
 	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
@@ -16,28 +15,25 @@ import (
 
 // iskByTypeHandler will be called every time a new
 // message is created on any channel that the autenticated bot has access to.
-func (h *discordHandler) iskByTypeHandler(s *discordgo.Session, m *discordgo.MessageCreate, args []string) {
-	// React before starting the balance calculation (it takes quite few seconds to fetch everything).
-	err := h.discord.MessageReactionAdd(m.ChannelID, m.ID, `⏱️`)
+func (h *discordHandler) iskByTypeHandler(r reply, args []string) {
+	r.Working()
+	period, err := h.parsePeriod(args)
 	if err != nil {
-		h.error(errors.Wrap(err, "error reacting with :stopwatch: emoji"), m.ChannelID)
-	}
-	dateStart, dateEnd, err := h.parseDateStartDateEnd(args)
-	if err != nil {
-		h.error(err, m.ChannelID)
+		r.Error(err)
 		return
 	}
 
-	balance, err := h.accountantSvc.BalanceByType(h.ctx, dateStart, dateEnd)
-	if err != nil {
-		h.error(errors.Wrap(err, "error calculating balance"), m.ChannelID)
+	balance, err := h.accountantSvc.BalanceByType(h.ctx, period)
+	if h.balanceError(err, r) {
 		return
 	}
 
-	for _, messages := range h.iskByTypeMessages(dateStart, dateEnd, balance) {
-		_, err = h.discord.ChannelMessageSendEmbed(m.ChannelID, messages)
+	messages := h.iskByTypeMessages(period, balance)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
 		if err != nil {
-			h.error(errors.Wrap(err, "error sending balance message"), m.ChannelID)
+			r.Error(errors.Wrap(err, "error sending balance message"))
 			return
 		}
 	}
@@ -48,7 +44,7 @@ type balanceByTypeRow struct {
 	Amount balanceDomainEntity.Amount
 }
 
-func (h *discordHandler) iskByTypeMessages(dateStart, dateEnd time.Time, balance *balanceDomainAggrgate.BalanceByType) []*discordgo.MessageEmbed {
+func (h *discordHandler) iskByTypeMessages(period balanceDomainEntity.Period, balance *balanceDomainAggrgate.BalanceByType) []*discordgo.MessageEmbed {
 	var descriptionRowData = make([]balanceByTypeRow, 0, len(balance.IncomeByType)+len(balance.ExpensesByType))
 
 	for refType, amount := range balance.IncomeByType {
@@ -91,12 +87,12 @@ func (h *discordHandler) iskByTypeMessages(dateStart, dateEnd time.Time, balance
 
 	var messages = []*discordgo.MessageEmbed{
 		{
-			Title:       fmt.Sprintf("%s %s", incomeMsg, titleWithDate(dateStart, dateEnd)),
+			Title:       fmt.Sprintf("%s %s", incomeMsg, titleWithDate(period)),
 			Description: income.String(),
 			Color:       0x00ff00,
 		},
 		{
-			Title:       fmt.Sprintf("%s %s", expensesMsg, titleWithDate(dateStart, dateEnd)),
+			Title:       fmt.Sprintf("%s %s", expensesMsg, titleWithDate(period)),
 			Description: expenses.String(),
 			Color:       0xff0000,
 		},
@@ -0,0 +1,111 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// How many ref types with the biggest change to list.
+const compareTopMovers = 5
+
+// iskCompareHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskCompareHandler(r reply, args []string) {
+	r.Working()
+
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	comparison, err := h.accountantSvc.Compare(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+
+	messages := h.iskCompareMessages(comparison)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
+		if err != nil {
+			r.Error(errors.Wrap(err, "error sending compare message"))
+			return
+		}
+	}
+}
+
+func (h *discordHandler) iskCompareMessages(comparison *balanceDomainAggrgate.Comparison) []*discordgo.MessageEmbed {
+	var messages []*discordgo.MessageEmbed
+	for _, base := range []struct {
+		title   string
+		summary *balanceDomainAggrgate.BalanceSummary
+	}{
+		{compareToPreviousMsg, comparison.Previous},
+		{compareToYearAgoMsg, comparison.YearAgo},
+	} {
+		var description strings.Builder
+		description.WriteString(fmt.Sprintf("%s vs %s\n", comparison.Current.Period, base.summary.Period))
+
+		description.WriteString("```")
+		total := balanceDomainAggrgate.CompareTotal(comparison.Current, base.summary)
+		writeDeltas(&description, total)
+		description.WriteString("```")
+
+		description.WriteString(fmt.Sprintf("**%s**\n```", compareByDivisionMsg))
+		writeDeltas(&description, balanceDomainAggrgate.CompareByDivision(comparison.Current, base.summary))
+		description.WriteString("```")
+
+		byType := balanceDomainAggrgate.CompareByType(comparison.Current, base.summary)
+		if len(byType) > compareTopMovers {
+			byType = byType[:compareTopMovers]
+		}
+		description.WriteString(fmt.Sprintf("**%s**\n```", compareTopMoversMsg))
+		writeDeltas(&description, byType)
+		description.WriteString("```")
+
+		color := 0x00ff00
+		if total[len(total)-1].Change() < 0 {
+			color = 0xff0000
+		}
+		messages = append(messages, &discordgo.MessageEmbed{
+			Title:       fmt.Sprintf("%s %s", base.title, titleWithDate(comparison.Current.Period)),
+			Description: description.String(),
+			Color:       color,
+		})
+	}
+	return messages
+}
+
+// writeDeltas writes table of current amounts and their change, arrows mark
+// direction of the change.
+func writeDeltas(b *strings.Builder, deltas []balanceDomainAggrgate.Delta) {
+	if len(deltas) == 0 {
+		b.WriteString("No transactions\n")
+		return
+	}
+	for _, delta := range deltas {
+		arrow := "▲"
+		if delta.Change() < 0 {
+			arrow = "▼"
+		}
+		percent := "new"
+		if p, ok := delta.Percent(); ok {
+			percent = fmt.Sprintf("%+.1f %%", p)
+		}
+		b.WriteString(fmt.Sprintf(
+			"%s  %s %s (%s)  %s\n",
+			humanize.FormatFloat(floatFormat, float64(delta.Current)),
+			arrow,
+			humanize.FormatFloat(floatFormat, float64(delta.Change())),
+			percent,
+			delta.Name,
+		))
+	}
+}
@@ -0,0 +1,106 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/lunemec/eve-accountant/pkg/export"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// exportSizeLimit is the largest file Discord accepts in servers without
+// boosts.
+const exportSizeLimit = 10 * 1000 * 1000
+
+// iskExportHandler uploads journal or report as a file, report comes first,
+// then optional format and period like `!isk export by division xlsx last month`.
+func (h *discordHandler) iskExportHandler(r reply, args []string) {
+	args = strings.Fields(strings.Join(args, " "))
+	if len(args) == 0 {
+		r.Error(errors.Errorf("which report to export? Use `!isk export <report> [csv|jsonl|xlsx] [period]`, reports: %s", reportNames()))
+		return
+	}
+	r.Working()
+
+	report, args, err := parseExportReport(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	format := export.CSV
+	if len(args) > 0 {
+		if parsed, err := export.ParseFormat(args[0]); err == nil {
+			format = parsed
+			args = args[1:]
+		}
+	}
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	table, err := h.accountantSvc.Export(h.ctx, report, period)
+	if h.balanceError(err, r) {
+		return
+	}
+	var buf bytes.Buffer
+	err = export.Write(&buf, format, table)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error writing export"))
+		return
+	}
+	if buf.Len() > exportSizeLimit {
+		r.Error(errors.Errorf(exportTooLargeMsg, humanize.Bytes(uint64(buf.Len())), humanize.Bytes(exportSizeLimit)))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s %s", exportMsg, table.Name, titleWithDate(period)),
+		Color:       0xffffff,
+		Description: fmt.Sprintf("%d rows", len(table.Rows)),
+	}
+	h.setDataAsOf(embed)
+	err = r.SendComplex(&discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+		Files: []*discordgo.File{
+			{
+				Name:        export.FileName(report, format, period.Start, period.LastDay()),
+				ContentType: format.ContentType(),
+				Reader:      &buf,
+			},
+		},
+	})
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending export message"))
+		return
+	}
+}
+
+// parseExportReport returns report named by the first one or two arguments,
+// so both `by_division` and `by division` work, and the remaining arguments.
+func parseExportReport(args []string) (export.Report, []string, error) {
+	if len(args) > 1 {
+		if report, err := export.ParseReport(args[0] + " " + args[1]); err == nil {
+			return report, args[2:], nil
+		}
+	}
+	report, err := export.ParseReport(args[0])
+	if err != nil {
+		return "", nil, err
+	}
+	return report, args[1:], nil
+}
+
+// reportNames lists reports which can be exported for help messages.
+func reportNames() string {
+	names := make([]string, 0, len(export.Reports))
+	for _, report := range export.Reports {
+		names = append(names, "`"+string(report)+"`")
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,85 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// iskForecastHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskForecastHandler(r reply, args []string) {
+	r.Working()
+
+	forecast, err := h.accountantSvc.Forecast(h.ctx)
+	if h.balanceError(err, r) {
+		return
+	}
+
+	message := h.iskForecastMessage(forecastMsg, forecast)
+	h.setDataAsOf(message)
+	err = r.SendEmbed(message)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending forecast message"))
+		return
+	}
+}
+
+// ForecastBelowThresholdMessage returns warning that balance of this month is
+// projected to end below the threshold.
+func (h *discordHandler) ForecastBelowThresholdMessage(ctx context.Context, forecast *balanceDomainAggrgate.Forecast) notify.Message {
+	message := h.iskForecastMessage(forecastNotificationMsg, forecast)
+	h.setDataAsOf(message)
+	return notify.FromEmbed(notify.ForecastKind, message)
+}
+
+func (h *discordHandler) iskForecastMessage(title string, forecast *balanceDomainAggrgate.Forecast) *discordgo.MessageEmbed {
+	format := func(amount balanceDomainEntity.Amount) string {
+		return humanize.FormatFloat(floatFormat, float64(amount))
+	}
+
+	var description strings.Builder
+	description.WriteString("```")
+	description.WriteString(fmt.Sprintf("%s  Month to date\n", format(forecast.Actual)))
+	description.WriteString(fmt.Sprintf("%s  Scheduled fees\n", format(forecast.Scheduled)))
+	refTypes := make([]balanceDomainEntity.RefType, 0, len(forecast.ScheduledByType))
+	for refType := range forecast.ScheduledByType {
+		refTypes = append(refTypes, refType)
+	}
+	sort.Slice(refTypes, func(i, j int) bool {
+		return refTypes[i] < refTypes[j]
+	})
+	for _, refType := range refTypes {
+		description.WriteString(fmt.Sprintf("  %s  %s\n", format(forecast.ScheduledByType[refType]), refType))
+	}
+	description.WriteString(fmt.Sprintf("%s  Expected from daily average of %d days\n", format(forecast.Expected), forecast.HistoryDays))
+	description.WriteString(fmt.Sprintf("\n%s  Projected month end\n", format(forecast.Projected())))
+	description.WriteString(fmt.Sprintf("%s - %s  %.0f %% range\n", format(forecast.Low), format(forecast.High), forecast.Confidence*100))
+	description.WriteString(fmt.Sprintf("%s  Threshold\n", format(forecast.Threshold)))
+	description.WriteString("```")
+
+	color := 0x00ff00
+	switch {
+	case forecast.BelowThreshold():
+		color = 0xff0000
+		description.WriteString(forecastBelowThresholdMsg)
+	case forecast.Low < forecast.Threshold:
+		color = 0xffa500
+		description.WriteString(forecastMayFallBelowMsg)
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", title, titleWithDate(forecast.Period)),
+		Description: description.String(),
+		Color:       color,
+	}
+}
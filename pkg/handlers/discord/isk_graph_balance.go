@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lunemec/eve-accountant/pkg/chart"
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/pkg/errors"
+)
+
+// iskGraphBalanceHandler plots wallet balance at the end of each day.
+func (h *discordHandler) iskGraphBalanceHandler(r reply, args []string) {
+	r.Working()
+
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	days, err := h.accountantSvc.WalletBalanceByDay(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+
+	wallets := make(map[balanceDomainAggrgate.Wallet]struct{})
+	corporations := make(map[balanceDomainEntity.CorporationID]struct{})
+	for _, day := range days {
+		for wallet := range day.ByWallet {
+			wallets[wallet] = struct{}{}
+			corporations[wallet.CorporationID] = struct{}{}
+		}
+	}
+
+	// Wallets are labeled with corporation name only when there are more of them.
+	var names map[namesDomainEntity.ID]namesDomainAggregate.Name
+	if len(corporations) > 1 {
+		ids := make([]namesDomainEntity.ID, 0, len(corporations))
+		for corporationID := range corporations {
+			ids = append(ids, namesDomainEntity.ID(corporationID))
+		}
+		names, err = h.accountantSvc.Names(h.ctx, ids)
+		if err != nil {
+			r.Error(errors.Wrap(err, "error resolving names"))
+			return
+		}
+	}
+
+	sortedWallets := make([]balanceDomainAggrgate.Wallet, 0, len(wallets))
+	for wallet := range wallets {
+		sortedWallets = append(sortedWallets, wallet)
+	}
+	sort.Slice(sortedWallets, func(i, j int) bool {
+		if sortedWallets[i].CorporationID != sortedWallets[j].CorporationID {
+			return sortedWallets[i].CorporationID < sortedWallets[j].CorporationID
+		}
+		return sortedWallets[i].Division < sortedWallets[j].Division
+	})
+
+	lineChart := chart.LineChart{}
+	for _, day := range days {
+		lineChart.Labels = append(lineChart.Labels, day.Timestamp.Format("2006-01-02"))
+	}
+	for _, wallet := range sortedWallets {
+		series := chart.Series{Name: string(wallet.Division)}
+		if names != nil {
+			series.Name = fmt.Sprintf("%s %s", partyName(names, balanceDomainEntity.PartyID(wallet.CorporationID)), wallet.Division)
+		}
+		for _, day := range days {
+			series.Values = append(series.Values, float64(day.ByWallet[wallet]))
+		}
+		lineChart.Series = append(lineChart.Series, series)
+	}
+	total := chart.Series{Name: walletTotalMsg}
+	for _, day := range days {
+		total.Values = append(total.Values, float64(day.Total()))
+	}
+	lineChart.Series = append(lineChart.Series, total)
+
+	image, err := h.chartRenderer.Line(lineChart)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error rendering chart"))
+		return
+	}
+
+	message := h.chartMessage(fmt.Sprintf("%s %s", walletBalanceMsg, titleWithDate(period)), image)
+	h.setDataAsOf(message.Embeds...)
+	err = r.SendComplex(message)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending balance message"))
+		return
+	}
+}
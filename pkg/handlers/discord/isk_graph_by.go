@@ -0,0 +1,133 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lunemec/eve-accountant/pkg/chart"
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Longest periods shown in daily and weekly buckets when no bucket is set.
+	maxDailyBucketDays  = 31
+	maxWeeklyBucketDays = 183
+)
+
+// iskGraphByHandler plots stacked income and expenses grouped by type or
+// division, income is above the axis and expenses below it.
+func (h *discordHandler) iskGraphByHandler(r reply, grouping string, args []string) {
+	if grouping != "type" && grouping != "division" {
+		r.Error(errors.Errorf("unknown grouping: %s, use `!isk graph by type` or `!isk graph by division`", grouping))
+		return
+	}
+	r.Working()
+
+	var bucket balanceDomainAggrgate.Bucket
+	if len(args) > 0 {
+		switch balanceDomainAggrgate.Bucket(args[0]) {
+		case balanceDomainAggrgate.BucketDay, balanceDomainAggrgate.BucketWeek, balanceDomainAggrgate.BucketMonth:
+			bucket = balanceDomainAggrgate.Bucket(args[0])
+			args = args[1:]
+		}
+	}
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	if bucket == "" {
+		bucket = defaultBucket(period)
+	}
+
+	rawBalance, err := h.accountantSvc.BalanceByDayByDivisionByType(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+	buckets := balanceDomainAggrgate.BucketBalance(rawBalance, bucket)
+
+	// key returns the grouped dimension of the amount.
+	key := func(divisionName balanceDomainEntity.DivisionName, refType balanceDomainEntity.RefType) string {
+		if grouping == "division" {
+			return string(divisionName)
+		}
+		return string(refType)
+	}
+	var (
+		income   = make(map[string][]float64)
+		expenses = make(map[string][]float64)
+		labels   []string
+	)
+	add := func(series map[string][]float64, amounts balanceDomainAggrgate.AmountByDivisionByType, i int) {
+		for divisionName, byType := range amounts {
+			for refType, amount := range byType {
+				k := key(divisionName, refType)
+				if _, ok := series[k]; !ok {
+					series[k] = make([]float64, len(buckets))
+				}
+				series[k][i] += float64(amount)
+			}
+		}
+	}
+	for i, b := range buckets {
+		add(income, b.Income, i)
+		add(expenses, b.Expenses, i)
+		labels = append(labels, bucket.Label(b.Timestamp))
+	}
+
+	barChart := chart.StackedBarChart{Labels: labels}
+	for _, name := range sortedKeys(income) {
+		seriesName := name
+		if _, ok := expenses[name]; ok {
+			seriesName = name + " (income)"
+		}
+		barChart.Series = append(barChart.Series, chart.Series{Name: seriesName, Values: income[name]})
+	}
+	for _, name := range sortedKeys(expenses) {
+		seriesName := name
+		if _, ok := income[name]; ok {
+			seriesName = name + " (expenses)"
+		}
+		barChart.Series = append(barChart.Series, chart.Series{Name: seriesName, Values: expenses[name]})
+	}
+
+	image, err := h.chartRenderer.StackedBar(barChart)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error rendering chart"))
+		return
+	}
+
+	title := fmt.Sprintf("%s %s %s, %s", movementByMsg, grouping, titleWithDate(period), bucket)
+	message := h.chartMessage(title, image)
+	h.setDataAsOf(message.Embeds...)
+	err = r.SendComplex(message)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending balance message"))
+		return
+	}
+}
+
+// defaultBucket keeps number of bars readable for long periods.
+func defaultBucket(period balanceDomainEntity.Period) balanceDomainAggrgate.Bucket {
+	days := len(period.Days())
+	switch {
+	case days <= maxDailyBucketDays:
+		return balanceDomainAggrgate.BucketDay
+	case days <= maxWeeklyBucketDays:
+		return balanceDomainAggrgate.BucketWeek
+	default:
+		return balanceDomainAggrgate.BucketMonth
+	}
+}
+
+func sortedKeys(m map[string][]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
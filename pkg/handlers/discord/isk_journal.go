@@ -0,0 +1,382 @@
+package discord
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// How many journal records to show on one page.
+	journalPageSize = 10
+	// How long pages of a search can be browsed with buttons.
+	journalSearchTTL = time.Hour
+	// How many records of one search are kept to browse, the newest ones.
+	journalSearchMaxRecords = 1000
+	// How many searches are kept, the oldest one is removed for a new one.
+	journalSearchesLimit = 50
+	// Custom ID prefix of page buttons, followed by search ID and page.
+	journalButtonPrefix = "journal_page:"
+	// Longer descriptions of records are cut.
+	journalDescriptionLength = 120
+)
+
+// Filters of `!isk journal`, typed as `key:value`.
+const (
+	journalDivisionFilter    = "division"
+	journalTypeFilter        = "type"
+	journalPartyFilter       = "party"
+	journalMinFilter         = "min"
+	journalMaxFilter         = "max"
+	journalDescriptionFilter = "text"
+)
+
+var journalFilters = []string{
+	journalDivisionFilter,
+	journalTypeFilter,
+	journalPartyFilter,
+	journalMinFilter,
+	journalMaxFilter,
+	journalDescriptionFilter,
+}
+
+// journalSearch holds results of one search, so its pages can be browsed.
+type journalSearch struct {
+	title string
+	// records are the newest journalSearchMaxRecords matching records.
+	records []balanceDomainAggrgate.WalletRecord
+	// truncated is true when more records match than are kept.
+	truncated bool
+	// total of the kept records.
+	total     balanceDomainEntity.Amount
+	names     map[namesDomainEntity.ID]namesDomainAggregate.Name
+	createdAt time.Time
+}
+
+// newJournalSearch keeps the newest journalSearchMaxRecords of records, which
+// are searched with limit one over it to tell whether more records match.
+func newJournalSearch(title string, records []balanceDomainAggrgate.WalletRecord, names map[namesDomainEntity.ID]namesDomainAggregate.Name, createdAt time.Time) *journalSearch {
+	search := &journalSearch{
+		title:     title,
+		names:     names,
+		createdAt: createdAt,
+	}
+	if len(records) > journalSearchMaxRecords {
+		records = records[:journalSearchMaxRecords:journalSearchMaxRecords]
+		search.truncated = true
+	}
+	for _, record := range records {
+		search.total += record.Record.Amount
+	}
+	search.records = records
+	return search
+}
+
+// journalSearches are recent searches by ID, kept only in memory.
+type journalSearches struct {
+	mu       sync.Mutex
+	lastID   int
+	searches map[string]*journalSearch
+}
+
+func newJournalSearches() *journalSearches {
+	return &journalSearches{
+		searches: make(map[string]*journalSearch),
+	}
+}
+
+// add stores the search and returns its ID, expired searches are removed and
+// the oldest ones over journalSearchesLimit too.
+func (s *journalSearches) add(search *journalSearch) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	for len(s.searches) >= journalSearchesLimit {
+		var oldest string
+		for id, stored := range s.searches {
+			if oldest == "" || stored.createdAt.Before(s.searches[oldest].createdAt) {
+				oldest = id
+			}
+		}
+		delete(s.searches, oldest)
+	}
+	s.lastID++
+	id := strconv.Itoa(s.lastID)
+	s.searches[id] = search
+	return id
+}
+
+func (s *journalSearches) get(id string) (*journalSearch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune()
+	search, ok := s.searches[id]
+	return search, ok
+}
+
+// prune removes expired searches, must be called with mu held.
+func (s *journalSearches) prune() {
+	for id, stored := range s.searches {
+		if time.Since(stored.createdAt) > journalSearchTTL {
+			delete(s.searches, id)
+		}
+	}
+}
+
+// iskJournalHandler lists journal records matching filters, period comes
+// before filters like `!isk journal last month type:player_donation min:1b`.
+func (h *discordHandler) iskJournalHandler(r reply, args []string) {
+	r.Working()
+
+	periodArgs, filters, err := parseJournalArgs(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	period, err := h.parsePeriod(periodArgs)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	query := balanceDomainAggrgate.JournalQuery{
+		Period:      period,
+		Division:    balanceDomainEntity.DivisionName(filters[journalDivisionFilter]),
+		Description: filters[journalDescriptionFilter],
+		Party:       filters[journalPartyFilter],
+		Limit:       journalSearchMaxRecords + 1,
+	}
+	if refType := filters[journalTypeFilter]; refType != "" {
+		query.RefTypes = []balanceDomainEntity.RefType{balanceDomainEntity.RefType(refType)}
+	}
+	for filter, amount := range map[string]*balanceDomainEntity.Amount{
+		journalMinFilter: &query.MinAmount,
+		journalMaxFilter: &query.MaxAmount,
+	} {
+		if filters[filter] == "" {
+			continue
+		}
+		*amount, err = parseAmount(filters[filter])
+		if err != nil {
+			r.Error(errors.Wrapf(err, "invalid %s amount", filter))
+			return
+		}
+	}
+
+	records, names, err := h.accountantSvc.SearchJournal(h.ctx, query)
+	if h.balanceError(err, r) {
+		return
+	}
+
+	search := newJournalSearch(fmt.Sprintf("%s %s", journalMsg, titleWithDate(period)), records, names, time.Now())
+	id := h.journalSearches.add(search)
+	embed, components := h.journalPage(id, search, 0)
+	err = r.SendComplex(&discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending journal message"))
+		return
+	}
+}
+
+// journalButtonHandler shows another page of the search in place of the
+// message whose button was clicked.
+func (h *discordHandler) journalButtonHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var (
+		response *discordgo.InteractionResponse
+		parts    = strings.Split(strings.TrimPrefix(i.MessageComponentData().CustomID, journalButtonPrefix), ":")
+	)
+	search, ok := h.journalSearches.get(parts[0])
+	page, err := strconv.Atoi(parts[len(parts)-1])
+	if !ok || len(parts) != 2 || err != nil {
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: journalSearchExpiredMsg,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		}
+	} else {
+		embed, components := h.journalPage(parts[0], search, page)
+		response = &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: components,
+			},
+		}
+	}
+	err = s.InteractionRespond(i.Interaction, response)
+	if err != nil {
+		h.log.Error("error responding to journal button", zap.Error(err))
+	}
+}
+
+// journalPage returns embed with one page of records and buttons to move
+// between pages.
+func (h *discordHandler) journalPage(id string, search *journalSearch, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	format := func(amount balanceDomainEntity.Amount) string {
+		return humanize.FormatFloat(floatFormat, float64(amount))
+	}
+	pages := (len(search.records) + journalPageSize - 1) / journalPageSize
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	var description strings.Builder
+	if len(search.records) == 0 {
+		description.WriteString(noJournalRecordsMsg)
+	} else {
+		description.WriteString(fmt.Sprintf("%d records, total `%s`, page %d/%d\n", len(search.records), format(search.total), page+1, pages))
+	}
+	if search.truncated {
+		description.WriteString(fmt.Sprintf(journalSearchTruncatedMsg+"\n", len(search.records)))
+	}
+	end := (page + 1) * journalPageSize
+	if end > len(search.records) {
+		end = len(search.records)
+	}
+	for _, record := range search.records[page*journalPageSize : end] {
+		description.WriteString(fmt.Sprintf(
+			"\n`%s` `%s` %s\n%s %s: %s → %s\n",
+			record.Record.Date.In(h.location).Format("2006-01-02 15:04"),
+			format(record.Record.Amount),
+			record.Record.RefType,
+			partyName(search.names, balanceDomainEntity.PartyID(record.Wallet.CorporationID)),
+			record.Wallet.Division,
+			partyName(search.names, balanceDomainEntity.PartyID(record.Record.FirstPartyId)),
+			partyName(search.names, balanceDomainEntity.PartyID(record.Record.SecondPartyId)),
+		))
+		if transaction := record.Transaction; transaction != nil {
+			action := "Sold"
+			if transaction.IsBuy {
+				action = "Bought"
+			}
+			description.WriteString(fmt.Sprintf(
+				"%s %s × %s at `%s`\n",
+				action,
+				humanize.Comma(int64(transaction.Quantity)),
+				typeName(search.names, transaction.TypeId),
+				humanize.FormatFloat(floatFormat, float64(transaction.UnitPrice)),
+			))
+		}
+		text := string(record.Record.Description)
+		if record.Record.Reason != "" {
+			text = fmt.Sprintf("%s (%s)", text, record.Record.Reason)
+		}
+		if len([]rune(text)) > journalDescriptionLength {
+			text = string([]rune(text)[:journalDescriptionLength]) + "…"
+		}
+		if text != "" {
+			description.WriteString(fmt.Sprintf("> %s\n", text))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       search.title,
+		Description: description.String(),
+		Color:       0xffffff,
+	}
+	h.setDataAsOf(embed)
+	if pages <= 1 {
+		return embed, nil
+	}
+	return embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("%s%s:%d", journalButtonPrefix, id, page-1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "Next",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("%s%s:%d", journalButtonPrefix, id, page+1),
+					Disabled: page == pages-1,
+				},
+			},
+		},
+	}
+}
+
+// parseJournalArgs returns arguments before the first filter as period and
+// filters, words after filter without key belong to its value.
+func parseJournalArgs(args []string) ([]string, map[string]string, error) {
+	var (
+		periodArgs []string
+		filters    = make(map[string]string)
+		current    string
+	)
+	for _, arg := range strings.Fields(strings.Join(args, " ")) {
+		key := journalFilterKey(arg)
+		switch {
+		case key != "":
+			if _, ok := filters[key]; ok {
+				return nil, nil, errors.Errorf("filter %s is used twice", key)
+			}
+			current = key
+			filters[key] = arg[len(key)+1:]
+		case current != "":
+			filters[current] = strings.TrimSpace(filters[current] + " " + arg)
+		default:
+			periodArgs = append(periodArgs, arg)
+		}
+	}
+	for key, value := range filters {
+		if value == "" {
+			return nil, nil, errors.Errorf("filter %s has no value", key)
+		}
+	}
+	return periodArgs, filters, nil
+}
+
+func journalFilterKey(arg string) string {
+	for _, filter := range journalFilters {
+		if strings.HasPrefix(strings.ToLower(arg), filter+":") {
+			return filter
+		}
+	}
+	return ""
+}
+
+// parseAmount parses ISK amount with optional k, m or b suffix like 1.5b.
+func parseAmount(input string) (balanceDomainEntity.Amount, error) {
+	s := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(input, " ", ""), ",", ""))
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1e3
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1e6
+	case strings.HasSuffix(s, "b"):
+		multiplier = 1e9
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || amount < 0 {
+		return 0, errors.Errorf("unable to parse amount: %s, use ISK like 500000, 250m or 1.5b", input)
+	}
+	return balanceDomainEntity.Amount(amount * multiplier), nil
+}
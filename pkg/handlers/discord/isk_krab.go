@@ -0,0 +1,218 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	balanceDomain "github.com/lunemec/eve-accountant/pkg/domain/balance"
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+const (
+	// How many pilots to list in the leaderboard.
+	krabTopRows = 25
+	// How many months of member history to show.
+	krabHistoryMonths = 12
+)
+
+// iskKrabHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskKrabHandler(r reply, args []string) {
+	r.Working()
+	if len(args) > 0 && args[0] == "member" {
+		h.iskKrabMemberHandler(r, strings.Join(args[1:], " "))
+		return
+	}
+
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+	ledger, err := h.accountantSvc.TaxLedger(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+	// Previous month is loaded separately to compare with.
+	previous := balanceDomainEntity.MonthPeriod(balanceDomainAggrgate.Month(period.Start).AddDate(0, -1, 0))
+	previousLedger, previousErr := h.accountantSvc.TaxLedger(h.ctx, previous)
+	if err != nil && balanceDomain.IsPartial(previousErr) {
+		// Missing corporations were reported already.
+		previousErr = nil
+	}
+	if h.balanceError(previousErr, r) {
+		return
+	}
+	names, err := h.krabNames(ledger, previousLedger)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error resolving names"))
+		return
+	}
+
+	messages := h.iskKrabMessages(period, previous, ledger, previousLedger, names)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
+		if err != nil {
+			r.Error(errors.Wrap(err, "error sending krab message"))
+			return
+		}
+	}
+}
+
+// iskKrabMemberHandler shows monthly tax history of one pilot.
+func (h *discordHandler) iskKrabMemberHandler(r reply, pilotName string) {
+	if pilotName == "" {
+		r.Error(errors.New("missing pilot name, use `!isk krab member <name>`"))
+		return
+	}
+	now := time.Now().In(h.location)
+	dateStart := balanceDomainAggrgate.Month(now).AddDate(0, -krabHistoryMonths+1, 0)
+	ledger, err := h.accountantSvc.TaxLedger(h.ctx, balanceDomainEntity.NewPeriod(dateStart, now))
+	if h.balanceError(err, r) {
+		return
+	}
+	names, err := h.krabNames(ledger)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error resolving names"))
+		return
+	}
+
+	var (
+		pilot balanceDomainEntity.PartyID
+		found bool
+	)
+	for _, name := range names {
+		if strings.EqualFold(string(name.Name), pilotName) {
+			pilot = balanceDomainEntity.PartyID(name.ID)
+			found = true
+			break
+		}
+	}
+	if !found {
+		r.Error(fmt.Errorf("pilot %s paid no tax in the last %d months", pilotName, krabHistoryMonths))
+		return
+	}
+
+	var (
+		total       balanceDomainEntity.Amount
+		description strings.Builder
+	)
+	description.WriteString("```")
+	for _, month := range ledger.History(pilot) {
+		total += month.Amount
+		description.WriteString(fmt.Sprintf("%s  %s\n", month.Month.Format("2006-01"), humanize.FormatFloat(floatFormat, float64(month.Amount))))
+	}
+	description.WriteString(fmt.Sprintf("\nTotal    %s```", humanize.FormatFloat(floatFormat, float64(total))))
+
+	message := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s %s", krabHistoryMsg, partyName(names, pilot)),
+		Description: description.String(),
+		Color:       0x00ff00,
+	}
+	h.setDataAsOf(message)
+	err = r.SendEmbed(message)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending krab message"))
+	}
+}
+
+func (h *discordHandler) krabNames(ledgers ...*balanceDomainAggrgate.TaxLedger) (map[namesDomainEntity.ID]namesDomainAggregate.Name, error) {
+	var ids []namesDomainEntity.ID
+	for _, ledger := range ledgers {
+		for _, pilot := range ledger.Pilots() {
+			ids = append(ids, namesDomainEntity.ID(pilot))
+		}
+	}
+	return h.accountantSvc.Names(h.ctx, ids)
+}
+
+type krabRow struct {
+	Pilot    balanceDomainEntity.PartyID
+	Amount   balanceDomainEntity.Amount
+	Previous balanceDomainEntity.Amount
+}
+
+func (h *discordHandler) iskKrabMessages(
+	period, previousPeriod balanceDomainEntity.Period,
+	ledger, previousLedger *balanceDomainAggrgate.TaxLedger,
+	names map[namesDomainEntity.ID]namesDomainAggregate.Name,
+) []*discordgo.MessageEmbed {
+	var (
+		current  = ledger.Total()
+		previous = previousLedger.Total()
+		rows     []krabRow
+		stopped  []krabRow
+	)
+	for pilot, amount := range current {
+		rows = append(rows, krabRow{
+			Pilot:    pilot,
+			Amount:   amount,
+			Previous: previous[pilot],
+		})
+	}
+	for pilot, amount := range previous {
+		if _, ok := current[pilot]; !ok {
+			stopped = append(stopped, krabRow{
+				Pilot:    pilot,
+				Previous: amount,
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Amount > rows[j].Amount
+	})
+	sort.Slice(stopped, func(i, j int) bool {
+		return stopped[i].Previous > stopped[j].Previous
+	})
+
+	var leaderboard strings.Builder
+	leaderboard.WriteString("```")
+	for i, row := range rows {
+		if i == krabTopRows {
+			break
+		}
+		leaderboard.WriteString(fmt.Sprintf(
+			"%2d. %s  (prev. month %s)  %s\n",
+			i+1,
+			humanize.FormatFloat(floatFormat, float64(row.Amount)),
+			humanize.FormatFloat(floatFormat, float64(row.Previous)),
+			partyName(names, row.Pilot),
+		))
+	}
+	leaderboard.WriteString("```")
+
+	var stoppedDescription strings.Builder
+	stoppedDescription.WriteString("```")
+	for i, row := range stopped {
+		if i == krabTopRows {
+			break
+		}
+		stoppedDescription.WriteString(fmt.Sprintf("%s  %s\n", humanize.FormatFloat(floatFormat, float64(row.Previous)), partyName(names, row.Pilot)))
+	}
+	stoppedDescription.WriteString("```")
+
+	var messages = []*discordgo.MessageEmbed{
+		{
+			Title:       fmt.Sprintf("%s %s", krabLeaderboardMsg, titleWithDate(period)),
+			Description: leaderboard.String(),
+			Color:       0x00ff00,
+		},
+		{
+			Title:       fmt.Sprintf("%s %s", krabStoppedMsg, titleWithDate(previousPeriod)),
+			Description: stoppedDescription.String(),
+			Color:       0xff0000,
+		},
+	}
+
+	return messages
+}
@@ -0,0 +1,145 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesDomainAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// How many items to list in each market message.
+const marketTopItems = 15
+
+// iskMarketHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskMarketHandler(r reply, args []string) {
+	r.Working()
+	period, err := h.parsePeriod(args)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	market, err := h.accountantSvc.MarketByItem(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+	items := market.Items()
+	typeIDs := make([]namesDomainEntity.ID, 0, len(items))
+	for _, item := range items {
+		typeIDs = append(typeIDs, namesDomainEntity.ID(item.TypeId))
+	}
+	names, err := h.accountantSvc.Names(h.ctx, typeIDs)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error resolving item names"))
+		return
+	}
+
+	messages := h.iskMarketMessages(period, items, names)
+	h.setDataAsOf(messages...)
+	for _, message := range messages {
+		err = r.SendEmbed(message)
+		if err != nil {
+			r.Error(errors.Wrap(err, "error sending market message"))
+			return
+		}
+	}
+}
+
+func (h *discordHandler) iskMarketMessages(
+	period balanceDomainEntity.Period,
+	items []*balanceDomainAggrgate.ItemMarket,
+	names map[namesDomainEntity.ID]namesDomainAggregate.Name,
+) []*discordgo.MessageEmbed {
+	itemName := func(item *balanceDomainAggrgate.ItemMarket) string {
+		return typeName(names, item.TypeId)
+	}
+	title := titleWithDate(period)
+
+	var byRevenue strings.Builder
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Revenue > items[j].Revenue
+	})
+	byRevenue.WriteString("```")
+	for i, item := range items {
+		if i == marketTopItems || item.Revenue <= 0 {
+			break
+		}
+		byRevenue.WriteString(fmt.Sprintf("%s  %s\n", humanize.FormatFloat(floatFormat, float64(item.Revenue)), itemName(item)))
+	}
+	byRevenue.WriteString("```")
+
+	var byVolume strings.Builder
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Volume() > items[j].Volume()
+	})
+	byVolume.WriteString("```")
+	for i, item := range items {
+		if i == marketTopItems {
+			break
+		}
+		byVolume.WriteString(fmt.Sprintf("%s bought  %s sold  %s\n", humanize.Comma(item.BoughtQuantity), humanize.Comma(item.SoldQuantity), itemName(item)))
+	}
+	byVolume.WriteString("```")
+
+	var (
+		byMargin    strings.Builder
+		marginItems = make([]*balanceDomainAggrgate.ItemMarket, 0, len(items))
+	)
+	for _, item := range items {
+		if _, ok := item.RealisedMargin(); ok && item.SoldQuantity > 0 {
+			marginItems = append(marginItems, item)
+		}
+	}
+	sort.Slice(marginItems, func(i, j int) bool {
+		marginI, _ := marginItems[i].RealisedMargin()
+		marginJ, _ := marginItems[j].RealisedMargin()
+		return marginI > marginJ
+	})
+	byMargin.WriteString("```")
+	for i, item := range marginItems {
+		if i == marketTopItems {
+			break
+		}
+		margin, _ := item.RealisedMargin()
+		byMargin.WriteString(fmt.Sprintf("%s  %s\n", humanize.FormatFloat(floatFormat, float64(margin)), itemName(item)))
+	}
+	byMargin.WriteString("```")
+
+	var messages = []*discordgo.MessageEmbed{
+		{
+			Title:       fmt.Sprintf("%s %s", marketRevenueMsg, title),
+			Description: byRevenue.String(),
+			Color:       0x00ff00,
+		},
+		{
+			Title:       fmt.Sprintf("%s %s", marketVolumeMsg, title),
+			Description: byVolume.String(),
+			Color:       0xffffff,
+		},
+		{
+			Title:       fmt.Sprintf("%s %s", marketMarginMsg, title),
+			Description: byMargin.String(),
+			Color:       0x0000ff,
+		},
+	}
+
+	return messages
+}
+
+// typeName returns name of the item type, or its ID when it is not known.
+func typeName(names map[namesDomainEntity.ID]namesDomainAggregate.Name, typeID balanceDomainEntity.TypeId) string {
+	name, ok := names[namesDomainEntity.ID(typeID)]
+	if !ok {
+		return fmt.Sprintf("Type %d", typeID)
+	}
+	return string(name.Name)
+}
@@ -0,0 +1,87 @@
+package discord
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	balanceDomainAggrgate "github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// iskTypesHandler will be called every time a new
+// message is created on any channel that the autenticated bot has access to.
+func (h *discordHandler) iskTypesHandler(r reply, args []string) {
+	if len(args) == 0 || args[0] != "unmapped" {
+		r.Error(errors.New("unknown command, use `!isk types unmapped`"))
+		return
+	}
+	r.Working()
+
+	// Without dates, whole journal stored in the DB is checked.
+	var (
+		err    error
+		period = balanceDomainEntity.NewPeriod(time.Time{}, time.Now())
+	)
+	if len(args) > 1 {
+		period, err = h.parsePeriod(args[1:])
+		if err != nil {
+			r.Error(err)
+			return
+		}
+	}
+
+	balance, err := h.accountantSvc.UnmappedTypes(h.ctx, period)
+	if h.balanceError(err, r) {
+		return
+	}
+
+	message := h.iskTypesUnmappedMessage(balance)
+	h.setDataAsOf(message)
+	err = r.SendEmbed(message)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error sending unmapped types message"))
+	}
+}
+
+func (h *discordHandler) iskTypesUnmappedMessage(balance *balanceDomainAggrgate.BalanceByType) *discordgo.MessageEmbed {
+	var refTypes []balanceDomainEntity.RefType
+	for refType := range balance.IncomeByType {
+		refTypes = append(refTypes, refType)
+	}
+	for refType := range balance.ExpensesByType {
+		if _, ok := balance.IncomeByType[refType]; !ok {
+			refTypes = append(refTypes, refType)
+		}
+	}
+	sort.Slice(refTypes, func(i, j int) bool {
+		return refTypes[i] < refTypes[j]
+	})
+
+	var description strings.Builder
+	if len(refTypes) == 0 {
+		description.WriteString(allTypesMappedMsg)
+	} else {
+		description.WriteString("```")
+		for _, refType := range refTypes {
+			description.WriteString(fmt.Sprintf(
+				"%s\n  income: %s expenses: %s\n",
+				refType,
+				humanize.FormatFloat(floatFormat, float64(balance.IncomeByType[refType])),
+				humanize.FormatFloat(floatFormat, float64(balance.ExpensesByType[refType])),
+			))
+		}
+		description.WriteString("```")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       unmappedTypesMsg,
+		Description: description.String(),
+		Color:       0xffffff,
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/dustin/go-humanize"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/notify"
 )
 
-func (h *discordHandler) MonthlyBalanceBelowThresholdMessage(ctx context.Context, notification aggregate.MonthlyBalanceNotification) {
+// MonthlyBalanceBelowThresholdMessage returns notification about balance of
+// this month below the threshold.
+func (h *discordHandler) MonthlyBalanceBelowThresholdMessage(ctx context.Context, notification aggregate.MonthlyBalanceNotification) notify.Message {
 	notificationMsg := fmt.Sprintf(
 		"`%s` < `%s`\n\n%s: `%s`\n%s: `%s`\n\nFor more details run:\n`!isk by division`\n`!isk by type`",
 		humanize.FormatFloat(floatFormat, float64(notification.Balance.Balance())),
@@ -19,17 +22,15 @@ func (h *discordHandler) MonthlyBalanceBelowThresholdMessage(ctx context.Context
 		expensesMsg,
 		humanize.FormatFloat(floatFormat, float64(notification.Balance.Expenses)),
 	)
-	_, err := h.discord.ChannelMessageSendEmbed(h.channelID, &discordgo.MessageEmbed{
+	message := &discordgo.MessageEmbed{
 		Title: fmt.Sprintf(
 			"%s %s",
 			monthlyBalanceNotificationMsg,
-			titleWithDate(notification.DateStart, notification.DateEnd),
+			titleWithDate(notification.Period),
 		),
 		Description: notificationMsg,
 		Color:       0xff0000,
-	})
-	if err != nil {
-		h.error(err, h.channelID)
-		return
 	}
+	h.setDataAsOf(message)
+	return notify.FromEmbed(notify.MonthlyBalanceKind, message)
 }
@@ -0,0 +1,124 @@
+package discord
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// reply sends results of a command back to whoever issued it, either as
+// channel messages for prefix commands or as interaction followups for slash
+// commands.
+type reply interface {
+	// Working tells the user the command was received, before the report is
+	// calculated (it takes quite few seconds to fetch everything).
+	Working()
+	SendEmbed(embed *discordgo.MessageEmbed) error
+	SendComplex(message *discordgo.MessageSend) error
+	Error(err error)
+	// User returns name of whoever issued the command.
+	User() string
+}
+
+// messageReply answers prefix command sent as a channel message.
+type messageReply struct {
+	h *discordHandler
+	m *discordgo.MessageCreate
+}
+
+func (r *messageReply) Working() {
+	err := r.h.discord.MessageReactionAdd(r.m.ChannelID, r.m.ID, `⏱️`)
+	if err != nil {
+		r.Error(errors.Wrap(err, "error reacting with :stopwatch: emoji"))
+	}
+}
+
+func (r *messageReply) SendEmbed(embed *discordgo.MessageEmbed) error {
+	_, err := r.h.discord.ChannelMessageSendEmbed(r.m.ChannelID, embed)
+	return err
+}
+
+func (r *messageReply) SendComplex(message *discordgo.MessageSend) error {
+	_, err := r.h.discord.ChannelMessageSendComplex(r.m.ChannelID, message)
+	return err
+}
+
+func (r *messageReply) Error(err error) {
+	r.h.error(err, r.m.ChannelID)
+}
+
+func (r *messageReply) User() string {
+	return r.m.Author.Username
+}
+
+// interactionReply answers slash command, the interaction is acknowledged
+// with deferred response and results are sent as followup messages.
+type interactionReply struct {
+	h        *discordHandler
+	i        *discordgo.InteractionCreate
+	deferred bool
+}
+
+func (r *interactionReply) Working() {
+	if r.deferred {
+		return
+	}
+	err := r.h.discord.InteractionRespond(r.i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		r.h.log.Error("error deferring interaction response", zap.Error(err))
+		return
+	}
+	r.deferred = true
+}
+
+func (r *interactionReply) SendEmbed(embed *discordgo.MessageEmbed) error {
+	return r.SendComplex(&discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{embed},
+	})
+}
+
+func (r *interactionReply) SendComplex(message *discordgo.MessageSend) error {
+	embeds := message.Embeds
+	if message.Embed != nil {
+		embeds = append(embeds, message.Embed)
+	}
+	files := message.Files
+	if message.File != nil {
+		files = append(files, message.File)
+	}
+	// Interaction must be acknowledged before followups can be sent.
+	r.Working()
+
+	_, err := r.h.discord.FollowupMessageCreate(r.i.Interaction, true, &discordgo.WebhookParams{
+		Content:    message.Content,
+		Embeds:     embeds,
+		Files:      files,
+		Components: message.Components,
+	})
+	return err
+}
+
+// User returns member who used the interaction in a guild, or user in DMs.
+func (r *interactionReply) User() string {
+	if r.i.Member != nil && r.i.Member.User != nil {
+		return r.i.Member.User.Username
+	}
+	if r.i.User != nil {
+		return r.i.User.Username
+	}
+	return ""
+}
+
+func (r *interactionReply) Error(errIn error) {
+	r.h.log.Error("error in discord handler call", zap.Error(errIn))
+	err := r.SendComplex(&discordgo.MessageSend{
+		Content: fmt.Sprintf("Sorry, some error happened: %s", errIn.Error()),
+	})
+	if err != nil {
+		r.h.log.Error("error responding with error", zap.Error(err), zap.NamedError("original_error", errIn))
+	}
+}
@@ -0,0 +1,86 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	balanceDomain "github.com/lunemec/eve-accountant/pkg/domain/balance"
+	balanceDomainEntity "github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	notifierHandler "github.com/lunemec/eve-accountant/pkg/handlers/notifier"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+// ReportMessages returns sections of scheduled report for the period, the
+// same messages as their commands show. Error of corporations missing from
+// the results is returned with messages of the rest.
+func (h *discordHandler) ReportMessages(ctx context.Context, report notifierHandler.Report, period balanceDomainEntity.Period) ([]notify.Message, error) {
+	var (
+		messages   []notify.Message
+		partialErr error
+	)
+	// checkErr returns true when the section cannot be shown.
+	checkErr := func(err error) bool {
+		if err != nil && balanceDomain.IsPartial(err) {
+			partialErr = err
+			return false
+		}
+		return err != nil
+	}
+	addEmbeds := func(embeds []*discordgo.MessageEmbed) {
+		h.setDataAsOf(embeds...)
+		for _, embed := range embeds {
+			messages = append(messages, notify.FromEmbed(notify.ReportKind, embed))
+		}
+	}
+
+	for _, section := range report.Sections {
+		switch section {
+		case notifierHandler.BalanceSection:
+			balance, err := h.accountantSvc.Balance(ctx, period)
+			if checkErr(err) {
+				return nil, errors.Wrap(err, "error calculating balance")
+			}
+			addEmbeds(h.iskMessages(period, balance))
+		case notifierHandler.ByDivisionSection:
+			balance, err := h.accountantSvc.BalanceByDivision(ctx, period)
+			if checkErr(err) {
+				return nil, errors.Wrap(err, "error calculating balance by division")
+			}
+			addEmbeds(h.iskByDivisionMessages(period, balance))
+		case notifierHandler.ByTypeSection:
+			balance, err := h.accountantSvc.BalanceByType(ctx, period)
+			if checkErr(err) {
+				return nil, errors.Wrap(err, "error calculating balance by type")
+			}
+			addEmbeds(h.iskByTypeMessages(period, balance))
+		case notifierHandler.GraphSection:
+			rawBalance, err := h.accountantSvc.BalanceByDayByDivisionByType(ctx, period)
+			if checkErr(err) {
+				return nil, errors.Wrap(err, "error calculating balance by day")
+			}
+			image, err := h.iskGraphImage(rawBalance)
+			if err != nil {
+				return nil, err
+			}
+			embed := &discordgo.MessageEmbed{
+				Title: fmt.Sprintf("%s %s", balanceMsg, titleWithDate(period)),
+				Color: 0xffffff,
+			}
+			h.setDataAsOf(embed)
+			message := notify.FromEmbed(notify.ReportKind, embed)
+			message.ImageURL = image.URL
+			message.Image = image.PNG
+			messages = append(messages, message)
+		default:
+			return nil, errors.Errorf("unknown report section: %s", section)
+		}
+	}
+
+	if len(messages) > 0 {
+		messages[0].Title = fmt.Sprintf("%s %s: %s", scheduledReportMsg, report.Name, messages[0].Title)
+	}
+	return messages, partialErr
+}
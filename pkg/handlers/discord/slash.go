@@ -0,0 +1,387 @@
+package discord
+
+import (
+	"strings"
+
+	"github.com/lunemec/eve-accountant/pkg/export"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+const (
+	periodOption    = "period"
+	dateStartOption = "start"
+	dateEndOption   = "end"
+	groupingOption  = "grouping"
+	memberOption    = "member"
+	modeOption      = "mode"
+	bucketOption    = "bucket"
+	alertOption     = "alert"
+	untilOption     = "until"
+	reportOption    = "report"
+	formatOption    = "format"
+)
+
+// journalOptions are filters of /isk journal, named like `!isk journal` filters.
+var journalOptions = []*discordgo.ApplicationCommandOption{
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        journalDivisionFilter,
+		Description: "Wallet division name (default all)",
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        journalTypeFilter,
+		Description: "Ref type or group as shown by by type report (default all)",
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        journalPartyFilter,
+		Description: "Part of name of the first or second party (default all)",
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        journalMinFilter,
+		Description: "Smallest amount either way, like 250m or 1.5b (default none)",
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        journalMaxFilter,
+		Description: "Largest amount either way, like 250m or 1.5b (default none)",
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        journalDescriptionFilter,
+		Description: "Text in description or reason (default all)",
+	},
+}
+
+// dateOptions let user pick the reported period, either by period expression
+// or by both dates.
+var dateOptions = []*discordgo.ApplicationCommandOption{
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        periodOption,
+		Description: "today, yesterday, this/last week|month|year, ytd, last 30d|4w|3m, Q2 2024, 2024, 2024-03, YYYY-MM-DD",
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        dateStartOption,
+		Description: "First day of the period as YYYY-MM-DD, e.g. 2024-03-15, needs end too (default this month)",
+		MinLength:   intPtr(len("2006-01-02")),
+		MaxLength:   len("2006-01-02"),
+	},
+	{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        dateEndOption,
+		Description: "Last day of the period as YYYY-MM-DD, e.g. 2024-03-20, included in the period",
+		MinLength:   intPtr(len("2006-01-02")),
+		MaxLength:   len("2006-01-02"),
+	},
+}
+
+// slashCommands are registered as Discord application commands, every
+// subcommand of /isk matches one prefix command.
+var slashCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "help",
+		Description: "Show list of commands",
+	},
+	{
+		Name:        "isk",
+		Description: "Corporation ISK reports",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "balance",
+				Description: "Top level balance overview",
+				Options:     dateOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "by",
+				Description: "Balance overview grouped by division, transaction type or member",
+				Options: append([]*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        groupingOption,
+						Description: "How to group the balance",
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "division", Value: "division"},
+							{Name: "type", Value: "type"},
+							{Name: "member", Value: "member"},
+						},
+					},
+				}, dateOptions...),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "graph",
+				Description: "Daily movement or wallet balance of each division",
+				Options: append([]*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        modeOption,
+						Description: "What to plot (default movement)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "movement", Value: "movement"},
+							{Name: "balance", Value: "balance"},
+							{Name: "by type", Value: "by type"},
+							{Name: "by division", Value: "by division"},
+						},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        bucketOption,
+						Description: "Period of one bar of by type and by division graphs (default by length of the period)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "daily", Value: "daily"},
+							{Name: "weekly", Value: "weekly"},
+							{Name: "monthly", Value: "monthly"},
+						},
+					},
+				}, dateOptions...),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "compare",
+				Description: "Change against the previous period and the same period last year",
+				Options:     dateOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "forecast",
+				Description: "Projected balance at the end of this month",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "market",
+				Description: "Top traded items by revenue, volume and realised margin",
+				Options:     dateOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "buyback",
+				Description: "Items bought from members by contract and their resale",
+				Options:     dateOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "krab",
+				Description: "Leaderboard of ratting and mission tax, or tax history of one pilot",
+				Options: append([]*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        memberOption,
+						Description: "Show monthly tax history of this pilot",
+					},
+				}, dateOptions...),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "journal",
+				Description: "Journal records matching all filters",
+				Options:     append(append([]*discordgo.ApplicationCommandOption{}, journalOptions...), dateOptions...),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "export",
+				Description: "Journal or report as a file for spreadsheets",
+				Options: append([]*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        reportOption,
+						Description: "What to export",
+						Required:    true,
+						Choices:     exportReportChoices(),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        formatOption,
+						Description: "Format of the file (default csv)",
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "CSV", Value: string(export.CSV)},
+							{Name: "JSON lines", Value: string(export.JSONLines)},
+							{Name: "XLSX", Value: string(export.XLSX)},
+						},
+					},
+				}, dateOptions...),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "ack",
+				Description: "List firing alerts or acknowledge one of them",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        alertOption,
+						Description: "Key of the alert to acknowledge (default list firing alerts)",
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        untilOption,
+						Description: "Hours like 12h, days like 3d or date YYYY-MM-DD (default until the alert clears)",
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "unmapped",
+				Description: "Raw transaction types which do not belong to any group",
+				Options:     dateOptions,
+			},
+		},
+	},
+}
+
+// exportReportChoices lists all reports which can be exported.
+func exportReportChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(export.Reports))
+	for _, report := range export.Reports {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  strings.Replace(string(report), "_", " ", -1),
+			Value: string(report),
+		})
+	}
+	return choices
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// registerCommands replaces all slash commands of the bot with slashCommands.
+func (h *discordHandler) registerCommands() error {
+	_, err := h.discord.ApplicationCommandBulkOverwrite(h.discord.State.User.ID, h.guildID, slashCommands)
+	if err != nil {
+		return errors.Wrap(err, "error overwriting application commands")
+	}
+	return nil
+}
+
+// interactionRouter handles slash commands, options are translated into
+// arguments of the matching prefix command handler. Buttons of alerts are
+// handled too.
+func (h *discordHandler) interactionRouter(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type == discordgo.InteractionMessageComponent {
+		if strings.HasPrefix(i.MessageComponentData().CustomID, journalButtonPrefix) {
+			h.journalButtonHandler(s, i)
+			return
+		}
+		h.alertButtonHandler(&interactionReply{h: h, i: i}, i.MessageComponentData().CustomID)
+		return
+	}
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	r := &interactionReply{h: h, i: i}
+	// Discord requires response within 3 seconds, reports take longer.
+	r.Working()
+
+	data := i.ApplicationCommandData()
+	if data.Name == "help" {
+		h.helpHandler(r, nil)
+		return
+	}
+	if data.Name != "isk" || len(data.Options) == 0 {
+		r.Error(errors.Errorf("unknown command: %s", data.Name))
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := make(map[string]string)
+	for _, option := range subcommand.Options {
+		options[option.Name] = option.StringValue()
+	}
+	args, err := dateArgs(options)
+	if err != nil {
+		r.Error(err)
+		return
+	}
+
+	switch subcommand.Name {
+	case "balance":
+		h.iskHandler(r, args)
+	case "by":
+		switch options[groupingOption] {
+		case "division":
+			h.iskByDivisionHandler(r, args)
+		case "type":
+			h.iskByTypeHandler(r, args)
+		case "member":
+			h.iskByMemberHandler(r, args)
+		default:
+			r.Error(errors.Errorf("unknown grouping: %s", options[groupingOption]))
+		}
+	case "graph":
+		switch options[modeOption] {
+		case "balance":
+			args = append([]string{"balance"}, args...)
+		case "by type", "by division":
+			if bucket, ok := options[bucketOption]; ok {
+				args = append([]string{bucket}, args...)
+			}
+			args = append(strings.Fields(options[modeOption]), args...)
+		}
+		h.iskGraphHandler(r, args)
+	case "compare":
+		h.iskCompareHandler(r, args)
+	case "forecast":
+		h.iskForecastHandler(r, args)
+	case "market":
+		h.iskMarketHandler(r, args)
+	case "buyback":
+		h.iskBuybackHandler(r, args)
+	case "krab":
+		if member, ok := options[memberOption]; ok {
+			h.iskKrabHandler(r, []string{"member", member})
+			return
+		}
+		h.iskKrabHandler(r, args)
+	case "journal":
+		for _, filter := range journalFilters {
+			if value, ok := options[filter]; ok {
+				args = append(args, filter+":"+value)
+			}
+		}
+		h.iskJournalHandler(r, args)
+	case "export":
+		exportArgs := []string{options[reportOption]}
+		if format, ok := options[formatOption]; ok {
+			exportArgs = append(exportArgs, format)
+		}
+		h.iskExportHandler(r, append(exportArgs, args...))
+	case "ack":
+		var ackArgs []string
+		if alert, ok := options[alertOption]; ok {
+			ackArgs = append(strings.Fields(alert), strings.Fields(options[untilOption])...)
+		}
+		h.iskAckHandler(r, ackArgs)
+	case "unmapped":
+		h.iskTypesHandler(r, append([]string{"unmapped"}, args...))
+	default:
+		r.Error(errors.Errorf("unknown command: %s %s", data.Name, subcommand.Name))
+	}
+}
+
+// dateArgs returns date options as prefix command arguments.
+func dateArgs(options map[string]string) ([]string, error) {
+	period, hasPeriod := options[periodOption]
+	dateStart, hasStart := options[dateStartOption]
+	dateEnd, hasEnd := options[dateEndOption]
+	if hasPeriod && (hasStart || hasEnd) {
+		return nil, errors.New("set either period or start and end dates")
+	}
+	if hasPeriod {
+		return strings.Fields(period), nil
+	}
+	if hasStart != hasEnd {
+		return nil, errors.New("set both start and end dates, or none for this month")
+	}
+	if !hasStart {
+		return nil, nil
+	}
+	return []string{dateStart, dateEnd}, nil
+}
@@ -4,25 +4,56 @@ import (
 	"context"
 	"time"
 
+	alertEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/notify"
 	"github.com/lunemec/eve-accountant/pkg/services/accountant"
 	"github.com/pkg/errors"
 
 	"go.uber.org/zap"
 )
 
-type sendMsgFunc func(context.Context, aggregate.MonthlyBalanceNotification)
+// Functions returning messages of notifications, which are delivered by
+// notifier routed for their kind.
+type monthlyBalanceMsgFunc func(context.Context, aggregate.MonthlyBalanceNotification) notify.Message
+
+type forecastMsgFunc func(context.Context, *aggregate.Forecast) notify.Message
+
+type anomalyMsgFunc func(context.Context, aggregate.Anomaly) (notify.Message, error)
+
+// Keys of alerts about balance of this month.
+const (
+	monthlyBalanceKey alertEntity.Key = "monthly_balance"
+	forecastKey       alertEntity.Key = "forecast"
+)
 
 type notifierHandler struct {
-	ctx            context.Context
-	log            *zap.Logger
-	checkInterval  time.Duration
+	ctx           context.Context
+	log           *zap.Logger
+	checkInterval time.Duration
+	// notifyInterval is how often balance and forecast alerts are sent again
+	// while they still fire.
 	notifyInterval time.Duration
 	accountantSvc  accountant.Service
+	// location of days of alert rule periods.
+	location *time.Location
+	// now is the clock rules are evaluated at.
+	now func() time.Time
+	// notifier delivers all messages.
+	notifier notify.Notifier
 
-	sendMsgFunc sendMsgFunc
-
-	lastNotify time.Time
+	monthlyBalanceMsgFunc monthlyBalanceMsgFunc
+	// forecastMsgFunc warns early about projected balance, nil disables it.
+	forecastMsgFunc forecastMsgFunc
+	// anomalyMsgFunc alerts unusual journal records, nil disables it.
+	anomalyMsgFunc anomalyMsgFunc
+	rules          Rules
+	alertMsgFunc   alertMsgFunc
+	// resolvedMsgFunc tells that firing alert no longer fires.
+	resolvedMsgFunc resolvedMsgFunc
+	reports         *Reports
+	reportMsgFunc   reportMsgFunc
 }
 
 func New(
@@ -30,17 +61,36 @@ func New(
 	log *zap.Logger,
 	checkInterval, notifyInterval time.Duration,
 	accountantSvc accountant.Service,
-	sendMsgFunc sendMsgFunc,
+	location *time.Location,
+	notifier notify.Notifier,
+	monthlyBalanceMsgFunc monthlyBalanceMsgFunc,
+	forecastMsgFunc forecastMsgFunc,
+	anomalyMsgFunc anomalyMsgFunc,
+	rules Rules,
+	alertMsgFunc alertMsgFunc,
+	resolvedMsgFunc resolvedMsgFunc,
+	reports *Reports,
+	reportMsgFunc reportMsgFunc,
 ) *notifierHandler {
-	notifier := notifierHandler{
-		ctx:            ctx,
-		log:            log,
-		checkInterval:  checkInterval,
-		notifyInterval: notifyInterval,
-		accountantSvc:  accountantSvc,
-		sendMsgFunc:    sendMsgFunc,
-	}
-	return &notifier
+	handler := notifierHandler{
+		ctx:                   ctx,
+		log:                   log,
+		checkInterval:         checkInterval,
+		notifyInterval:        notifyInterval,
+		accountantSvc:         accountantSvc,
+		location:              location,
+		now:                   time.Now,
+		notifier:              notifier,
+		monthlyBalanceMsgFunc: monthlyBalanceMsgFunc,
+		forecastMsgFunc:       forecastMsgFunc,
+		anomalyMsgFunc:        anomalyMsgFunc,
+		rules:                 rules,
+		alertMsgFunc:          alertMsgFunc,
+		resolvedMsgFunc:       resolvedMsgFunc,
+		reports:               reports,
+		reportMsgFunc:         reportMsgFunc,
+	}
+	return &handler
 }
 
 func (n *notifierHandler) Start() {
@@ -65,31 +115,108 @@ func (n *notifierHandler) Start() {
 	}
 }
 
-// tick is called every ticker interval.
+// tick is called every ticker interval, all alerts are checked on every tick
+// and persisted alert state decides what is sent.
 func (n *notifierHandler) tick() error {
-	now := time.Now()
-	if now.After(n.lastNotify.Add(n.notifyInterval)) {
-		err := n.notify()
+	if n.anomalyMsgFunc != nil {
+		err := n.alertAnomalies()
 		if err != nil {
-			return err
+			n.log.Error("anomaly alert error", zap.Error(err))
 		}
+	}
+	if n.alertMsgFunc != nil {
+		err := n.alertRules()
+		if err != nil {
+			n.log.Error("alert rules error", zap.Error(err))
+		}
+	}
 
-		n.lastNotify = now
+	if n.reportMsgFunc != nil {
+		err := n.sendReports()
+		if err != nil {
+			n.log.Error("scheduled reports error", zap.Error(err))
+		}
 	}
-	return nil
+
+	return n.notify()
 }
 
 func (n *notifierHandler) notify() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	notify, balance, err := n.accountantSvc.MonthlyBalanceBelowThreshold(ctx)
+	// Balance of empty DB is always below threshold, wait for the first synchronization.
+	dataAsOf, err := n.accountantSvc.DataAsOf(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error loading last synchronization time")
+	}
+	if dataAsOf.IsZero() {
+		return errors.New("data not synchronized yet, skipping notification check")
+	}
+
+	below, balance, err := n.accountantSvc.MonthlyBalanceBelowThreshold(ctx)
 	if err != nil {
 		return errors.Wrap(err, "error")
 	}
-	if notify {
-		n.sendMsgFunc(ctx, balance)
+	err = n.condition(ctx, notify.MonthlyBalanceKind, monthlyBalanceKey, below, n.notifyInterval, func() (notify.Message, error) {
+		return n.monthlyBalanceMsgFunc(ctx, balance), nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error sending monthly balance notification")
+	}
+	if below || n.forecastMsgFunc == nil {
+		return nil
 	}
 
+	// Balance is fine so far, warn when it is projected to fall below the threshold.
+	forecast, err := n.accountantSvc.Forecast(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error forecasting balance")
+	}
+	err = n.condition(ctx, notify.ForecastKind, forecastKey, forecast.BelowThreshold(), n.notifyInterval, func() (notify.Message, error) {
+		return n.forecastMsgFunc(ctx, forecast), nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error sending forecast notification")
+	}
+
+	return nil
+}
+
+// alertAnomalies sends every anomaly not alerted yet, each one is marked only
+// after it was sent so failed messages are retried on the next tick.
+func (n *notifierHandler) alertAnomalies() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	dataAsOf, err := n.accountantSvc.DataAsOf(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error loading last synchronization time")
+	}
+	if dataAsOf.IsZero() {
+		return nil
+	}
+
+	anomalies, err := n.accountantSvc.Anomalies(ctx)
+	if err != nil {
+		if !balance.IsPartial(err) {
+			return errors.Wrap(err, "error detecting anomalies")
+		}
+		n.log.Warn("anomalies detected without some corporations", zap.Error(err))
+	}
+	for _, anomaly := range anomalies {
+		message, err := n.anomalyMsgFunc(ctx, anomaly)
+		if err != nil {
+			return errors.Wrapf(err, "error creating anomaly alert for journal: %d", anomaly.Record.Id)
+		}
+		err = n.notifier.Notify(ctx, message)
+		if err != nil {
+			return errors.Wrapf(err, "error sending anomaly alert for journal: %d", anomaly.Record.Id)
+		}
+		err = n.accountantSvc.AnomalyAlerted(ctx, anomaly)
+		if err != nil {
+			return errors.Wrapf(err, "error saving anomaly alert for journal: %d", anomaly.Record.Id)
+		}
+	}
 	return nil
 }
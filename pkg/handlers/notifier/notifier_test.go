@@ -0,0 +1,239 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/alert"
+	alertAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	alertEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+	"github.com/lunemec/eve-accountant/pkg/services/accountant"
+
+	"go.uber.org/zap"
+)
+
+// memoryAlerts is alert repository kept in memory.
+type memoryAlerts struct {
+	mu     sync.Mutex
+	states map[alertEntity.Key]alertAggregate.State
+}
+
+func (r *memoryAlerts) State(ctx context.Context, key alertEntity.Key) (*alertAggregate.State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[key]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (r *memoryAlerts) SaveState(ctx context.Context, state alertAggregate.State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[state.Key] = state
+	return nil
+}
+
+func (r *memoryAlerts) FiringStates(ctx context.Context) ([]alertAggregate.State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var states []alertAggregate.State
+	for _, state := range r.states {
+		if state.Firing {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+// fakeAccountant serves fixed balances, alert state is kept by real alert
+// service in memory. Methods not overridden panic.
+type fakeAccountant struct {
+	accountant.Service
+	alerts alert.Service
+
+	mu         sync.Mutex
+	reportRuns map[string]time.Time
+
+	dataAsOf      time.Time
+	spending      entity.Amount
+	loss          entity.Amount
+	walletBalance map[entity.DivisionName]entity.Balance
+	large         []aggregate.WalletRecord
+	// below is whether monthly balance is below threshold.
+	below bool
+	// periods passed to balance methods.
+	periods []entity.Period
+}
+
+func newFakeAccountant(dataAsOf time.Time) *fakeAccountant {
+	return &fakeAccountant{
+		alerts:     alert.NewService(&memoryAlerts{states: make(map[alertEntity.Key]alertAggregate.State)}),
+		reportRuns: make(map[string]time.Time),
+		dataAsOf:   dataAsOf,
+	}
+}
+
+func (a *fakeAccountant) DataAsOf(ctx context.Context) (time.Time, error) {
+	return a.dataAsOf, nil
+}
+
+func (a *fakeAccountant) Spending(ctx context.Context, period entity.Period, refType entity.RefType) (entity.Amount, error) {
+	a.periods = append(a.periods, period)
+	return a.spending, nil
+}
+
+func (a *fakeAccountant) Balance(ctx context.Context, period entity.Period) (*aggregate.Balance, error) {
+	a.periods = append(a.periods, period)
+	return &aggregate.Balance{Expenses: -a.loss}, nil
+}
+
+func (a *fakeAccountant) WalletBalanceByDay(ctx context.Context, period entity.Period) ([]*aggregate.WalletBalanceByDay, error) {
+	a.periods = append(a.periods, period)
+	day := aggregate.NewWalletBalanceByDay(period.Start)
+	for division, balance := range a.walletBalance {
+		day.ByWallet[aggregate.Wallet{CorporationID: 98000001, Division: division}] = balance
+	}
+	return []*aggregate.WalletBalanceByDay{day}, nil
+}
+
+func (a *fakeAccountant) LargeTransactions(ctx context.Context, period entity.Period, minAmount entity.Amount) ([]aggregate.WalletRecord, error) {
+	a.periods = append(a.periods, period)
+	return a.large, nil
+}
+
+func (a *fakeAccountant) MonthlyBalanceBelowThreshold(ctx context.Context) (bool, aggregate.MonthlyBalanceNotification, error) {
+	return a.below, aggregate.MonthlyBalanceNotification{}, nil
+}
+
+func (a *fakeAccountant) AlertState(ctx context.Context, key alertEntity.Key) (*alertAggregate.State, error) {
+	return a.alerts.State(ctx, key)
+}
+
+func (a *fakeAccountant) FiringAlerts(ctx context.Context) ([]alertAggregate.State, error) {
+	return a.alerts.Firing(ctx)
+}
+
+func (a *fakeAccountant) MarkAlertSent(ctx context.Context, key alertEntity.Key, at time.Time) error {
+	return a.alerts.MarkSent(ctx, key, at)
+}
+
+func (a *fakeAccountant) MarkAlertFiring(ctx context.Context, key alertEntity.Key, title string, channel alertEntity.ChannelID, at time.Time) error {
+	return a.alerts.MarkFiring(ctx, key, title, channel, at)
+}
+
+func (a *fakeAccountant) ClearAlert(ctx context.Context, key alertEntity.Key) error {
+	return a.alerts.Clear(ctx, key)
+}
+
+func (a *fakeAccountant) AcknowledgeAlert(ctx context.Context, key alertEntity.Key, until time.Time, by string) error {
+	return a.alerts.Acknowledge(ctx, key, until, by)
+}
+
+func (a *fakeAccountant) ReportLastRun(ctx context.Context, name string) (time.Time, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.reportRuns[name], nil
+}
+
+func (a *fakeAccountant) MarkReportRun(ctx context.Context, name string, at time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reportRuns[name] = at
+	return nil
+}
+
+// recordingNotifier keeps titles of delivered messages, fail makes the next
+// deliveries fail.
+type recordingNotifier struct {
+	titles []string
+	fail   error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, message notify.Message) error {
+	if n.fail != nil {
+		return n.fail
+	}
+	n.titles = append(n.titles, message.Title)
+	return nil
+}
+
+type staticRules []alertAggregate.Rule
+
+func (r staticRules) Rules() []alertAggregate.Rule {
+	return r
+}
+
+// fakeClock is the time of notifier checks, tests move it forward.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// testHandler returns notifier checking rules at the clock time, messages
+// are titled by alert key.
+func testHandler(accountantSvc accountant.Service, notifier notify.Notifier, clock *fakeClock, rules ...alertAggregate.Rule) *notifierHandler {
+	return &notifierHandler{
+		ctx:           context.Background(),
+		log:           zap.NewNop(),
+		accountantSvc: accountantSvc,
+		location:      time.UTC,
+		now:           clock.Now,
+		notifier:      notifier,
+		rules:         staticRules(rules),
+		alertMsgFunc: func(ctx context.Context, alert alertAggregate.Alert) (notify.Message, error) {
+			return notify.Message{Title: string(alert.Key)}, nil
+		},
+		resolvedMsgFunc: func(ctx context.Context, state alertAggregate.State) notify.Message {
+			return notify.Message{Title: "resolved " + string(state.Key)}
+		},
+	}
+}
+
+func TestTickNotifyInterval(t *testing.T) {
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	accountantSvc.below = true
+	notifier := &recordingNotifier{}
+	handler := testHandler(accountantSvc, notifier, clock)
+	handler.notifyInterval = time.Hour
+	handler.monthlyBalanceMsgFunc = func(ctx context.Context, balance aggregate.MonthlyBalanceNotification) notify.Message {
+		return notify.Message{Title: string(monthlyBalanceKey)}
+	}
+
+	for i := 0; i < 5; i++ {
+		err := handler.tick()
+		if err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(20 * time.Minute)
+	}
+	if fmt.Sprint(notifier.titles) != "[monthly_balance monthly_balance]" {
+		t.Errorf("expected firing alert sent again after notify interval, got %v", notifier.titles)
+	}
+
+	// Resolve is sent on the next tick, not after notify interval.
+	accountantSvc.below = false
+	notifier.titles = nil
+	err := handler.tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(notifier.titles) != "[resolved monthly_balance]" {
+		t.Errorf("expected resolved alert on the next tick, got %v", notifier.titles)
+	}
+}
@@ -0,0 +1,290 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/daterange"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+	"github.com/pkg/errors"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// maxCatchUpRuns limits how many runs of one report missed during downtime
+// are sent, older ones are skipped.
+const maxCatchUpRuns = 3
+
+// Section of a scheduled report, each matches one command.
+type Section string
+
+const (
+	BalanceSection    Section = "balance"
+	ByDivisionSection Section = "by_division"
+	ByTypeSection     Section = "by_type"
+	GraphSection      Section = "graph"
+)
+
+// Report is sent on schedule for the period before each run.
+type Report struct {
+	Name string `yaml:"name" json:"name"`
+	// Schedule is a cron expression in location of reports.
+	Schedule string `yaml:"schedule" json:"schedule"`
+	// Period is parsed like periods of commands, relative to the run time.
+	Period   string    `yaml:"period" json:"period"`
+	Sections []Section `yaml:"sections" json:"sections"`
+	// Channels are Discord channels to send the report to, when empty it is
+	// sent by notifiers routed for reports.
+	Channels []string `yaml:"channels" json:"channels"`
+
+	schedule *Schedule
+}
+
+// ReportsConfig is the format of the scheduled reports file, for example:
+//
+//	reports:
+//	  - name: monthly closing
+//	    schedule: "0 8 1 * *"
+//	    period: last month
+//	    sections: [balance, by_division, by_type, graph]
+//	    channels: ["123456789012345678"]
+//
+// JSON files use the same structure.
+type ReportsConfig struct {
+	Reports []Report `yaml:"reports" json:"reports"`
+}
+
+// reportMsgFunc returns messages of report sections for the period.
+type reportMsgFunc func(context.Context, Report, entity.Period) ([]notify.Message, error)
+
+// Reports are scheduled reports loaded from a file which can be reloaded at
+// runtime, there are none without a file.
+type Reports struct {
+	path string
+
+	mu      sync.RWMutex
+	reports []Report
+}
+
+// NewReports returns reports loaded from path, no reports when path is empty.
+func NewReports(path string) (*Reports, error) {
+	r := &Reports{
+		path: path,
+	}
+	if path == "" {
+		return r, nil
+	}
+	err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Path returns file the reports are loaded from.
+func (r *Reports) Path() string {
+	return r.path
+}
+
+// Reload reads the reports file again, current reports are kept when the
+// file is invalid.
+func (r *Reports) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return errors.Wrapf(err, "error reading reports file: %s", r.path)
+	}
+	reports, err := ParseReports(data, filepath.Ext(r.path))
+	if err != nil {
+		return errors.Wrapf(err, "error loading reports file: %s", r.path)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = reports
+	return nil
+}
+
+// Reports returns current reports.
+func (r *Reports) Reports() []Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.reports
+}
+
+// ParseReports parses and validates scheduled reports, ext selects the
+// format (".json", ".yaml" or ".yml").
+func ParseReports(data []byte, ext string) ([]Report, error) {
+	var config ReportsConfig
+	switch strings.ToLower(ext) {
+	// JSON is a subset of YAML, strict YAML decoder rejects unknown fields and
+	// duplicate keys in both.
+	case ".json", ".yaml", ".yml":
+		err := yaml.UnmarshalStrict(data, &config)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid reports")
+		}
+	default:
+		return nil, errors.Errorf("unsupported reports format: %q, use .yaml, .yml or .json", ext)
+	}
+
+	return config.reports()
+}
+
+// reports validates the config and parses schedules.
+func (c ReportsConfig) reports() ([]Report, error) {
+	var (
+		problems []string
+		names    = make(map[string]struct{}, len(c.Reports))
+		reports  = make([]Report, 0, len(c.Reports))
+	)
+	for i, report := range c.Reports {
+		if strings.TrimSpace(report.Name) == "" {
+			problems = append(problems, fmt.Sprintf("report %d has no name", i+1))
+			continue
+		}
+		if _, ok := names[report.Name]; ok {
+			problems = append(problems, fmt.Sprintf("report %q is defined twice", report.Name))
+			continue
+		}
+		names[report.Name] = struct{}{}
+
+		schedule, err := ParseSchedule(report.Schedule)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("report %q: %s", report.Name, err))
+		}
+		report.schedule = schedule
+		if strings.TrimSpace(report.Period) == "" {
+			problems = append(problems, fmt.Sprintf("report %q has no period", report.Name))
+		} else if _, err := daterange.Parse(strings.Fields(report.Period), time.Now()); err != nil {
+			problems = append(problems, fmt.Sprintf("report %q: %s", report.Name, err))
+		}
+		if len(report.Sections) == 0 {
+			problems = append(problems, fmt.Sprintf("report %q has no sections", report.Name))
+		}
+		for _, section := range report.Sections {
+			switch section {
+			case BalanceSection, ByDivisionSection, ByTypeSection, GraphSection:
+			default:
+				problems = append(problems, fmt.Sprintf("report %q has unknown section %q", report.Name, section))
+			}
+		}
+		reports = append(reports, report)
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("invalid reports: %s", strings.Join(problems, "; "))
+	}
+	return reports, nil
+}
+
+// sendReports sends runs of reports due since their last run, including runs
+// missed while the bot was down. Run waits until data was synchronized after
+// it, so the period is complete. Report seen for the first time starts its
+// schedule now.
+func (n *notifierHandler) sendReports() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	dataAsOf, err := n.accountantSvc.DataAsOf(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error loading last synchronization time")
+	}
+
+	now := n.now().In(n.location)
+	for _, report := range n.reports.Reports() {
+		lastRun, err := n.accountantSvc.ReportLastRun(ctx, report.Name)
+		if err != nil {
+			return err
+		}
+		if lastRun.IsZero() {
+			err = n.accountantSvc.MarkReportRun(ctx, report.Name, now)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var runs []time.Time
+		for at := report.schedule.Next(lastRun.In(n.location)); !at.IsZero() && !at.After(now); at = report.schedule.Next(at) {
+			runs = append(runs, at)
+		}
+		if len(runs) > maxCatchUpRuns {
+			n.log.Warn("skipping missed runs of report", zap.String("report", report.Name), zap.Int("skipped", len(runs)-maxCatchUpRuns))
+			runs = runs[len(runs)-maxCatchUpRuns:]
+		}
+		for _, at := range runs {
+			if dataAsOf.Before(at) {
+				break
+			}
+			err = n.sendReport(ctx, report, at)
+			if err != nil {
+				return errors.Wrapf(err, "error sending report: %s", report.Name)
+			}
+			err = n.accountantSvc.MarkReportRun(ctx, report.Name, at)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sendReport sends run of the report at given time to all its channels.
+// Failed messages are only logged once any message was delivered, the run is
+// then marked and retrying it would repeat messages already delivered.
+// Error is returned when nothing was delivered.
+func (n *notifierHandler) sendReport(ctx context.Context, report Report, at time.Time) error {
+	period, err := daterange.Parse(strings.Fields(report.Period), at)
+	if err != nil {
+		return err
+	}
+	messages, err := n.reportMsgFunc(ctx, report, period)
+	if err != nil {
+		if !balance.IsPartial(err) {
+			return errors.Wrap(err, "error creating report")
+		}
+		n.log.Warn("report created without some corporations", zap.String("report", report.Name), zap.Error(err))
+	}
+
+	channels := report.Channels
+	if len(channels) == 0 {
+		channels = []string{""}
+	}
+	var (
+		delivered bool
+		lastErr   error
+	)
+	for _, channel := range channels {
+		for _, message := range messages {
+			message.Kind = notify.ReportKind
+			message.Channel = channel
+			err = n.notifier.Notify(ctx, message)
+			if err != nil {
+				lastErr = err
+				n.log.Error("error sending report message",
+					zap.String("report", report.Name),
+					zap.String("channel", channel),
+					zap.String("title", message.Title),
+					zap.Error(err),
+				)
+				continue
+			}
+			delivered = true
+		}
+	}
+	if !delivered && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
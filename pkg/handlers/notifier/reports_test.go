@@ -0,0 +1,83 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+)
+
+// channelNotifier records channels of delivered messages, messages to
+// failing channels fail.
+type channelNotifier struct {
+	failing  map[string]bool
+	channels []string
+}
+
+func (n *channelNotifier) Notify(ctx context.Context, message notify.Message) error {
+	if n.failing[message.Channel] {
+		return errors.New("unknown channel")
+	}
+	n.channels = append(n.channels, message.Channel)
+	return nil
+}
+
+func TestSendReportsPartialDelivery(t *testing.T) {
+	reports, err := ParseReports([]byte(`
+reports:
+  - name: daily
+    schedule: "0 8 * * *"
+    period: yesterday
+    sections: [balance]
+    channels: ["1", "2"]
+`), ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	notifier := &channelNotifier{failing: map[string]bool{"1": true, "2": true}}
+	handler := testHandler(accountantSvc, notifier, clock)
+	handler.reports = &Reports{reports: reports}
+	handler.reportMsgFunc = func(ctx context.Context, report Report, period entity.Period) ([]notify.Message, error) {
+		return []notify.Message{{Title: report.Name}}, nil
+	}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		failing []string
+		want    string
+	}{
+		{name: "schedule starts", want: "[]"},
+		{name: "nothing delivered", advance: 24 * time.Hour, failing: []string{"1", "2"}, want: "[]"},
+		{name: "retried, one channel delivered", advance: time.Minute, failing: []string{"1"}, want: "[2]"},
+		{name: "run not repeated", advance: time.Minute, want: "[2]"},
+		{name: "next run", advance: 24 * time.Hour, want: "[2 1 2]"},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			clock.Advance(step.advance)
+			accountantSvc.dataAsOf = clock.now
+			notifier.failing = make(map[string]bool)
+			for _, channel := range step.failing {
+				notifier.failing[channel] = true
+			}
+
+			err := handler.sendReports()
+			if len(step.failing) == 2 && err == nil {
+				t.Error("expected error when nothing was delivered")
+			}
+			if len(step.failing) < 2 && err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprint(notifier.channels); got != step.want {
+				t.Errorf("expected deliveries %s, got %s", step.want, got)
+			}
+		})
+	}
+}
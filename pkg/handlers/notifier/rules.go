@@ -0,0 +1,202 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	alertAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	alertEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+	"github.com/pkg/errors"
+
+	"go.uber.org/zap"
+)
+
+// Rules returns alert rules currently configured.
+type Rules interface {
+	Rules() []alertAggregate.Rule
+}
+
+type alertMsgFunc func(context.Context, alertAggregate.Alert) (notify.Message, error)
+
+// alertRules evaluates every rule and sends alerts whose cooldown passed. Rule
+// that fails to evaluate is logged and the rest are still evaluated, results
+// with some corporations missing are not used to avoid false alerts.
+func (n *notifierHandler) alertRules() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Empty DB would trigger balance floors, wait for the first synchronization.
+	dataAsOf, err := n.accountantSvc.DataAsOf(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error loading last synchronization time")
+	}
+	if dataAsOf.IsZero() {
+		return nil
+	}
+
+	rules := n.rules.Rules()
+	err = n.clearRemovedRules(ctx, rules)
+	if err != nil {
+		return err
+	}
+
+	now := n.now()
+	for _, rule := range rules {
+		alerts, err := n.evaluate(ctx, rule, now)
+		if err != nil {
+			n.log.Error("error evaluating alert rule", zap.String("rule", string(rule.Name)), zap.Error(err))
+			continue
+		}
+		if rule.Kind != alertAggregate.LargeTransaction {
+			// Condition rules fire while evaluate returns an alert.
+			var alert alertAggregate.Alert
+			if len(alerts) > 0 {
+				alert = alerts[0]
+			}
+			err = n.condition(ctx, notify.RuleKind, ruleKey(rule), len(alerts) > 0, rule.Cooldown, func() (notify.Message, error) {
+				return n.alertMsgFunc(ctx, alert)
+			})
+			if err != nil {
+				return errors.Wrapf(err, "error alerting rule: %s", rule.Name)
+			}
+			continue
+		}
+		// Every large transaction is sent only once.
+		for _, alert := range alerts {
+			state, err := n.accountantSvc.AlertState(ctx, alert.Key)
+			if err != nil {
+				return errors.Wrapf(err, "error checking alert of rule: %s", rule.Name)
+			}
+			if state != nil {
+				continue
+			}
+			message, err := n.alertMsgFunc(ctx, alert)
+			if err != nil {
+				return errors.Wrapf(err, "error creating alert of rule: %s", rule.Name)
+			}
+			err = n.notifier.Notify(ctx, message)
+			if err != nil {
+				return errors.Wrapf(err, "error sending alert of rule: %s", rule.Name)
+			}
+			err = n.accountantSvc.MarkAlertSent(ctx, alert.Key, now)
+			if err != nil {
+				return errors.Wrapf(err, "error saving alert of rule: %s", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// clearRemovedRules clears firing alerts of rules no longer configured, so
+// removed or renamed rules neither stay firing nor can be acknowledged.
+func (n *notifierHandler) clearRemovedRules(ctx context.Context, rules []alertAggregate.Rule) error {
+	firing, err := n.accountantSvc.FiringAlerts(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error listing firing alerts")
+	}
+	configured := make(map[alertEntity.Key]struct{}, len(rules))
+	for _, rule := range rules {
+		configured[ruleKey(rule)] = struct{}{}
+	}
+	for _, state := range firing {
+		if !strings.HasPrefix(string(state.Key), rulePrefix) {
+			continue
+		}
+		if _, ok := configured[state.Key]; ok {
+			continue
+		}
+		err = n.accountantSvc.ClearAlert(ctx, state.Key)
+		if err != nil {
+			return errors.Wrapf(err, "error clearing alert of removed rule: %s", state.Key)
+		}
+		n.log.Info("cleared alert of removed rule", zap.String("key", string(state.Key)))
+	}
+	return nil
+}
+
+// rulePrefix starts keys of all rule alerts.
+const rulePrefix = "rule/"
+
+// ruleKey returns key of alerts of the rule.
+func ruleKey(rule alertAggregate.Rule) alertEntity.Key {
+	return alertEntity.Key(rulePrefix + string(rule.Name))
+}
+
+// evaluate returns alerts of the rule at now, each LargeTransaction record is
+// a separate alert.
+func (n *notifierHandler) evaluate(ctx context.Context, rule alertAggregate.Rule, now time.Time) ([]alertAggregate.Alert, error) {
+	now = now.In(n.location)
+	var (
+		key    = ruleKey(rule)
+		period = entity.NewPeriod(now.Add(-rule.Window), now)
+	)
+	switch rule.Kind {
+	case alertAggregate.DivisionBalanceFloor:
+		today := entity.DaysPeriod(now, now)
+		days, err := n.accountantSvc.WalletBalanceByDay(ctx, today)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading wallet balance")
+		}
+		var (
+			balance entity.Amount
+			found   bool
+		)
+		for wallet, walletBalance := range days[len(days)-1].ByWallet {
+			if strings.EqualFold(string(wallet.Division), string(rule.Division)) {
+				balance += entity.Amount(walletBalance)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("no wallet division: %s", rule.Division)
+		}
+		if balance < rule.Amount {
+			return []alertAggregate.Alert{{Key: key, Rule: rule, Period: today, Value: balance}}, nil
+		}
+
+	case alertAggregate.TypeSpendingCap:
+		spent, err := n.accountantSvc.Spending(ctx, period, rule.RefType)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading spending")
+		}
+		if spent > rule.Amount {
+			return []alertAggregate.Alert{{Key: key, Rule: rule, Period: period, Value: spent}}, nil
+		}
+
+	case alertAggregate.NetLossLimit:
+		balance, err := n.accountantSvc.Balance(ctx, period)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading balance")
+		}
+		loss := -balance.Balance()
+		if loss > rule.Amount {
+			return []alertAggregate.Alert{{Key: key, Rule: rule, Period: period, Value: loss}}, nil
+		}
+
+	case alertAggregate.LargeTransaction:
+		records, err := n.accountantSvc.LargeTransactions(ctx, period, rule.Amount)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading transactions")
+		}
+		var alerts []alertAggregate.Alert
+		for i := range records {
+			record := records[i]
+			if rule.Division != "" && !strings.EqualFold(string(record.Wallet.Division), string(rule.Division)) {
+				continue
+			}
+			alerts = append(alerts, alertAggregate.Alert{
+				Key:    alertEntity.Key(fmt.Sprintf("%s/%d/%d", key, record.Wallet.CorporationID, record.Record.Id)),
+				Rule:   rule,
+				Period: period,
+				Value:  record.Record.Amount,
+				Record: &record,
+			})
+		}
+		return alerts, nil
+	}
+	return nil, nil
+}
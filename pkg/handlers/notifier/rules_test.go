@@ -0,0 +1,197 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	alertAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+var rulesStart = time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+func TestAlertRulesCondition(t *testing.T) {
+	rule := alertAggregate.Rule{
+		Name:     "fuel",
+		Kind:     alertAggregate.TypeSpendingCap,
+		RefType:  "Structures",
+		Amount:   100,
+		Window:   24 * time.Hour,
+		Cooldown: 6 * time.Hour,
+	}
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		spending entity.Amount
+		want     []string
+	}{
+		{name: "under the cap", spending: 100},
+		{name: "over the cap", advance: time.Hour, spending: 101, want: []string{"rule/fuel"}},
+		{name: "within cooldown", advance: 5 * time.Hour, spending: 150},
+		{name: "after cooldown", advance: time.Hour, spending: 150, want: []string{"rule/fuel"}},
+		{name: "resolved", advance: time.Minute, spending: 50, want: []string{"resolved rule/fuel"}},
+		{name: "stays resolved", advance: time.Minute, spending: 50},
+		{name: "fires again without cooldown", advance: time.Minute, spending: 120, want: []string{"rule/fuel"}},
+	}
+
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock.Advance(test.advance)
+			accountantSvc.spending = test.spending
+			accountantSvc.periods = nil
+			notifier := &recordingNotifier{}
+
+			err := testHandler(accountantSvc, notifier, clock, rule).alertRules()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(notifier.titles) != fmt.Sprint(test.want) {
+				t.Errorf("expected messages %v, got %v", test.want, notifier.titles)
+			}
+			want := entity.NewPeriod(clock.now.Add(-rule.Window), clock.now)
+			if len(accountantSvc.periods) != 1 || !accountantSvc.periods[0].Start.Equal(want.Start) || !accountantSvc.periods[0].End.Equal(want.End) {
+				t.Errorf("expected window %v, got %v", want, accountantSvc.periods)
+			}
+		})
+	}
+}
+
+func TestAlertRulesAcknowledged(t *testing.T) {
+	rule := alertAggregate.Rule{Name: "loss", Kind: alertAggregate.NetLossLimit, Amount: 100, Window: time.Hour, Cooldown: time.Hour}
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	accountantSvc.loss = 500
+	notifier := &recordingNotifier{}
+	handler := testHandler(accountantSvc, notifier, clock, rule)
+
+	err := handler.alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = accountantSvc.AcknowledgeAlert(handler.ctx, ruleKey(rule), clock.now.Add(3*time.Hour), "director")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Snoozed past the cooldown, sent again when the snooze ends.
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Hour)
+		err = handler.alertRules()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fmt.Sprint(notifier.titles) != "[rule/loss rule/loss]" {
+		t.Errorf("expected alert before and after snooze, got %v", notifier.titles)
+	}
+}
+
+func TestAlertRulesBalanceFloor(t *testing.T) {
+	rule := alertAggregate.Rule{Name: "srp", Kind: alertAggregate.DivisionBalanceFloor, Division: "srp", Amount: 1000, Cooldown: time.Hour}
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	accountantSvc.walletBalance = map[entity.DivisionName]entity.Balance{"SRP": 999, "Main": 5000}
+	notifier := &recordingNotifier{}
+
+	err := testHandler(accountantSvc, notifier, clock, rule).alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(notifier.titles) != "[rule/srp]" {
+		t.Errorf("expected balance floor alert, got %v", notifier.titles)
+	}
+	if today := entity.DaysPeriod(clock.now, clock.now); len(accountantSvc.periods) != 1 || !accountantSvc.periods[0].Start.Equal(today.Start) {
+		t.Errorf("expected balance of today, got %v", accountantSvc.periods)
+	}
+}
+
+func TestAlertRulesLargeTransaction(t *testing.T) {
+	rule := alertAggregate.Rule{Name: "large", Kind: alertAggregate.LargeTransaction, Division: "main", Amount: 1000, Window: 48 * time.Hour}
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	record := func(id entity.Id, division entity.DivisionName) aggregate.WalletRecord {
+		return aggregate.WalletRecord{
+			Wallet: aggregate.Wallet{CorporationID: 98000001, Division: division},
+			Record: aggregate.JournalRecord{Id: id, Amount: -5000},
+		}
+	}
+	accountantSvc.large = []aggregate.WalletRecord{record(1, "Main"), record(2, "SRP")}
+	notifier := &recordingNotifier{}
+	handler := testHandler(accountantSvc, notifier, clock, rule)
+
+	err := handler.alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	accountantSvc.large = append(accountantSvc.large, record(3, "Main"))
+	err = handler.alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(notifier.titles) != "[rule/large/98000001/1 rule/large/98000001/3]" {
+		t.Errorf("expected every record of the division once, got %v", notifier.titles)
+	}
+}
+
+func TestAlertRulesNotSynchronized(t *testing.T) {
+	rule := alertAggregate.Rule{Name: "srp", Kind: alertAggregate.DivisionBalanceFloor, Division: "SRP", Amount: 1000}
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(time.Time{})
+	notifier := &recordingNotifier{}
+
+	err := testHandler(accountantSvc, notifier, clock, rule).alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.titles) > 0 || len(accountantSvc.periods) > 0 {
+		t.Errorf("expected no rules evaluated before synchronization, got %v", notifier.titles)
+	}
+}
+
+func TestAlertRulesRemoved(t *testing.T) {
+	var (
+		fuel = alertAggregate.Rule{Name: "fuel", Kind: alertAggregate.TypeSpendingCap, RefType: "Structures", Amount: 100, Window: time.Hour}
+		loss = alertAggregate.Rule{Name: "loss", Kind: alertAggregate.NetLossLimit, Amount: 100, Window: time.Hour, Cooldown: time.Hour}
+	)
+	clock := &fakeClock{now: rulesStart}
+	accountantSvc := newFakeAccountant(rulesStart)
+	accountantSvc.spending = 500
+	accountantSvc.loss = 500
+
+	err := testHandler(accountantSvc, &recordingNotifier{}, clock, fuel, loss).alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Anomaly alerts are not rules and stay firing.
+	err = accountantSvc.MarkAlertFiring(context.Background(), "anomaly/1", "anomaly", "", clock.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &recordingNotifier{}
+	err = testHandler(accountantSvc, notifier, clock, loss).alertRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	firing, err := accountantSvc.FiringAlerts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, state := range firing {
+		keys = append(keys, string(state.Key))
+	}
+	sort.Strings(keys)
+	if fmt.Sprint(keys) != "[anomaly/1 rule/loss]" {
+		t.Errorf("expected alert of removed rule cleared, firing %v", keys)
+	}
+	if len(notifier.titles) > 0 {
+		t.Errorf("expected no messages for removed rule, got %v", notifier.titles)
+	}
+}
@@ -0,0 +1,163 @@
+package notifier
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// scheduleSearchYears limits search of the next run, schedules like
+// `0 0 30 2 *` never run.
+const scheduleSearchYears = 5
+
+// scheduleShortcuts are schedules with names.
+var scheduleShortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 1",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Schedule is a cron expression with minute, hour, day of month, month and
+// day of week fields. Fields are `*`, numbers, ranges `1-5`, steps `*/15` or
+// `1-10/2` and lists of them separated by commas. Sunday is 0 or 7 and like
+// cron, when both days are restricted either of them matches.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are true for `*` day fields.
+	domAny, dowAny bool
+}
+
+// ParseSchedule parses cron expression or one of @hourly, @daily, @weekly
+// (Mondays), @monthly and @yearly.
+func ParseSchedule(expr string) (*Schedule, error) {
+	if shortcut, ok := scheduleShortcuts[strings.TrimSpace(expr)]; ok {
+		expr = shortcut
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("schedule %q must have 5 fields: minute hour day-of-month month day-of-week", expr)
+	}
+
+	var (
+		s   Schedule
+		err error
+	)
+	for _, field := range []struct {
+		name     string
+		expr     string
+		min, max int
+		bits     *uint64
+	}{
+		{"minute", fields[0], 0, 59, &s.minute},
+		{"hour", fields[1], 0, 23, &s.hour},
+		{"day of month", fields[2], 1, 31, &s.dom},
+		{"month", fields[3], 1, 12, &s.month},
+		{"day of week", fields[4], 0, 7, &s.dow},
+	} {
+		*field.bits, err = parseScheduleField(field.expr, field.min, field.max)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s of schedule %q", field.name, expr)
+		}
+	}
+	// Sunday is both 0 and 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return &s, nil
+}
+
+// parseScheduleField returns bits of values matching the field.
+func parseScheduleField(expr string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		var (
+			rangeExpr = part
+			step      = 1
+		)
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangeExpr = part[:i]
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step: %q", part)
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, errors.Errorf("invalid range: %q", part)
+			}
+		default:
+			value, err := strconv.Atoi(rangeExpr)
+			if err != nil {
+				return 0, errors.Errorf("invalid value: %q", part)
+			}
+			start, end = value, value
+			if strings.Contains(part, "/") {
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, errors.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first minute after given time matching the schedule, in
+// location of after. Zero time means schedule never runs.
+func (s *Schedule) Next(after time.Time) time.Time {
+	location := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(scheduleSearchYears, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case s.month&(1<<uint(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, location)
+		case !s.dayMatches(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, location)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	var (
+		dom = s.dom&(1<<uint(t.Day())) != 0
+		dow = s.dow&(1<<uint(t.Weekday())) != 0
+	)
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	alertAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	alertEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/lunemec/eve-accountant/pkg/notify"
+	"github.com/pkg/errors"
+)
+
+// resolvedMsgFunc returns message telling that condition of firing alert
+// no longer holds.
+type resolvedMsgFunc func(context.Context, alertAggregate.State) notify.Message
+
+// condition sends alert with the key while firing, at most once per interval
+// and not while it is acknowledged. Alert which stops firing is resolved.
+// State is persisted so restarts neither repeat nor forget alerts.
+func (n *notifierHandler) condition(ctx context.Context, kind notify.Kind, key alertEntity.Key, firing bool, interval time.Duration, message func() (notify.Message, error)) error {
+	state, err := n.accountantSvc.AlertState(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if !firing {
+		if state == nil || !state.Firing {
+			return nil
+		}
+		resolved := n.resolvedMsgFunc(ctx, *state)
+		resolved.Kind = kind
+		resolved.Channel = string(state.Channel)
+		err = n.notifier.Notify(ctx, resolved)
+		if err != nil {
+			return errors.Wrapf(err, "error sending resolved alert: %s", key)
+		}
+		return n.accountantSvc.ClearAlert(ctx, key)
+	}
+
+	now := n.now()
+	if state != nil && state.Firing && (state.Snoozed(now) || now.Before(state.SentAt.Add(interval))) {
+		return nil
+	}
+	msg, err := message()
+	if err != nil {
+		return errors.Wrapf(err, "error creating alert: %s", key)
+	}
+	msg.AlertKey = string(key)
+	err = n.notifier.Notify(ctx, msg)
+	if err != nil {
+		return errors.Wrapf(err, "error sending alert: %s", key)
+	}
+	return n.accountantSvc.MarkAlertFiring(ctx, key, msg.Title, alertEntity.ChannelID(msg.Channel), now)
+}
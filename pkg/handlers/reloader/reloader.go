@@ -0,0 +1,87 @@
+package reloader
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config is reloaded every time its file changes.
+type Config interface {
+	Path() string
+	Reload() error
+}
+
+type reloaderHandler struct {
+	ctx           context.Context
+	log           *zap.Logger
+	checkInterval time.Duration
+	config        Config
+
+	modTime time.Time
+}
+
+func New(
+	ctx context.Context,
+	log *zap.Logger,
+	checkInterval time.Duration,
+	config Config,
+) *reloaderHandler {
+	return &reloaderHandler{
+		ctx:           ctx,
+		log:           log,
+		checkInterval: checkInterval,
+		config:        config,
+	}
+}
+
+func (h *reloaderHandler) Start() {
+	if h.config.Path() == "" {
+		return
+	}
+	h.log.Info("Reloader handler started.", zap.String("path", h.config.Path()))
+	// Config was loaded at startup, remember its modification time.
+	h.modTime, _ = h.stat()
+
+	ticker := time.NewTicker(h.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.tick()
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// tick reloads the config when its file was modified, invalid config is
+// logged and the previous one is kept.
+func (h *reloaderHandler) tick() {
+	modTime, err := h.stat()
+	if err != nil {
+		h.log.Error("error checking config file", zap.String("path", h.config.Path()), zap.Error(err))
+		return
+	}
+	if modTime.Equal(h.modTime) {
+		return
+	}
+	h.modTime = modTime
+
+	err = h.config.Reload()
+	if err != nil {
+		h.log.Error("error reloading config, keeping previous", zap.String("path", h.config.Path()), zap.Error(err))
+		return
+	}
+	h.log.Info("Config reloaded.", zap.String("path", h.config.Path()))
+}
+
+func (h *reloaderHandler) stat() (time.Time, error) {
+	info, err := os.Stat(h.config.Path())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,65 @@
+package synchronizer
+
+import (
+	"context"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+
+	"go.uber.org/zap"
+)
+
+// Repository is synchronized with ESI every check interval.
+type Repository interface {
+	CorporationID() entity.CorporationID
+	Sync(ctx context.Context) error
+}
+
+type synchronizerHandler struct {
+	ctx           context.Context
+	log           *zap.Logger
+	checkInterval time.Duration
+	repositories  []Repository
+}
+
+func New(
+	ctx context.Context,
+	log *zap.Logger,
+	checkInterval time.Duration,
+	repositories ...Repository,
+) *synchronizerHandler {
+	return &synchronizerHandler{
+		ctx:           ctx,
+		log:           log,
+		checkInterval: checkInterval,
+		repositories:  repositories,
+	}
+}
+
+func (h *synchronizerHandler) Start() {
+	h.log.Info("Synchronizer handler started.")
+	// Tick 1x at startup.
+	h.tick()
+
+	ticker := time.NewTicker(h.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			h.tick()
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// tick synchronizes all corporations, failure of one corporation does not
+// prevent synchronization of the others.
+func (h *synchronizerHandler) tick() {
+	for _, repository := range h.repositories {
+		err := repository.Sync(h.ctx)
+		if err != nil {
+			h.log.Error("synchronization error", zap.Int32("corporation_id", int32(repository.CorporationID())), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package notify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	DiscordType = "discord"
+	WebhookType = "webhook"
+	SlackType   = "slack"
+	MatrixType  = "matrix"
+	SMTPType    = "smtp"
+
+	// defaultRoute is used for kinds without their own route.
+	defaultRoute = "default"
+)
+
+// Config is the format of the notifiers file, for example:
+//
+//	notifiers:
+//	  officers:
+//	    type: discord
+//	    channel: "123456789012345678"
+//	  mail:
+//	    type: smtp
+//	    addr: smtp.example.com:587
+//	    from: bot@example.com
+//	    to: [ceo@example.com]
+//	routes:
+//	  anomaly: [officers, mail]
+//	  default: [officers]
+//
+// JSON files use the same structure.
+type Config struct {
+	Notifiers map[string]NotifierConfig `yaml:"notifiers" json:"notifiers"`
+	// Routes list notifiers for each kind of notification or "default".
+	Routes map[string][]string `yaml:"routes" json:"routes"`
+	Retry  RetryConfig         `yaml:"retry" json:"retry"`
+}
+
+// NotifierConfig configures one notifier, fields used depend on the type.
+type NotifierConfig struct {
+	Type string `yaml:"type" json:"type"`
+	// Channel of discord, the default channel when empty.
+	Channel string `yaml:"channel" json:"channel"`
+	// URL of webhook and slack.
+	URL string `yaml:"url" json:"url"`
+	// Secret signs webhook requests.
+	Secret string `yaml:"secret" json:"secret"`
+	// Homeserver, Room ID and access Token of matrix.
+	Homeserver string `yaml:"homeserver" json:"homeserver"`
+	Room       string `yaml:"room" json:"room"`
+	Token      string `yaml:"token" json:"token"`
+	// Addr (host:port), optional login and addresses of smtp.
+	Addr     string   `yaml:"addr" json:"addr"`
+	Username string   `yaml:"username" json:"username"`
+	Password string   `yaml:"password" json:"password"`
+	From     string   `yaml:"from" json:"from"`
+	To       []string `yaml:"to" json:"to"`
+}
+
+// RetryConfig of every notifier.
+type RetryConfig struct {
+	Attempts int           `yaml:"attempts" json:"attempts"`
+	Backoff  time.Duration `yaml:"backoff" json:"backoff"`
+}
+
+// Load returns router configured by file at path, all notifications go to
+// the default Discord channel when path is empty.
+func Load(log *zap.Logger, path string, session *discordgo.Session, defaultChannelID string, client *http.Client) (*Router, error) {
+	config := Config{
+		Notifiers: map[string]NotifierConfig{
+			DiscordType: {Type: DiscordType},
+		},
+		Routes: map[string][]string{
+			defaultRoute: {DiscordType},
+		},
+	}
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading notifiers file: %s", path)
+		}
+		config, err = ParseConfig(data, filepath.Ext(path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error loading notifiers file: %s", path)
+		}
+	}
+
+	notifiers := make(map[string]Notifier, len(config.Notifiers))
+	for name, notifierConfig := range config.Notifiers {
+		var notifier Notifier
+		switch notifierConfig.Type {
+		case DiscordType:
+			channelID := notifierConfig.Channel
+			if channelID == "" {
+				channelID = defaultChannelID
+			}
+			notifier = NewDiscord(session, channelID)
+		case WebhookType:
+			notifier = NewWebhook(client, notifierConfig.URL, notifierConfig.Secret)
+		case SlackType:
+			notifier = NewSlack(client, notifierConfig.URL)
+		case MatrixType:
+			notifier = NewMatrix(client, notifierConfig.Homeserver, notifierConfig.Room, notifierConfig.Token)
+		case SMTPType:
+			notifier = NewSMTP(notifierConfig.Addr, notifierConfig.Username, notifierConfig.Password, notifierConfig.From, notifierConfig.To)
+		}
+		notifiers[name] = WithRetry(log, name, notifier, config.Retry.Attempts, config.Retry.Backoff)
+	}
+
+	var (
+		routes   = make(map[Kind][]Notifier)
+		fallback []Notifier
+	)
+	for route, names := range config.Routes {
+		routed := make([]Notifier, 0, len(names))
+		for _, name := range names {
+			routed = append(routed, notifiers[name])
+		}
+		if route == defaultRoute {
+			fallback = routed
+			continue
+		}
+		routes[Kind(route)] = routed
+	}
+	return NewRouter(log, routes, fallback...), nil
+}
+
+// ParseConfig parses and validates notifiers config, ext selects the format
+// (".json", ".yaml" or ".yml").
+func ParseConfig(data []byte, ext string) (Config, error) {
+	var config Config
+	switch strings.ToLower(ext) {
+	// JSON is a subset of YAML, strict YAML decoder rejects unknown fields and
+	// duplicate keys in both.
+	case ".json", ".yaml", ".yml":
+		err := yaml.UnmarshalStrict(data, &config)
+		if err != nil {
+			return config, errors.Wrap(err, "invalid notifiers")
+		}
+	default:
+		return config, errors.Errorf("unsupported notifiers format: %q, use .yaml, .yml or .json", ext)
+	}
+
+	return config, config.validate()
+}
+
+func (c Config) validate() error {
+	var problems []string
+	names := make([]string, 0, len(c.Notifiers))
+	for name := range c.Notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n := c.Notifiers[name]
+		missing := func(field, value string) {
+			if strings.TrimSpace(value) == "" {
+				problems = append(problems, fmt.Sprintf("notifier %q has no %s", name, field))
+			}
+		}
+		switch n.Type {
+		case DiscordType:
+		case WebhookType, SlackType:
+			missing("url", n.URL)
+		case MatrixType:
+			missing("homeserver", n.Homeserver)
+			missing("room", n.Room)
+			missing("token", n.Token)
+		case SMTPType:
+			missing("addr", n.Addr)
+			missing("from", n.From)
+			if len(n.To) == 0 {
+				problems = append(problems, fmt.Sprintf("notifier %q has no to", name))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("notifier %q has unknown type %q", name, n.Type))
+		}
+	}
+
+	kinds := map[string]struct{}{defaultRoute: {}}
+	for _, kind := range Kinds {
+		kinds[string(kind)] = struct{}{}
+	}
+	routes := make([]string, 0, len(c.Routes))
+	for route := range c.Routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		if _, ok := kinds[route]; !ok {
+			problems = append(problems, fmt.Sprintf("route %q is not a kind of notification", route))
+		}
+		for _, name := range c.Routes[route] {
+			if _, ok := c.Notifiers[name]; !ok {
+				problems = append(problems, fmt.Sprintf("route %q uses unknown notifier %q", route, name))
+			}
+		}
+	}
+	if _, ok := c.Routes[defaultRoute]; !ok {
+		problems = append(problems, "no default route")
+	}
+	if c.Retry.Attempts < 0 || c.Retry.Backoff < 0 {
+		problems = append(problems, "retry must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("invalid notifiers: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
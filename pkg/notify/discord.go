@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Custom ID prefixes of buttons acknowledging alert, followed by the key.
+	AckButtonPrefix    = "alert_ack:"
+	SnoozeButtonPrefix = "alert_snooze:"
+	// SnoozeDuration is how long snooze button silences alert.
+	SnoozeDuration = 24 * time.Hour
+
+	// Discord limits length of button custom IDs.
+	maxCustomIDLength = 100
+
+	// Name of image attached to messages.
+	imageFileName = "chart.png"
+)
+
+// discordNotifier posts messages as embeds to a Discord channel.
+type discordNotifier struct {
+	session   *discordgo.Session
+	channelID string
+}
+
+// NewDiscord returns notifier posting to channelID, or to channel of the
+// message when it has one.
+func NewDiscord(session *discordgo.Session, channelID string) *discordNotifier {
+	return &discordNotifier{
+		session:   session,
+		channelID: channelID,
+	}
+}
+
+func (n *discordNotifier) Notify(ctx context.Context, message Message) error {
+	channelID := n.channelID
+	if message.Channel != "" {
+		channelID = message.Channel
+	}
+
+	send := &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{Embed(message)},
+		Components: ackButtons(message.AlertKey),
+	}
+	if message.Image != nil {
+		send.Files = []*discordgo.File{
+			{
+				Name:        imageFileName,
+				ContentType: "image/png",
+				Reader:      bytes.NewReader(message.Image),
+			},
+		}
+		send.Embeds[0].Image = &discordgo.MessageEmbedImage{URL: "attachment://" + imageFileName}
+	}
+	_, err := n.session.ChannelMessageSendComplex(channelID, send, discordgo.WithContext(ctx))
+	if err != nil {
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Response != nil {
+			err = statusError(restErr.Response.StatusCode, err)
+		}
+		return errors.Wrapf(err, "error sending message to discord channel: %s", channelID)
+	}
+	return nil
+}
+
+// ackButtons returns buttons acknowledging alert with the key, none for
+// messages which are not alerts or keys too long for Discord.
+func ackButtons(key string) []discordgo.MessageComponent {
+	if key == "" || len(SnoozeButtonPrefix+key) > maxCustomIDLength {
+		return nil
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Acknowledge",
+					Style:    discordgo.PrimaryButton,
+					CustomID: AckButtonPrefix + key,
+				},
+				discordgo.Button{
+					Label:    "Snooze 1 day",
+					Style:    discordgo.SecondaryButton,
+					CustomID: SnoozeButtonPrefix + key,
+				},
+			},
+		},
+	}
+}
+
+// Embed returns message as Discord embed.
+func Embed(message Message) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       message.Title,
+		Description: message.Description,
+		Color:       message.Color,
+	}
+	for _, field := range message.Fields {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   field.Name,
+			Value:  field.Value,
+			Inline: field.Inline,
+		})
+	}
+	if message.ImageURL != "" {
+		embed.Image = &discordgo.MessageEmbedImage{URL: message.ImageURL}
+	}
+	if message.Footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: message.Footer}
+	}
+	if !message.Timestamp.IsZero() {
+		embed.Timestamp = message.Timestamp.Format(time.RFC3339)
+	}
+	return embed
+}
+
+// FromEmbed returns Discord embed as message of kind.
+func FromEmbed(kind Kind, embed *discordgo.MessageEmbed) Message {
+	message := Message{
+		Kind:        kind,
+		Title:       embed.Title,
+		Description: embed.Description,
+		Color:       embed.Color,
+	}
+	for _, field := range embed.Fields {
+		message.Fields = append(message.Fields, Field{
+			Name:   field.Name,
+			Value:  field.Value,
+			Inline: field.Inline,
+		})
+	}
+	if embed.Image != nil {
+		message.ImageURL = embed.Image.URL
+	}
+	if embed.Footer != nil {
+		message.Footer = embed.Footer.Text
+	}
+	if timestamp, err := time.Parse(time.RFC3339, embed.Timestamp); err == nil {
+		message.Timestamp = timestamp
+	}
+	return message
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// matrixNotifier sends messages to a Matrix room with client-server API.
+type matrixNotifier struct {
+	client     *http.Client
+	homeserver string
+	roomID     string
+	token      string
+
+	// session and txn make transaction IDs unique, homeserver ignores
+	// requests repeating transaction ID it has already seen.
+	session int64
+	txn     int64
+}
+
+// NewMatrix returns notifier sending to roomID on homeserver (e.g.
+// https://matrix.org) as user of access token, the user must have joined the
+// room.
+func NewMatrix(client *http.Client, homeserver, roomID, token string) *matrixNotifier {
+	return &matrixNotifier{
+		client:     client,
+		homeserver: strings.TrimSuffix(homeserver, "/"),
+		roomID:     roomID,
+		token:      token,
+		session:    time.Now().UnixNano(),
+	}
+}
+
+type matrixPayload struct {
+	MsgType string `json:"msgtype"`
+	Body    string `json:"body"`
+}
+
+func (n *matrixNotifier) Notify(ctx context.Context, message Message) error {
+	body, err := json.Marshal(matrixPayload{MsgType: "m.notice", Body: message.Text()})
+	if err != nil {
+		return Permanent(errors.Wrap(err, "error encoding matrix payload"))
+	}
+	endpoint := fmt.Sprintf(
+		"%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		n.homeserver,
+		url.PathEscape(n.roomID),
+		n.txnID(),
+	)
+	err = sendJSON(ctx, n.client, http.MethodPut, endpoint, body, map[string]string{"Authorization": "Bearer " + n.token})
+	if err != nil {
+		return errors.Wrap(err, "error sending matrix message")
+	}
+	return nil
+}
+
+// txnID returns transaction ID unique for each sent message, alerts repeated
+// with the same text would be dropped by homeserver with ID derived from the
+// content.
+func (n *matrixNotifier) txnID() string {
+	return fmt.Sprintf("%d.%d", n.session, atomic.AddInt64(&n.txn, 1))
+}
@@ -0,0 +1,120 @@
+// Package notify delivers notifications to Discord channels, HTTP webhooks,
+// Slack, Matrix and email, routed by kind of the notification.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Kind of notification, notifications are routed to notifiers by kind.
+type Kind string
+
+const (
+	MonthlyBalanceKind Kind = "monthly_balance"
+	ForecastKind       Kind = "forecast"
+	AnomalyKind        Kind = "anomaly"
+	RuleKind           Kind = "rule"
+	ReportKind         Kind = "report"
+)
+
+// Kinds lists all kinds of notifications.
+var Kinds = []Kind{MonthlyBalanceKind, ForecastKind, AnomalyKind, RuleKind, ReportKind}
+
+// Field is a named value shown below the description.
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// Message is a notification independent of where it is delivered.
+// Description may contain Discord markdown, which Slack and Matrix show
+// similarly and email as plain text.
+type Message struct {
+	Kind        Kind    `json:"kind"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Fields      []Field `json:"fields,omitempty"`
+	// Color of Discord embed as 0xRRGGBB.
+	Color  int    `json:"color"`
+	Footer string `json:"footer,omitempty"`
+	// ImageURL of an image shown below the description, Discord notifiers
+	// attach Image instead when it is set.
+	ImageURL string `json:"image_url,omitempty"`
+	// Image is PNG of a chart, base64 encoded in JSON.
+	Image []byte `json:"image,omitempty"`
+	// Timestamp shown in the footer, usually time of the data.
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	// Channel overrides Discord channel of Discord notifiers, others ignore it.
+	Channel string `json:"channel,omitempty"`
+	// AlertKey identifies alert which can be acknowledged with `!isk ack`,
+	// Discord notifiers add acknowledge buttons.
+	AlertKey string `json:"alert_key,omitempty"`
+}
+
+// Text returns the message as plain text.
+func (m Message) Text() string {
+	var out strings.Builder
+	out.WriteString(m.Title)
+	if m.Description != "" {
+		out.WriteString("\n\n")
+		out.WriteString(m.Description)
+	}
+	if len(m.Fields) > 0 {
+		out.WriteString("\n")
+	}
+	for _, field := range m.Fields {
+		out.WriteString(fmt.Sprintf("\n%s: %s", field.Name, field.Value))
+	}
+	if m.ImageURL != "" {
+		out.WriteString(fmt.Sprintf("\n\n%s", m.ImageURL))
+	}
+	if m.AlertKey != "" {
+		out.WriteString(fmt.Sprintf("\n\nAcknowledge with: !isk ack %s", m.AlertKey))
+	}
+	if m.Footer != "" {
+		out.WriteString(fmt.Sprintf("\n\n%s", m.Footer))
+		if !m.Timestamp.IsZero() {
+			out.WriteString(fmt.Sprintf(" %s", m.Timestamp.Format(time.RFC3339)))
+		}
+	}
+	return out.String()
+}
+
+// Notifier delivers messages to one destination.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// permanentError is not retried, e.g. rejected credentials or payload.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err or any error it wraps was marked with
+// Permanent.
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 1 * time.Second
+	maxRetryBackoff      = 1 * time.Minute
+)
+
+// retryNotifier retries failed deliveries with exponential backoff, errors
+// marked with Permanent are returned right away.
+type retryNotifier struct {
+	log      *zap.Logger
+	name     string
+	next     Notifier
+	attempts int
+	backoff  time.Duration
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// WithRetry returns notifier trying next at most attempts times, waiting
+// backoff before the first retry and twice as long before each next one.
+func WithRetry(log *zap.Logger, name string, next Notifier, attempts int, backoff time.Duration) *retryNotifier {
+	if attempts < 1 {
+		attempts = defaultRetryAttempts
+	}
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	return &retryNotifier{
+		log:      log,
+		name:     name,
+		next:     next,
+		attempts: attempts,
+		backoff:  backoff,
+		sleep:    sleep,
+	}
+}
+
+func (n *retryNotifier) Notify(ctx context.Context, message Message) error {
+	for attempt := 0; ; attempt++ {
+		err := n.next.Notify(ctx, message)
+		if err == nil {
+			return nil
+		}
+		if attempt+1 >= n.attempts || IsPermanent(err) || ctx.Err() != nil {
+			return errors.Wrapf(err, "error notifying %s after %d attempts", n.name, attempt+1)
+		}
+
+		delay := n.delay(attempt)
+		n.log.Warn("retrying notification",
+			zap.String("notifier", n.name),
+			zap.String("kind", string(message.Kind)),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+		err = n.sleep(ctx, delay)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// delay returns exponential delay for given attempt with random jitter in the
+// upper half.
+func (n *retryNotifier) delay(attempt int) time.Duration {
+	delay := n.backoff << uint(attempt)
+	if delay > maxRetryBackoff || delay <= 0 {
+		delay = maxRetryBackoff
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
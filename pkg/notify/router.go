@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Router sends each message to notifiers routed for its kind, or to fallback
+// notifiers when the kind has no route.
+type Router struct {
+	log      *zap.Logger
+	routes   map[Kind][]Notifier
+	fallback []Notifier
+}
+
+func NewRouter(log *zap.Logger, routes map[Kind][]Notifier, fallback ...Notifier) *Router {
+	return &Router{
+		log:      log,
+		routes:   routes,
+		fallback: fallback,
+	}
+}
+
+// Notify delivers message to all its notifiers concurrently, so a slow
+// notifier does not use up the deadline of ctx for the others, and failure of
+// one does not stop the others. Failures are only logged when at least one
+// notifier delivered the message, callers record it as sent and retrying would
+// send it again to notifiers which already delivered it. Error is returned
+// when all failed.
+func (r *Router) Notify(ctx context.Context, message Message) error {
+	notifiers, ok := r.routes[message.Kind]
+	if !ok {
+		notifiers = r.fallback
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(notifiers))
+	)
+	for i, notifier := range notifiers {
+		wg.Add(1)
+		go func(i int, notifier Notifier) {
+			defer wg.Done()
+			errs[i] = notifier.Notify(ctx, message)
+		}(i, notifier)
+	}
+	wg.Wait()
+
+	var (
+		delivered bool
+		msgs      []string
+	)
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+			r.log.Error("error delivering notification",
+				zap.String("kind", string(message.Kind)),
+				zap.String("title", message.Title),
+				zap.Bool("permanent", IsPermanent(err)),
+				zap.Error(err),
+			)
+			continue
+		}
+		delivered = true
+	}
+	if !delivered && len(msgs) > 0 {
+		return errors.Errorf("error delivering %s notification: %s", message.Kind, strings.Join(msgs, "; "))
+	}
+	return nil
+}
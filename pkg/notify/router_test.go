@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// countingNotifier counts deliveries and fails them with err.
+type countingNotifier struct {
+	delivered int
+	err       error
+}
+
+func (n *countingNotifier) Notify(ctx context.Context, message Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.delivered++
+	return nil
+}
+
+func TestRouterRoutes(t *testing.T) {
+	var (
+		anomaly  = &countingNotifier{}
+		fallback = &countingNotifier{}
+		router   = NewRouter(zap.NewNop(), map[Kind][]Notifier{AnomalyKind: {anomaly}}, fallback)
+	)
+	for _, kind := range []Kind{AnomalyKind, ReportKind, ForecastKind} {
+		err := router.Notify(context.Background(), Message{Kind: kind})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if anomaly.delivered != 1 || fallback.delivered != 2 {
+		t.Errorf("expected 1 anomaly and 2 fallback deliveries, got %d and %d", anomaly.delivered, fallback.delivered)
+	}
+}
+
+func TestRouterPartialFailure(t *testing.T) {
+	var (
+		ok     = &countingNotifier{}
+		failed = &countingNotifier{err: Permanent(errors.New("forbidden"))}
+	)
+	err := NewRouter(zap.NewNop(), nil, failed, ok).Notify(context.Background(), Message{Kind: RuleKind})
+	if err != nil {
+		t.Errorf("expected delivery by one notifier to succeed, got %v", err)
+	}
+	if ok.delivered != 1 {
+		t.Errorf("expected delivery after failed notifier, got %d", ok.delivered)
+	}
+
+	err = NewRouter(zap.NewNop(), nil, failed, failed).Notify(context.Background(), Message{Kind: RuleKind})
+	if err == nil {
+		t.Error("expected error when all notifiers failed")
+	}
+}
+
+// blockingNotifier waits for ctx to be done.
+type blockingNotifier struct{}
+
+func (n blockingNotifier) Notify(ctx context.Context, message Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRouterSlowNotifier(t *testing.T) {
+	ok := &countingNotifier{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := NewRouter(zap.NewNop(), nil, blockingNotifier{}, ok).Notify(ctx, Message{Kind: RuleKind})
+	if err != nil {
+		t.Errorf("expected delivery by one notifier to succeed, got %v", err)
+	}
+	if ok.delivered != 1 {
+		t.Errorf("expected delivery not blocked by slow notifier, got %d", ok.delivered)
+	}
+}
+
+func TestMatrixTransactionID(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+	}))
+	defer server.Close()
+
+	notifier := NewMatrix(server.Client(), server.URL, "!room:example.org", "token")
+	message := Message{Kind: AnomalyKind, Title: "test", Timestamp: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for i := 0; i < 2; i++ {
+		err := notifier.Notify(context.Background(), message)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(paths) != 2 || paths[0] == paths[1] {
+		t.Errorf("expected distinct transaction IDs for repeated message, got %v", paths)
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantRequests int32
+		wantErr      bool
+		wantPerm     bool
+	}{
+		{name: "delivered", statuses: []int{http.StatusOK}, wantRequests: 1},
+		{name: "retried", statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests, http.StatusNoContent}, wantRequests: 3},
+		{name: "attempts exhausted", statuses: []int{http.StatusServiceUnavailable}, wantRequests: 3, wantErr: true},
+		{name: "permanent", statuses: []int{http.StatusUnauthorized}, wantRequests: 1, wantErr: true, wantPerm: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := int(atomic.AddInt32(&requests, 1)) - 1
+				if i >= len(test.statuses) {
+					i = len(test.statuses) - 1
+				}
+				w.WriteHeader(test.statuses[i])
+			}))
+			defer server.Close()
+
+			var slept []time.Duration
+			notifier := WithRetry(zap.NewNop(), "webhook", NewWebhook(server.Client(), server.URL, "secret"), 3, time.Second)
+			notifier.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			err := notifier.Notify(context.Background(), Message{Kind: AnomalyKind, Title: "test"})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %v, got %v", test.wantErr, err)
+			}
+			if IsPermanent(err) != test.wantPerm {
+				t.Errorf("expected permanent %v, got %v", test.wantPerm, err)
+			}
+			if requests != test.wantRequests {
+				t.Errorf("expected %d requests, got %d", test.wantRequests, requests)
+			}
+			if len(slept) != int(test.wantRequests)-1 {
+				t.Errorf("expected backoff before each retry, got %v", slept)
+			}
+			for i, d := range slept {
+				max := time.Second << uint(i)
+				if d < max/2 || d > max {
+					t.Errorf("expected backoff %d between %s and %s, got %s", i, max/2, max, d)
+				}
+			}
+		})
+	}
+}
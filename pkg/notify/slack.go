@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// slackNotifier posts messages to Slack-compatible incoming webhooks, which
+// Mattermost and Rocket.Chat accept as well.
+type slackNotifier struct {
+	client *http.Client
+	url    string
+}
+
+func NewSlack(client *http.Client, url string) *slackNotifier {
+	return &slackNotifier{
+		client: client,
+		url:    url,
+	}
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func (n *slackNotifier) Notify(ctx context.Context, message Message) error {
+	body, err := json.Marshal(slackPayload{Text: message.Text()})
+	if err != nil {
+		return Permanent(errors.Wrap(err, "error encoding slack payload"))
+	}
+	err = sendJSON(ctx, n.client, http.MethodPost, n.url, body, nil)
+	if err != nil {
+		return errors.Wrap(err, "error calling slack webhook")
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// smtpTimeout limits connecting to the server and the whole delivery, a
+// stalled server would block all other notifications otherwise.
+const smtpTimeout = 30 * time.Second
+
+// smtpNotifier emails messages as plain text.
+type smtpNotifier struct {
+	addr     string
+	username string
+	password string
+	from     string
+	to       []string
+	timeout  time.Duration
+}
+
+// NewSMTP returns notifier sending mail through server at addr (host:port),
+// STARTTLS is used when the server supports it and login is required for
+// authentication.
+func NewSMTP(addr, username, password, from string, to []string) *smtpNotifier {
+	return &smtpNotifier{
+		addr:     addr,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+		timeout:  smtpTimeout,
+	}
+}
+
+func (n *smtpNotifier) Notify(ctx context.Context, message Message) error {
+	host, _, err := net.SplitHostPort(n.addr)
+	if err != nil {
+		return Permanent(errors.Wrapf(err, "invalid smtp address: %s", n.addr))
+	}
+
+	err = n.send(ctx, host, n.mail(message))
+	if err != nil {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+			err = Permanent(err)
+		}
+		return errors.Wrap(err, "error sending email")
+	}
+	return nil
+}
+
+// send delivers mail like smtp.SendMail, but with the connection limited by
+// the timeout and the context deadline.
+func (n *smtpNotifier) send(ctx context.Context, host string, mail []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	dialer := net.Dialer{Timeout: n.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return errors.Wrap(err, "error connecting")
+	}
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "error setting deadline")
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if n.username != "" {
+		err = client.Auth(smtp.PlainAuth("", n.username, n.password, host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(n.from)
+	if err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		err = client.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(mail)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// mail returns RFC 5322 message with UTF-8 plain text body.
+func (n *smtpNotifier) mail(message Message) []byte {
+	var out strings.Builder
+	headers := [][2]string{
+		{"From", n.from},
+		{"To", strings.Join(n.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Title)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	}
+	for _, header := range headers {
+		out.WriteString(fmt.Sprintf("%s: %s\r\n", header[0], header[1]))
+	}
+	out.WriteString("\r\n")
+	out.WriteString(strings.ReplaceAll(message.Text(), "\n", "\r\n"))
+	out.WriteString("\r\n")
+	return []byte(out.String())
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpServer is an SMTP stand-in answering rcptCode to RCPT and keeping the
+// received mail, hang makes it accept connections and never answer.
+type smtpServer struct {
+	listener net.Listener
+	rcptCode int
+	hang     bool
+	mails    chan string
+}
+
+func newSMTPServer(t *testing.T, rcptCode int, hang bool) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: listener, rcptCode: rcptCode, hang: hang, mails: make(chan string, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	if s.hang {
+		_, _ = bufio.NewReader(conn).ReadString('\n')
+		return
+	}
+	text := textproto.NewConn(conn)
+	reply := func(code int, msg string) { _ = text.PrintfLine("%d %s", code, msg) }
+	reply(220, "localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+		case "EHLO", "HELO":
+			reply(250, "localhost")
+		case "MAIL":
+			reply(250, "OK")
+		case "RCPT":
+			reply(s.rcptCode, fmt.Sprintf("recipient %d", s.rcptCode))
+		case "DATA":
+			reply(354, "go ahead")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			s.mails <- strings.Join(lines, "\n")
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "not implemented")
+		}
+	}
+}
+
+func TestSMTPNotify(t *testing.T) {
+	server := newSMTPServer(t, 250, false)
+	notifier := NewSMTP(server.listener.Addr().String(), "", "", "bot@example.com", []string{"ceo@example.com"})
+
+	err := notifier.Notify(context.Background(), Message{Title: "Large withdrawal", Description: "100 ISK"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mail := <-server.mails
+	for _, want := range []string{"From: bot@example.com", "To: ceo@example.com", "Subject: Large withdrawal", "Large withdrawal\n\n100 ISK"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("expected mail to contain %q, got:\n%s", want, mail)
+		}
+	}
+}
+
+func TestSMTPNotifyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		rcptCode int
+		hang     bool
+		wantPerm bool
+	}{
+		{name: "rejected recipient", rcptCode: 550, wantPerm: true},
+		{name: "mailbox busy", rcptCode: 450},
+		{name: "stalled server", hang: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newSMTPServer(t, test.rcptCode, test.hang)
+			notifier := NewSMTP(server.listener.Addr().String(), "", "", "bot@example.com", []string{"ceo@example.com"})
+			notifier.timeout = 100 * time.Millisecond
+
+			start := time.Now()
+			err := notifier.Notify(context.Background(), Message{Title: "test"})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if IsPermanent(err) != test.wantPerm {
+				t.Errorf("expected permanent %v, got %v", test.wantPerm, err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("expected delivery to give up after timeout, took %s", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// SignatureHeader carries hex HMAC-SHA256 of the request body prefixed with
+// "sha256=", computed with the webhook secret.
+const SignatureHeader = "X-Signature-256"
+
+// webhookNotifier posts messages as JSON to an URL.
+type webhookNotifier struct {
+	client *http.Client
+	url    string
+	secret []byte
+}
+
+// NewWebhook returns notifier posting Message JSON to url, requests are signed
+// when secret is not empty.
+func NewWebhook(client *http.Client, url string, secret string) *webhookNotifier {
+	return &webhookNotifier{
+		client: client,
+		url:    url,
+		secret: []byte(secret),
+	}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return Permanent(errors.Wrap(err, "error encoding webhook payload"))
+	}
+	headers := make(map[string]string)
+	if len(n.secret) > 0 {
+		headers[SignatureHeader] = Sign(n.secret, body)
+	}
+	err = sendJSON(ctx, n.client, http.MethodPost, n.url, body, headers)
+	if err != nil {
+		return errors.Wrap(err, "error calling webhook")
+	}
+	return nil
+}
+
+// Sign returns value of SignatureHeader for body, receivers should compare it
+// with hmac.Equal.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
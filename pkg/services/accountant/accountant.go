@@ -2,64 +2,324 @@ package accountant
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"github.com/lunemec/eve-accountant/pkg/domain/alert"
+	alertAggregate "github.com/lunemec/eve-accountant/pkg/domain/alert/aggregate"
+	alertEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
 	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/buyback"
+	buybackAggregate "github.com/lunemec/eve-accountant/pkg/domain/buyback/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/names"
+	namesAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/report"
+	reportEntity "github.com/lunemec/eve-accountant/pkg/domain/report/entity"
+	"github.com/lunemec/eve-accountant/pkg/export"
 	"github.com/pkg/errors"
 )
 
 type Service interface {
-	Balance(ctx context.Context, from, to time.Time) (*aggregate.Balance, error)
-	BalanceByDivision(ctx context.Context, from, to time.Time) (*aggregate.BalanceByDivision, error)
-	BalanceByType(ctx context.Context, from, to time.Time) (*aggregate.BalanceByType, error)
-	BalanceByDayByDivisionByType(ctx context.Context, from, to time.Time) ([]*aggregate.BalanceByDivisionByType, error)
+	Balance(ctx context.Context, period entity.Period) (*aggregate.Balance, error)
+	BalanceByDivision(ctx context.Context, period entity.Period) (*aggregate.BalanceByDivision, error)
+	BalanceByType(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error)
+	BalanceByParty(ctx context.Context, period entity.Period) (*aggregate.BalanceByParty, error)
+	TaxLedger(ctx context.Context, period entity.Period) (*aggregate.TaxLedger, error)
+	Compare(ctx context.Context, period entity.Period) (*aggregate.Comparison, error)
+	BalanceByDayByDivisionByType(ctx context.Context, period entity.Period) ([]*aggregate.BalanceByDivisionByType, error)
+	WalletBalanceByDay(ctx context.Context, period entity.Period) ([]*aggregate.WalletBalanceByDay, error)
+	MarketByItem(ctx context.Context, period entity.Period) (aggregate.MarketByItem, error)
+	UnmappedTypes(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error)
+	Buyback(ctx context.Context, period entity.Period) (*buybackAggregate.Buyback, error)
+	Names(ctx context.Context, ids []namesEntity.ID) (map[namesEntity.ID]namesAggregate.Name, error)
+	DataAsOf(ctx context.Context) (time.Time, error)
 	MonthlyBalanceBelowThreshold(ctx context.Context) (bool, aggregate.MonthlyBalanceNotification, error)
+	Forecast(ctx context.Context) (*aggregate.Forecast, error)
+	Anomalies(ctx context.Context) ([]aggregate.Anomaly, error)
+	AnomalyAlerted(ctx context.Context, anomaly aggregate.Anomaly) error
+	LargeTransactions(ctx context.Context, period entity.Period, minAmount entity.Amount) ([]aggregate.WalletRecord, error)
+	Spending(ctx context.Context, period entity.Period, refType entity.RefType) (entity.Amount, error)
+	SearchJournal(ctx context.Context, query aggregate.JournalQuery) ([]aggregate.WalletRecord, map[namesEntity.ID]namesAggregate.Name, error)
+	Export(ctx context.Context, report export.Report, period entity.Period) (*export.Table, error)
+	AlertState(ctx context.Context, key alertEntity.Key) (*alertAggregate.State, error)
+	FiringAlerts(ctx context.Context) ([]alertAggregate.State, error)
+	MarkAlertSent(ctx context.Context, key alertEntity.Key, at time.Time) error
+	MarkAlertFiring(ctx context.Context, key alertEntity.Key, title string, channel alertEntity.ChannelID, at time.Time) error
+	ClearAlert(ctx context.Context, key alertEntity.Key) error
+	AcknowledgeAlert(ctx context.Context, key alertEntity.Key, until time.Time, by string) error
+	ReportLastRun(ctx context.Context, name string) (time.Time, error)
+	MarkReportRun(ctx context.Context, name string, at time.Time) error
 }
 
 type accountantService struct {
 	balanceSvc              balance.Service
+	buybackSvc              buyback.Service
+	namesSvc                names.Service
+	alertSvc                alert.Service
+	reportSvc               report.Service
 	monthlyBalanceThreshold entity.Amount
+	location                *time.Location
 }
 
-func New(balanceSvc balance.Service, monthlyBalanceThreshold entity.Amount) *accountantService {
+// New returns accountant service, months of notifications are calendar months
+// in location.
+func New(balanceSvc balance.Service, buybackSvc buyback.Service, namesSvc names.Service, alertSvc alert.Service, reportSvc report.Service, monthlyBalanceThreshold entity.Amount, location *time.Location) *accountantService {
 	return &accountantService{
 		balanceSvc:              balanceSvc,
+		buybackSvc:              buybackSvc,
+		namesSvc:                namesSvc,
+		alertSvc:                alertSvc,
+		reportSvc:               reportSvc,
 		monthlyBalanceThreshold: monthlyBalanceThreshold,
+		location:                location,
 	}
 }
 
-func (s *accountantService) Balance(ctx context.Context, from, to time.Time) (*aggregate.Balance, error) {
-	return s.balanceSvc.Balance(ctx, from, to)
+func (s *accountantService) Balance(ctx context.Context, period entity.Period) (*aggregate.Balance, error) {
+	return s.balanceSvc.Balance(ctx, period)
 }
 
-func (s *accountantService) BalanceByDayByDivisionByType(ctx context.Context, from, to time.Time) ([]*aggregate.BalanceByDivisionByType, error) {
-	return s.balanceSvc.BalanceByDayByDivisionByType(ctx, from, to)
+func (s *accountantService) BalanceByDayByDivisionByType(ctx context.Context, period entity.Period) ([]*aggregate.BalanceByDivisionByType, error) {
+	return s.balanceSvc.BalanceByDayByDivisionByType(ctx, period)
 }
 
-func (s *accountantService) BalanceByDivision(ctx context.Context, from, to time.Time) (*aggregate.BalanceByDivision, error) {
-	return s.balanceSvc.BalanceByDivision(ctx, from, to)
+func (s *accountantService) WalletBalanceByDay(ctx context.Context, period entity.Period) ([]*aggregate.WalletBalanceByDay, error) {
+	return s.balanceSvc.WalletBalanceByDay(ctx, period)
 }
 
-func (s *accountantService) BalanceByType(ctx context.Context, from, to time.Time) (*aggregate.BalanceByType, error) {
-	return s.balanceSvc.BalanceByType(ctx, from, to)
+func (s *accountantService) BalanceByDivision(ctx context.Context, period entity.Period) (*aggregate.BalanceByDivision, error) {
+	return s.balanceSvc.BalanceByDivision(ctx, period)
 }
 
-func (s *accountantService) MonthlyBalanceBelowThreshold(ctx context.Context) (bool, aggregate.MonthlyBalanceNotification, error) {
-	now := time.Now()
-	currentYear, currentMonth, _ := now.Date()
+func (s *accountantService) BalanceByType(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error) {
+	return s.balanceSvc.BalanceByType(ctx, period)
+}
+
+// BalanceByParty sums journal by members, parties which are not characters,
+// such as other corporations, are left out.
+func (s *accountantService) BalanceByParty(ctx context.Context, period entity.Period) (*aggregate.BalanceByParty, error) {
+	balance, err := s.balanceSvc.BalanceByParty(ctx, period)
+	if balance == nil {
+		return nil, err
+	}
+	ids := make([]namesEntity.ID, 0, len(balance.IncomeByParty)+len(balance.ExpensesByParty))
+	for party := range balance.IncomeByParty {
+		ids = append(ids, namesEntity.ID(party))
+	}
+	for party := range balance.ExpensesByParty {
+		ids = append(ids, namesEntity.ID(party))
+	}
+	names, namesErr := s.namesSvc.Names(ctx, ids)
+	if namesErr != nil {
+		return nil, errors.Wrap(namesErr, "error resolving members")
+	}
+
+	members := aggregate.NewBalanceByParty()
+	for party, amount := range balance.IncomeByParty {
+		if names[namesEntity.ID(party)].IsCharacter() {
+			members.IncomeByParty[party] = amount
+		}
+	}
+	for party, amount := range balance.ExpensesByParty {
+		if names[namesEntity.ID(party)].IsCharacter() {
+			members.ExpensesByParty[party] = amount
+		}
+	}
+	return members, err
+}
+
+func (s *accountantService) TaxLedger(ctx context.Context, period entity.Period) (*aggregate.TaxLedger, error) {
+	return s.balanceSvc.TaxLedger(ctx, period)
+}
+
+// Compare returns balance of the period, of the previous period of the same
+// length and of the same period a year ago. Only the elapsed part of periods
+// reaching into the future is compared, with the same part of the others.
+func (s *accountantService) Compare(ctx context.Context, period entity.Period) (*aggregate.Comparison, error) {
+	current := period.Until(time.Now())
+	elapsed := current.End.Sub(current.Start)
+	previous := period.Previous()
+	yearAgo := period.YearAgo()
+
+	var (
+		partialErr error
+		periods    = []entity.Period{current, previous.Until(previous.Start.Add(elapsed)), yearAgo.Until(yearAgo.Start.Add(elapsed))}
+		summaries  = make([]*aggregate.BalanceSummary, len(periods))
+	)
+	for i, p := range periods {
+		summary, err := s.balanceSvc.Summary(ctx, p)
+		if err != nil {
+			if !balance.IsPartial(err) {
+				return nil, errors.Wrapf(err, "error loading balance for: %s", p)
+			}
+			partialErr = err
+		}
+		summaries[i] = summary
+	}
+	return &aggregate.Comparison{
+		Current:  summaries[0],
+		Previous: summaries[1],
+		YearAgo:  summaries[2],
+	}, partialErr
+}
+
+func (s *accountantService) MarketByItem(ctx context.Context, period entity.Period) (aggregate.MarketByItem, error) {
+	return s.balanceSvc.MarketByItem(ctx, period)
+}
+
+func (s *accountantService) UnmappedTypes(ctx context.Context, period entity.Period) (*aggregate.BalanceByType, error) {
+	return s.balanceSvc.UnmappedTypes(ctx, period)
+}
+
+func (s *accountantService) Buyback(ctx context.Context, period entity.Period) (*buybackAggregate.Buyback, error) {
+	return s.buybackSvc.Buyback(ctx, period)
+}
+
+func (s *accountantService) Names(ctx context.Context, ids []namesEntity.ID) (map[namesEntity.ID]namesAggregate.Name, error) {
+	return s.namesSvc.Names(ctx, ids)
+}
+
+// LargeTransactions returns journal records of the period of at least
+// minAmount either way.
+func (s *accountantService) LargeTransactions(ctx context.Context, period entity.Period, minAmount entity.Amount) ([]aggregate.WalletRecord, error) {
+	var records []aggregate.WalletRecord
+	err := s.balanceSvc.Aggregate(ctx, period, balance.AccumulatorFunc(func(source balance.Source, record aggregate.JournalRecord) {
+		if record.Amount >= minAmount || record.Amount <= -minAmount {
+			records = append(records, aggregate.WalletRecord{
+				Wallet: aggregate.Wallet{CorporationID: source.CorporationID, Division: source.DivisionName()},
+				Record: record,
+			})
+		}
+	}))
+	return records, err
+}
+
+// Spending returns ISK paid by records of the ref type within the period, ref
+// type may be a group, raw ref types of the group are summed then.
+func (s *accountantService) Spending(ctx context.Context, period entity.Period, refType entity.RefType) (entity.Amount, error) {
+	refTypes := make(map[entity.RefType]struct{})
+	for _, raw := range s.balanceSvc.RefTypes(refType) {
+		refTypes[raw] = struct{}{}
+	}
+	var spent entity.Amount
+	err := s.balanceSvc.Aggregate(ctx, period, balance.AccumulatorFunc(func(_ balance.Source, record aggregate.JournalRecord) {
+		if _, ok := refTypes[record.RefType]; ok && record.Amount < 0 {
+			spent -= record.Amount
+		}
+	}))
+	return spent, err
+}
+
+// SearchJournal returns journal records matching the query newest first, with
+// names of their corporations, parties and items of market transactions.
+// Party of the query is matched with the resolved names, so records are
+// limited only after that when it is set.
+func (s *accountantService) SearchJournal(ctx context.Context, query aggregate.JournalQuery) ([]aggregate.WalletRecord, map[namesEntity.ID]namesAggregate.Name, error) {
+	balanceQuery := query
+	if query.Party != "" {
+		balanceQuery.Limit = 0
+	}
+	records, searchErr := s.balanceSvc.SearchJournal(ctx, balanceQuery)
+	if searchErr != nil && !balance.IsPartial(searchErr) {
+		return nil, nil, errors.Wrap(searchErr, "error searching journal")
+	}
 
-	dateStart := time.Date(currentYear, currentMonth, 1, 0, 0, 0, 0, time.UTC)
-	dateEnd := dateStart.AddDate(0, 1, -1)
+	var (
+		ids  []namesEntity.ID
+		seen = make(map[namesEntity.ID]struct{})
+	)
+	for _, record := range records {
+		recordIDs := []namesEntity.ID{
+			namesEntity.ID(record.Wallet.CorporationID),
+			namesEntity.ID(record.Record.FirstPartyId),
+			namesEntity.ID(record.Record.SecondPartyId),
+		}
+		if record.Transaction != nil {
+			recordIDs = append(recordIDs, namesEntity.ID(record.Transaction.TypeId))
+		}
+		for _, id := range recordIDs {
+			if _, ok := seen[id]; ok || id == 0 {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	names, err := s.namesSvc.Names(ctx, ids)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error resolving names")
+	}
 
+	if query.Party != "" {
+		party := strings.ToLower(query.Party)
+		matches := func(id namesEntity.ID) bool {
+			name, ok := names[id]
+			return ok && strings.Contains(strings.ToLower(string(name.Name)), party)
+		}
+		matching := records[:0]
+		for _, record := range records {
+			if matches(namesEntity.ID(record.Record.FirstPartyId)) || matches(namesEntity.ID(record.Record.SecondPartyId)) {
+				matching = append(matching, record)
+			}
+		}
+		records = matching
+		if query.Limit > 0 && len(records) > query.Limit {
+			records = records[:query.Limit]
+		}
+	}
+	return records, names, searchErr
+}
+
+func (s *accountantService) AlertState(ctx context.Context, key alertEntity.Key) (*alertAggregate.State, error) {
+	return s.alertSvc.State(ctx, key)
+}
+
+func (s *accountantService) FiringAlerts(ctx context.Context) ([]alertAggregate.State, error) {
+	return s.alertSvc.Firing(ctx)
+}
+
+func (s *accountantService) MarkAlertSent(ctx context.Context, key alertEntity.Key, at time.Time) error {
+	return s.alertSvc.MarkSent(ctx, key, at)
+}
+
+func (s *accountantService) MarkAlertFiring(ctx context.Context, key alertEntity.Key, title string, channel alertEntity.ChannelID, at time.Time) error {
+	return s.alertSvc.MarkFiring(ctx, key, title, channel, at)
+}
+
+func (s *accountantService) ClearAlert(ctx context.Context, key alertEntity.Key) error {
+	return s.alertSvc.Clear(ctx, key)
+}
+
+func (s *accountantService) AcknowledgeAlert(ctx context.Context, key alertEntity.Key, until time.Time, by string) error {
+	return s.alertSvc.Acknowledge(ctx, key, until, by)
+}
+
+// ReportLastRun returns time of the last run of scheduled report, zero when
+// it never ran.
+func (s *accountantService) ReportLastRun(ctx context.Context, name string) (time.Time, error) {
+	return s.reportSvc.LastRun(ctx, reportEntity.Name(name))
+}
+
+// MarkReportRun remembers run of scheduled report, so runs are neither
+// repeated nor missed across restarts.
+func (s *accountantService) MarkReportRun(ctx context.Context, name string, at time.Time) error {
+	return s.reportSvc.MarkRun(ctx, reportEntity.Name(name), at)
+}
+
+func (s *accountantService) DataAsOf(ctx context.Context) (time.Time, error) {
+	return s.balanceSvc.DataAsOf(ctx)
+}
+
+func (s *accountantService) MonthlyBalanceBelowThreshold(ctx context.Context) (bool, aggregate.MonthlyBalanceNotification, error) {
 	monthlyBalanceNotification := aggregate.MonthlyBalanceNotification{
 		Threshold: s.monthlyBalanceThreshold,
-		DateStart: dateStart,
-		DateEnd:   dateEnd,
+		Period:    entity.MonthPeriod(time.Now().In(s.location)),
 	}
 
-	balance, err := s.Balance(ctx, dateStart, dateEnd)
+	balance, err := s.Balance(ctx, monthlyBalanceNotification.Period)
 	if err != nil {
 		return false, monthlyBalanceNotification, errors.Wrap(err, "error checking balance")
 	}
@@ -0,0 +1,71 @@
+package accountant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+// journalBalance streams fixed records to accumulators and groups ref types
+// by real ref type groups. Methods not overridden panic.
+type journalBalance struct {
+	balance.Service
+	groups  *balance.RefTypeGroups
+	records []aggregate.JournalRecord
+}
+
+func (b *journalBalance) Aggregate(ctx context.Context, period entity.Period, accumulators ...balance.Accumulator) error {
+	for _, record := range b.records {
+		for _, accumulator := range accumulators {
+			accumulator.Accumulate(balance.Source{CorporationID: 98000001}, record)
+		}
+	}
+	return nil
+}
+
+func (b *journalBalance) RefTypes(name entity.RefType) []entity.RefType {
+	return b.groups.RefTypes(name)
+}
+
+func TestSpending(t *testing.T) {
+	groups, err := balance.NewRefTypeGroups("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []aggregate.JournalRecord{
+		{Id: 1, RefType: "alliance_maintainance_fee", Amount: -10},
+		{Id: 2, RefType: "office_rental_fee", Amount: -20},
+		{Id: 3, RefType: "office_rental_fee", Amount: 5},
+		{Id: 4, RefType: "bounty_prizes", Amount: 1000},
+		{Id: 5, RefType: "player_donation", Amount: -40},
+		{Id: 6, RefType: "corporation_account_withdrawal", Amount: -100},
+	}
+	s := New(&journalBalance{groups: groups, records: records}, nil, nil, nil, nil, 0, nil)
+
+	tests := []struct {
+		refType entity.RefType
+		want    entity.Amount
+	}{
+		{refType: "office_rental_fee", want: 20},
+		{refType: "Fee", want: 30},
+		{refType: "fee", want: 30},
+		{refType: "player_donation", want: 40},
+		{refType: "Player Wallet Action", want: 140},
+		{refType: "Krab Tax", want: 0},
+		{refType: "unknown_ref_type", want: 0},
+	}
+	for _, test := range tests {
+		t.Run(string(test.refType), func(t *testing.T) {
+			spent, err := s.Spending(context.Background(), entity.Period{}, test.refType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if spent != test.want {
+				t.Errorf("expected spent %v, got %v", test.want, spent)
+			}
+		})
+	}
+}
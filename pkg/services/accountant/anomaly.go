@@ -0,0 +1,187 @@
+package accountant
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	alertEntity "github.com/lunemec/eve-accountant/pkg/domain/alert/entity"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Only records this recent are checked, so the first start does not alert
+	// the whole history while records synchronized late are still checked.
+	anomalyLookback = 48 * time.Hour
+	// Records are compared to history of this many days before the lookback,
+	// parties and ref types not seen within it are new again.
+	anomalyHistoryDays = 90
+	// Records are not compared to shorter history, everything would be new.
+	anomalyMinHistory = 7 * 24 * time.Hour
+	// Withdrawal is large when it is this many times the typical one, typical
+	// withdrawal is known only with enough samples.
+	largeWithdrawalFactor     = 5
+	largeWithdrawalMinSamples = 5
+)
+
+var (
+	withdrawalRefType = entity.RefType("corporation_account_withdrawal")
+	// recipientRefTypes are ref types of ISK given away from the corporation,
+	// payments for market orders and contracts are left out, otherwise every
+	// new seller would be reported.
+	recipientRefTypes = map[entity.RefType]struct{}{
+		withdrawalRefType:                 {},
+		entity.RefType("player_donation"): {},
+	}
+)
+
+// Anomalies returns unusual journal records of the last days which were not
+// alerted yet. Only the lookback and the history window before it are read,
+// not the whole journal.
+func (s *accountantService) Anomalies(ctx context.Context) ([]aggregate.Anomaly, error) {
+	now := time.Now()
+	since := now.Add(-anomalyLookback)
+	detector := newAnomalyDetector(since)
+	partialErr := s.balanceSvc.Aggregate(ctx, entity.NewPeriod(since.AddDate(0, 0, -anomalyHistoryDays), now), detector)
+	if partialErr != nil && !balance.IsPartial(partialErr) {
+		return nil, errors.Wrap(partialErr, "error loading journal")
+	}
+	if detector.first.IsZero() || detector.since.Sub(detector.first) < anomalyMinHistory {
+		return nil, partialErr
+	}
+
+	var anomalies []aggregate.Anomaly
+	for _, anomaly := range detector.anomalies() {
+		sent, err := s.alertSvc.Sent(ctx, anomalyKey(anomaly))
+		if err != nil {
+			return nil, errors.Wrap(err, "error checking alerted anomalies")
+		}
+		if !sent {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies, partialErr
+}
+
+// AnomalyAlerted remembers the anomaly was alerted, so it is not returned
+// again even after restart.
+func (s *accountantService) AnomalyAlerted(ctx context.Context, anomaly aggregate.Anomaly) error {
+	return s.alertSvc.MarkSent(ctx, anomalyKey(anomaly), time.Now())
+}
+
+// anomalyKey identifies alert by journal ID, the same transaction between two
+// of our corporations is in both journals.
+func anomalyKey(anomaly aggregate.Anomaly) alertEntity.Key {
+	return alertEntity.Key(fmt.Sprintf("anomaly/%d/%d", anomaly.CorporationID, anomaly.Record.Id))
+}
+
+type anomalyRecord struct {
+	source balance.Source
+	record aggregate.JournalRecord
+}
+
+// anomalyDetector collects statistics of history records before since and
+// keeps records after it to be checked against them.
+type anomalyDetector struct {
+	since       time.Time
+	first       time.Time
+	refTypes    map[entity.RefType]struct{}
+	recipients  map[entity.PartyID]struct{}
+	withdrawals []float64
+	recent      []anomalyRecord
+}
+
+func newAnomalyDetector(since time.Time) *anomalyDetector {
+	return &anomalyDetector{
+		since:      since,
+		refTypes:   make(map[entity.RefType]struct{}),
+		recipients: make(map[entity.PartyID]struct{}),
+	}
+}
+
+func (d *anomalyDetector) Accumulate(source balance.Source, record aggregate.JournalRecord) {
+	if d.first.IsZero() || record.Date.Before(d.first) {
+		d.first = record.Date
+	}
+	if !record.Date.Before(d.since) {
+		d.recent = append(d.recent, anomalyRecord{source: source, record: record})
+		return
+	}
+
+	d.refTypes[record.RefType] = struct{}{}
+	party, ok := recipient(source, record)
+	if !ok {
+		return
+	}
+	d.recipients[party] = struct{}{}
+	if record.RefType == withdrawalRefType {
+		d.withdrawals = append(d.withdrawals, math.Abs(float64(record.Amount)))
+	}
+}
+
+// anomalies checks recent records from the oldest, each one becomes part of
+// the history so only the first payment to a new party is reported.
+func (d *anomalyDetector) anomalies() []aggregate.Anomaly {
+	sort.Slice(d.recent, func(i, j int) bool {
+		if !d.recent[i].record.Date.Equal(d.recent[j].record.Date) {
+			return d.recent[i].record.Date.Before(d.recent[j].record.Date)
+		}
+		return d.recent[i].record.Id < d.recent[j].record.Id
+	})
+	typical, typicalOk := median(d.withdrawals), len(d.withdrawals) >= largeWithdrawalMinSamples
+
+	var anomalies []aggregate.Anomaly
+	for _, recent := range d.recent {
+		anomaly := aggregate.Anomaly{
+			CorporationID: recent.source.CorporationID,
+			Division:      recent.source.DivisionName(),
+			Record:        recent.record,
+			Party:         recent.source.Party(recent.record),
+		}
+		if _, ok := d.refTypes[recent.record.RefType]; !ok {
+			d.refTypes[recent.record.RefType] = struct{}{}
+			anomaly.Reasons = append(anomaly.Reasons, aggregate.NewRefType)
+		}
+		if party, ok := recipient(recent.source, recent.record); ok {
+			if _, ok := d.recipients[party]; !ok {
+				d.recipients[party] = struct{}{}
+				anomaly.Reasons = append(anomaly.Reasons, aggregate.NewRecipient)
+			}
+			amount := math.Abs(float64(recent.record.Amount))
+			if recent.record.RefType == withdrawalRefType && typicalOk && amount > largeWithdrawalFactor*typical {
+				anomaly.Reasons = append(anomaly.Reasons, aggregate.LargeWithdrawal)
+				anomaly.Typical = entity.Amount(-typical)
+			}
+		}
+		if len(anomaly.Reasons) > 0 {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies
+}
+
+// recipient returns the party ISK was given to, false for other records.
+func recipient(source balance.Source, record aggregate.JournalRecord) (entity.PartyID, bool) {
+	if _, ok := recipientRefTypes[record.RefType]; !ok {
+		return 0, false
+	}
+	return source.Recipient(record)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
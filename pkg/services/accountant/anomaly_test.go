@@ -0,0 +1,58 @@
+package accountant
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	const (
+		corporation = 98000001
+		known       = 91000001
+		stranger    = 91000002
+	)
+	var (
+		since  = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+		source = balance.Source{CorporationID: corporation}
+		id     entity.Id
+	)
+	withdrawal := func(date time.Time, party int32, amount entity.Amount) aggregate.JournalRecord {
+		id++
+		return aggregate.JournalRecord{
+			Id:            id,
+			Date:          date,
+			Amount:        -amount,
+			RefType:       withdrawalRefType,
+			FirstPartyId:  corporation,
+			SecondPartyId: entity.SecondPartyId(party),
+		}
+	}
+
+	detector := newAnomalyDetector(since)
+	for day := 1; day <= 10; day++ {
+		detector.Accumulate(source, withdrawal(since.AddDate(0, 0, -day), known, 100))
+	}
+	detector.Accumulate(source, withdrawal(since.Add(time.Hour), known, 120))
+	detector.Accumulate(source, withdrawal(since.Add(3*time.Hour), known, 1000))
+	detector.Accumulate(source, withdrawal(since.Add(2*time.Hour), stranger, 50))
+	detector.Accumulate(source, withdrawal(since.Add(4*time.Hour), stranger, 50))
+	detector.Accumulate(source, aggregate.JournalRecord{Id: 100, Date: since.Add(5 * time.Hour), Amount: 10, RefType: "daily_goal_payouts", FirstPartyId: 1000125, SecondPartyId: corporation})
+
+	var got []string
+	for _, anomaly := range detector.anomalies() {
+		got = append(got, fmt.Sprintf("%d:%v", anomaly.Record.Id, anomaly.Reasons))
+	}
+	want := fmt.Sprint([]string{
+		fmt.Sprintf("13:%v", []aggregate.AnomalyReason{aggregate.NewRecipient}),
+		fmt.Sprintf("12:%v", []aggregate.AnomalyReason{aggregate.LargeWithdrawal}),
+		fmt.Sprintf("100:%v", []aggregate.AnomalyReason{aggregate.NewRefType}),
+	})
+	if fmt.Sprint(got) != want {
+		t.Errorf("expected anomalies %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,414 @@
+package accountant
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	namesAggregate "github.com/lunemec/eve-accountant/pkg/domain/names/aggregate"
+	namesEntity "github.com/lunemec/eve-accountant/pkg/domain/names/entity"
+	"github.com/lunemec/eve-accountant/pkg/export"
+	"github.com/pkg/errors"
+)
+
+// Export returns report of the period as a table with resolved names and ref
+// type groups, times are in location of reports. Error of corporations
+// missing from the results is returned with the table of the rest.
+func (s *accountantService) Export(ctx context.Context, report export.Report, period entity.Period) (*export.Table, error) {
+	var (
+		table *export.Table
+		err   error
+	)
+	switch report {
+	case export.JournalReport:
+		table, err = s.exportJournal(ctx, period)
+	case export.BalanceReport:
+		table, err = s.exportBalance(ctx, period)
+	case export.ByDivisionReport:
+		table, err = s.exportByDivision(ctx, period)
+	case export.ByTypeReport:
+		table, err = s.exportByType(ctx, period)
+	case export.ByMemberReport:
+		table, err = s.exportByMember(ctx, period)
+	case export.DailyReport:
+		table, err = s.exportDaily(ctx, period)
+	case export.WalletBalanceReport:
+		table, err = s.exportWalletBalance(ctx, period)
+	case export.MarketReport:
+		table, err = s.exportMarket(ctx, period)
+	case export.KrabReport:
+		table, err = s.exportKrab(ctx, period)
+	default:
+		return nil, errors.Errorf("unknown report: %s", report)
+	}
+	if err != nil && !balance.IsPartial(err) {
+		return nil, errors.Wrapf(err, "error exporting report: %s", report)
+	}
+	return table, err
+}
+
+func (s *accountantService) exportJournal(ctx context.Context, period entity.Period) (*export.Table, error) {
+	records, searchErr := s.balanceSvc.SearchJournal(ctx, aggregate.JournalQuery{Period: period})
+	if searchErr != nil && !balance.IsPartial(searchErr) {
+		return nil, searchErr
+	}
+	// Search returns newest first, spreadsheets read better oldest first.
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Record.Date.Before(records[j].Record.Date)
+	})
+
+	var ids []namesEntity.ID
+	for _, record := range records {
+		ids = append(ids,
+			namesEntity.ID(record.Wallet.CorporationID),
+			namesEntity.ID(record.Record.FirstPartyId),
+			namesEntity.ID(record.Record.SecondPartyId),
+		)
+		if record.Transaction != nil {
+			ids = append(ids, namesEntity.ID(record.Transaction.TypeId))
+		}
+	}
+	names, err := s.exportNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.NewTable(
+		"Journal",
+		"date", "id", "corporation_id", "corporation", "division", "ref_type", "ref_type_group",
+		"amount", "balance", "first_party_id", "first_party", "second_party_id", "second_party",
+		"description", "reason", "tax", "tax_receiver_id", "context_id", "context_id_type",
+		"type_id", "item", "quantity", "unit_price",
+	)
+	for _, record := range records {
+		r := record.Record
+		// Market transaction columns are empty for other records.
+		var typeID, item, quantity, unitPrice interface{}
+		if t := record.Transaction; t != nil {
+			typeID, item, quantity, unitPrice = int64(t.TypeId), names.name(namesEntity.ID(t.TypeId)), int64(t.Quantity), float64(t.UnitPrice)
+		}
+		table.Append(
+			r.Date.In(s.location),
+			int64(r.Id),
+			int64(record.Wallet.CorporationID),
+			names.name(namesEntity.ID(record.Wallet.CorporationID)),
+			string(record.Wallet.Division),
+			string(r.RefType),
+			string(s.balanceSvc.Group(r.RefType)),
+			float64(r.Amount),
+			float64(r.Balance),
+			int64(r.FirstPartyId),
+			names.name(namesEntity.ID(r.FirstPartyId)),
+			int64(r.SecondPartyId),
+			names.name(namesEntity.ID(r.SecondPartyId)),
+			string(r.Description),
+			string(r.Reason),
+			float64(r.Tax),
+			int64(r.TaxReceiverId),
+			int64(r.ContextId),
+			string(r.ContextIdType),
+			typeID,
+			item,
+			quantity,
+			unitPrice,
+		)
+	}
+	return table, searchErr
+}
+
+func (s *accountantService) exportBalance(ctx context.Context, period entity.Period) (*export.Table, error) {
+	balance, err := s.Balance(ctx, period)
+	if balance == nil {
+		return nil, err
+	}
+	table := export.NewTable("Balance", "period_start", "period_end", "income", "expenses", "balance")
+	table.Append(period.Start, period.LastDay(), float64(balance.Income), float64(balance.Expenses), float64(balance.Balance()))
+	return table, err
+}
+
+func (s *accountantService) exportByDivision(ctx context.Context, period entity.Period) (*export.Table, error) {
+	balance, err := s.BalanceByDivision(ctx, period)
+	if balance == nil {
+		return nil, err
+	}
+	divisions := make(map[entity.DivisionName]struct{})
+	for division := range balance.IncomeByDivision {
+		divisions[division] = struct{}{}
+	}
+	for division := range balance.ExpensesByDivision {
+		divisions[division] = struct{}{}
+	}
+	names := make([]entity.DivisionName, 0, len(divisions))
+	for division := range divisions {
+		names = append(names, division)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+
+	table := export.NewTable("By Division", "division", "income", "expenses", "balance")
+	for _, division := range names {
+		income, expenses := balance.IncomeByDivision[division], balance.ExpensesByDivision[division]
+		table.Append(string(division), float64(income), float64(expenses), float64(income+expenses))
+	}
+	return table, err
+}
+
+func (s *accountantService) exportByType(ctx context.Context, period entity.Period) (*export.Table, error) {
+	balance, err := s.BalanceByType(ctx, period)
+	if balance == nil {
+		return nil, err
+	}
+	groups := make(map[entity.RefType]struct{})
+	for group := range balance.IncomeByType {
+		groups[group] = struct{}{}
+	}
+	for group := range balance.ExpensesByType {
+		groups[group] = struct{}{}
+	}
+	names := make([]entity.RefType, 0, len(groups))
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+
+	table := export.NewTable("By Type", "ref_type_group", "income", "expenses", "balance")
+	for _, group := range names {
+		income, expenses := balance.IncomeByType[group], balance.ExpensesByType[group]
+		table.Append(string(group), float64(income), float64(expenses), float64(income+expenses))
+	}
+	return table, err
+}
+
+func (s *accountantService) exportByMember(ctx context.Context, period entity.Period) (*export.Table, error) {
+	balance, balanceErr := s.BalanceByParty(ctx, period)
+	if balance == nil {
+		return nil, balanceErr
+	}
+	parties := make(map[entity.PartyID]struct{})
+	for party := range balance.IncomeByParty {
+		parties[party] = struct{}{}
+	}
+	for party := range balance.ExpensesByParty {
+		parties[party] = struct{}{}
+	}
+	ids := make([]namesEntity.ID, 0, len(parties))
+	for party := range parties {
+		ids = append(ids, namesEntity.ID(party))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	names, err := s.exportNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.NewTable("By Member", "party_id", "party", "income", "expenses", "balance")
+	for _, id := range ids {
+		income, expenses := balance.IncomeByParty[entity.PartyID(id)], balance.ExpensesByParty[entity.PartyID(id)]
+		table.Append(int64(id), names.name(id), float64(income), float64(expenses), float64(income+expenses))
+	}
+	return table, balanceErr
+}
+
+func (s *accountantService) exportDaily(ctx context.Context, period entity.Period) (*export.Table, error) {
+	days, err := s.BalanceByDayByDivisionByType(ctx, period)
+	if days == nil && err != nil {
+		return nil, err
+	}
+	type key struct {
+		division entity.DivisionName
+		group    entity.RefType
+	}
+
+	table := export.NewTable("Daily", "date", "division", "ref_type_group", "income", "expenses", "balance")
+	for _, day := range days {
+		amounts := make(map[key][2]entity.Amount)
+		for i, byDivision := range []aggregate.AmountByDivisionByType{day.Income, day.Expenses} {
+			for division, byType := range byDivision {
+				for group, amount := range byType {
+					incomeExpenses := amounts[key{division, group}]
+					incomeExpenses[i] += amount
+					amounts[key{division, group}] = incomeExpenses
+				}
+			}
+		}
+		keys := make([]key, 0, len(amounts))
+		for k := range amounts {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].division == keys[j].division {
+				return keys[i].group < keys[j].group
+			}
+			return keys[i].division < keys[j].division
+		})
+		for _, k := range keys {
+			income, expenses := amounts[k][0], amounts[k][1]
+			table.Append(day.Timestamp, string(k.division), string(k.group), float64(income), float64(expenses), float64(income+expenses))
+		}
+	}
+	return table, err
+}
+
+func (s *accountantService) exportWalletBalance(ctx context.Context, period entity.Period) (*export.Table, error) {
+	days, balanceErr := s.WalletBalanceByDay(ctx, period)
+	if balanceErr != nil && !balance.IsPartial(balanceErr) {
+		return nil, balanceErr
+	}
+	var (
+		ids  []namesEntity.ID
+		seen = make(map[entity.CorporationID]struct{})
+	)
+	for _, day := range days {
+		for wallet := range day.ByWallet {
+			if _, ok := seen[wallet.CorporationID]; !ok {
+				seen[wallet.CorporationID] = struct{}{}
+				ids = append(ids, namesEntity.ID(wallet.CorporationID))
+			}
+		}
+	}
+	names, err := s.exportNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.NewTable("Wallet Balance", "date", "corporation_id", "corporation", "division", "balance")
+	for _, day := range days {
+		wallets := make([]aggregate.Wallet, 0, len(day.ByWallet))
+		for wallet := range day.ByWallet {
+			wallets = append(wallets, wallet)
+		}
+		sort.Slice(wallets, func(i, j int) bool {
+			if wallets[i].CorporationID == wallets[j].CorporationID {
+				return wallets[i].Division < wallets[j].Division
+			}
+			return wallets[i].CorporationID < wallets[j].CorporationID
+		})
+		for _, wallet := range wallets {
+			table.Append(
+				day.Timestamp,
+				int64(wallet.CorporationID),
+				names.name(namesEntity.ID(wallet.CorporationID)),
+				string(wallet.Division),
+				float64(day.ByWallet[wallet]),
+			)
+		}
+	}
+	return table, balanceErr
+}
+
+func (s *accountantService) exportMarket(ctx context.Context, period entity.Period) (*export.Table, error) {
+	items, marketErr := s.MarketByItem(ctx, period)
+	if marketErr != nil && !balance.IsPartial(marketErr) {
+		return nil, marketErr
+	}
+	ids := make([]namesEntity.ID, 0, len(items))
+	for typeID := range items {
+		ids = append(ids, namesEntity.ID(typeID))
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	names, err := s.exportNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.NewTable(
+		"Market",
+		"type_id", "item", "bought_quantity", "spent", "sold_quantity", "revenue", "average_buy_price", "realised_margin",
+	)
+	for _, id := range ids {
+		item := items[entity.TypeId(id)]
+		var averageBuyPrice, realisedMargin interface{}
+		if price, ok := item.AverageBuyPrice(); ok {
+			averageBuyPrice = float64(price)
+		}
+		if margin, ok := item.RealisedMargin(); ok {
+			realisedMargin = float64(margin)
+		}
+		table.Append(
+			int64(id),
+			names.name(id),
+			item.BoughtQuantity,
+			float64(item.Spent),
+			item.SoldQuantity,
+			float64(item.Revenue),
+			averageBuyPrice,
+			realisedMargin,
+		)
+	}
+	return table, marketErr
+}
+
+func (s *accountantService) exportKrab(ctx context.Context, period entity.Period) (*export.Table, error) {
+	ledger, ledgerErr := s.TaxLedger(ctx, period)
+	if ledger == nil {
+		return nil, ledgerErr
+	}
+	months := make([]time.Time, 0, len(ledger.ByMonth))
+	var ids []namesEntity.ID
+	for month, byParty := range ledger.ByMonth {
+		months = append(months, month)
+		for pilot := range byParty {
+			ids = append(ids, namesEntity.ID(pilot))
+		}
+	}
+	sort.Slice(months, func(i, j int) bool {
+		return months[i].Before(months[j])
+	})
+	names, err := s.exportNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	table := export.NewTable("Krab Tax", "month", "pilot_id", "pilot", "tax")
+	for _, month := range months {
+		byParty := ledger.ByMonth[month]
+		pilots := make([]entity.PartyID, 0, len(byParty))
+		for pilot := range byParty {
+			pilots = append(pilots, pilot)
+		}
+		sort.Slice(pilots, func(i, j int) bool {
+			return byParty[pilots[i]] > byParty[pilots[j]]
+		})
+		for _, pilot := range pilots {
+			table.Append(month.Format("2006-01"), int64(pilot), names.name(namesEntity.ID(pilot)), float64(byParty[pilot]))
+		}
+	}
+	return table, ledgerErr
+}
+
+// exportNames maps IDs to names, unknown IDs are exported empty.
+type exportNames map[namesEntity.ID]namesAggregate.Name
+
+func (n exportNames) name(id namesEntity.ID) string {
+	return string(n[id].Name)
+}
+
+// exportNames resolves unique non-zero IDs.
+func (s *accountantService) exportNames(ctx context.Context, ids []namesEntity.ID) (exportNames, error) {
+	var (
+		unique []namesEntity.ID
+		seen   = make(map[namesEntity.ID]struct{}, len(ids))
+	)
+	for _, id := range ids {
+		if _, ok := seen[id]; ok || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	names, err := s.namesSvc.Names(ctx, unique)
+	if err != nil {
+		return nil, errors.Wrap(err, "error resolving names")
+	}
+	return names, nil
+}
@@ -0,0 +1,112 @@
+package accountant
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/lunemec/eve-accountant/pkg/domain/balance"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/aggregate"
+	"github.com/lunemec/eve-accountant/pkg/domain/balance/entity"
+	"github.com/pkg/errors"
+)
+
+const (
+	// How many past days daily statistics are calculated from, today is left out
+	// because it is not over yet.
+	forecastHistoryDays = 90
+	// Projected balance is within the band with this probability, forecastZ is
+	// the matching quantile of normal distribution.
+	forecastConfidence = 0.8
+	forecastZ          = 1.2816
+)
+
+// scheduledRefTypes are recurring fees charged once a month, each payment is
+// expected again one month later instead of being spread into daily averages.
+var scheduledRefTypes = map[entity.RefType]struct{}{
+	entity.RefType("office_rental_fee"):         {},
+	entity.RefType("alliance_maintainance_fee"): {},
+}
+
+// Forecast projects balance of this month at its end.
+func (s *accountantService) Forecast(ctx context.Context) (*aggregate.Forecast, error) {
+	now := time.Now().In(s.location)
+	month := entity.MonthPeriod(now)
+	today := entity.Midnight(now)
+
+	actual, partialErr := s.balanceSvc.Balance(ctx, month.Until(now))
+	if partialErr != nil && !balance.IsPartial(partialErr) {
+		return nil, errors.Wrap(partialErr, "error loading balance of this month")
+	}
+	history, err := s.balanceSvc.DailyAmountsByType(ctx, entity.NewPeriod(today.AddDate(0, 0, -forecastHistoryDays), today))
+	if err != nil {
+		if !balance.IsPartial(err) {
+			return nil, errors.Wrap(err, "error loading daily history")
+		}
+		partialErr = err
+	}
+
+	forecast := newForecast(month, now, actual.Balance(), history)
+	forecast.Threshold = s.monthlyBalanceThreshold
+	return forecast, partialErr
+}
+
+// newForecast adds scheduled fees and daily mean of other ref types for the
+// rest of the month to the actual balance. Confidence band grows with square
+// root of remaining days, as for sum of independent days.
+func newForecast(month entity.Period, now time.Time, actual entity.Amount, history *aggregate.DailyAmounts) *aggregate.Forecast {
+	forecast := &aggregate.Forecast{
+		Period:          month,
+		AsOf:            now,
+		Actual:          actual,
+		ScheduledByType: make(aggregate.AmountByType),
+		Confidence:      forecastConfidence,
+	}
+
+	// Days before the first record are most likely not synchronized, not quiet.
+	first := len(history.Days)
+	for _, amounts := range history.ByType {
+		for i, amount := range amounts {
+			if amount != 0 && i < first {
+				first = i
+			}
+		}
+	}
+	daily := make([]float64, len(history.Days)-first)
+	for refType, amounts := range history.ByType {
+		_, scheduled := scheduledRefTypes[refType]
+		for i := first; i < len(amounts); i++ {
+			if !scheduled {
+				daily[i-first] += float64(amounts[i])
+				continue
+			}
+			next := history.Days[i].AddDate(0, 1, 0)
+			if amounts[i] != 0 && next.After(now) && next.Before(month.End) {
+				forecast.ScheduledByType[refType] += amounts[i]
+				forecast.Scheduled += amounts[i]
+			}
+		}
+	}
+	forecast.HistoryDays = len(daily)
+	if len(daily) == 0 {
+		forecast.Low, forecast.High = forecast.Projected(), forecast.Projected()
+		return forecast
+	}
+
+	var mean, variance float64
+	for _, amount := range daily {
+		mean += amount
+	}
+	mean /= float64(len(daily))
+	for _, amount := range daily {
+		variance += (amount - mean) * (amount - mean)
+	}
+	variance /= float64(len(daily))
+
+	remainingDays := month.End.Sub(now).Hours() / 24
+	forecast.Expected = entity.Amount(mean * remainingDays)
+	band := entity.Amount(forecastZ * math.Sqrt(variance*remainingDays))
+	forecast.Low = forecast.Projected() - band
+	forecast.High = forecast.Projected() + band
+	return forecast
+}
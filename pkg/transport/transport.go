@@ -0,0 +1,234 @@
+package transport
+
+import (
+	"context"
+	"expvar"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// Retry idempotent requests at most maxRetries times.
+	maxRetries  = 3
+	baseBackoff = 1 * time.Second
+	maxBackoff  = 30 * time.Second
+
+	// Pause all requests until error limit window resets when there are
+	// less than errorLimitFloor errors remaining.
+	errorLimitFloor = 10
+	// Used when ESI does not tell us when the error limit window resets.
+	defaultErrorLimitReset = 60 * time.Second
+
+	errorLimitRemainHeader = "X-ESI-Error-Limit-Remain"
+	errorLimitResetHeader  = "X-ESI-Error-Limit-Reset"
+
+	// statusErrorLimited is returned by ESI when error limit was reached.
+	statusErrorLimited = 420
+
+	// Each attempt including reading of the body is cancelled after
+	// requestTimeout, retries and pauses are not counted in.
+	requestTimeout = 10 * time.Second
+)
+
+var (
+	metrics          = expvar.NewMap("esi_transport")
+	errorLimitRemain = new(expvar.Int)
+	pausedUntil      = new(expvar.String)
+)
+
+func init() {
+	metrics.Set("error_limit_remain", errorLimitRemain)
+	metrics.Set("paused_until", pausedUntil)
+}
+
+// Transport retries failed idempotent ESI requests with jittered backoff and
+// pauses all requests when ESI error limit is close to being reached.
+// Counters are published in expvar under "esi_transport".
+type Transport struct {
+	log  *zap.Logger
+	next http.RoundTripper
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+
+	timeout time.Duration
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// New returns Transport sending requests with next.
+func New(log *zap.Logger, next http.RoundTripper) *Transport {
+	return &Transport{
+		log:     log,
+		next:    next,
+		timeout: requestTimeout,
+		now:     time.Now,
+		sleep:   sleep,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		err := t.waitForErrorLimit(req)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics.Add("requests", 1)
+		ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+		resp, err := t.next.RoundTrip(req.WithContext(ctx))
+		if resp != nil {
+			t.recordErrorLimit(req, resp)
+		}
+		if attempt >= maxRetries || !retryable(req, resp, err) {
+			if err != nil {
+				cancel()
+				return resp, err
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		delay := backoff(attempt)
+		fields := []zap.Field{
+			zap.String("method", req.Method),
+			zap.String("url", req.URL.String()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+		}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		if resp != nil {
+			fields = append(fields, zap.Int("status", resp.StatusCode), zap.String("error_limit_remain", resp.Header.Get(errorLimitRemainHeader)))
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+		t.log.Warn("retrying ESI request", fields...)
+		metrics.Add("retries", 1)
+
+		err = t.sleep(req.Context(), delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// cancelBody cancels timeout of the attempt once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// waitForErrorLimit blocks while requests are paused.
+func (t *Transport) waitForErrorLimit(req *http.Request) error {
+	t.mu.Lock()
+	wait := t.pausedUntil.Sub(t.now())
+	t.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	t.log.Warn("ESI error limit reached, waiting",
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.String()),
+		zap.Duration("wait", wait),
+	)
+	metrics.Add("paused_requests", 1)
+	return t.sleep(req.Context(), wait)
+}
+
+// recordErrorLimit reads error limit headers and pauses requests when there
+// are only few errors remaining or ESI already refuses requests.
+func (t *Transport) recordErrorLimit(req *http.Request, resp *http.Response) {
+	switch {
+	case resp.StatusCode == statusErrorLimited:
+		metrics.Add("error_limited", 1)
+	case resp.StatusCode >= 500:
+		metrics.Add("server_errors", 1)
+	}
+
+	remain, err := strconv.Atoi(resp.Header.Get(errorLimitRemainHeader))
+	if err != nil {
+		if resp.StatusCode != statusErrorLimited {
+			return
+		}
+		remain = 0
+	}
+	errorLimitRemain.Set(int64(remain))
+	if remain >= errorLimitFloor && resp.StatusCode != statusErrorLimited {
+		return
+	}
+
+	reset := defaultErrorLimitReset
+	resetSeconds, err := strconv.Atoi(resp.Header.Get(errorLimitResetHeader))
+	if err == nil {
+		reset = time.Duration(resetSeconds) * time.Second
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until := t.now().Add(reset)
+	if until.After(t.pausedUntil) {
+		t.pausedUntil = until
+		pausedUntil.Set(until.Format(time.RFC3339))
+		metrics.Add("pauses", 1)
+		t.log.Warn("pausing ESI requests until error limit resets",
+			zap.String("url", req.URL.String()),
+			zap.Int("status", resp.StatusCode),
+			zap.Int("error_limit_remain", remain),
+			zap.Duration("error_limit_reset", reset),
+		)
+	}
+}
+
+// retryable returns true for idempotent requests that failed on network error
+// or temporary ESI error.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case statusErrorLimited, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns exponential delay for given attempt with random jitter
+// in the upper half.
+func backoff(attempt int) time.Duration {
+	delay := baseBackoff << uint(attempt)
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
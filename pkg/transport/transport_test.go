@@ -0,0 +1,238 @@
+package transport
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeClock advances when the transport sleeps, so backoffs and pauses are
+// recorded instead of waited for.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+// esiStandIn serves responses in order, the last one repeats.
+func esiStandIn(t *testing.T, responses ...func(w http.ResponseWriter)) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&requests, 1)) - 1
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		responses[i](w)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func status(code int, headers ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(http.StatusText(code)))
+	}
+}
+
+func testClient(clock *fakeClock) *http.Client {
+	transport := New(zap.NewNop(), http.DefaultTransport)
+	transport.now = clock.Now
+	transport.sleep = clock.Sleep
+	return &http.Client{Transport: transport}
+}
+
+func TestRoundTripRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		responses    []func(w http.ResponseWriter)
+		wantStatus   int
+		wantRequests int32
+	}{
+		{
+			name:         "success",
+			method:       http.MethodGet,
+			responses:    []func(w http.ResponseWriter){status(http.StatusOK)},
+			wantStatus:   http.StatusOK,
+			wantRequests: 1,
+		},
+		{
+			name:         "temporary errors are retried",
+			method:       http.MethodGet,
+			responses:    []func(w http.ResponseWriter){status(http.StatusBadGateway), status(http.StatusServiceUnavailable), status(http.StatusOK)},
+			wantStatus:   http.StatusOK,
+			wantRequests: 3,
+		},
+		{
+			name:         "retries give up",
+			method:       http.MethodGet,
+			responses:    []func(w http.ResponseWriter){status(http.StatusGatewayTimeout)},
+			wantStatus:   http.StatusGatewayTimeout,
+			wantRequests: maxRetries + 1,
+		},
+		{
+			name:         "client errors are not retried",
+			method:       http.MethodGet,
+			responses:    []func(w http.ResponseWriter){status(http.StatusForbidden)},
+			wantStatus:   http.StatusForbidden,
+			wantRequests: 1,
+		},
+		{
+			name:         "post is not retried",
+			method:       http.MethodPost,
+			responses:    []func(w http.ResponseWriter){status(http.StatusServiceUnavailable)},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantRequests: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := esiStandIn(t, test.responses...)
+			clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+			req, err := http.NewRequest(test.method, server.URL, strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := testClient(clock).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != test.wantStatus || string(body) != http.StatusText(test.wantStatus) {
+				t.Errorf("expected %d %q, got %d %q", test.wantStatus, http.StatusText(test.wantStatus), resp.StatusCode, body)
+			}
+			if got := atomic.LoadInt32(requests); got != test.wantRequests {
+				t.Errorf("expected %d requests, got %d", test.wantRequests, got)
+			}
+			if len(clock.sleeps) != int(test.wantRequests)-1 {
+				t.Errorf("expected backoff before each retry, got: %v", clock.sleeps)
+			}
+			for i, delay := range clock.sleeps {
+				if max := baseBackoff << uint(i); delay < max/2 || delay > max {
+					t.Errorf("expected backoff %d between %s and %s, got %s", i, max/2, max, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTripErrorLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []func(w http.ResponseWriter)
+		wantSleep time.Duration
+	}{
+		{
+			name: "error limit reached",
+			responses: []func(w http.ResponseWriter){
+				status(statusErrorLimited, errorLimitRemainHeader, "0", errorLimitResetHeader, "42"),
+				status(http.StatusOK, errorLimitRemainHeader, "100", errorLimitResetHeader, "41"),
+			},
+			wantSleep: 42 * time.Second,
+		},
+		{
+			name: "error limit reached without headers",
+			responses: []func(w http.ResponseWriter){
+				status(statusErrorLimited),
+				status(http.StatusOK),
+			},
+			wantSleep: defaultErrorLimitReset,
+		},
+		{
+			name: "few errors remaining",
+			responses: []func(w http.ResponseWriter){
+				status(http.StatusOK, errorLimitRemainHeader, "5", errorLimitResetHeader, "12"),
+				status(http.StatusOK, errorLimitRemainHeader, "100", errorLimitResetHeader, "60"),
+			},
+			wantSleep: 12 * time.Second,
+		},
+		{
+			name: "enough errors remaining",
+			responses: []func(w http.ResponseWriter){
+				status(http.StatusOK, errorLimitRemainHeader, "50", errorLimitResetHeader, "12"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, _ := esiStandIn(t, test.responses...)
+			clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+			client := testClient(clock)
+
+			// Second request waits until the error limit window resets.
+			for i := 0; i < 2; i++ {
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK && i > 0 {
+					t.Errorf("expected success after the pause, got %d", resp.StatusCode)
+				}
+			}
+
+			// Retry of 420 backs off first and waits only for the rest.
+			var paused time.Duration
+			for _, d := range clock.sleeps {
+				paused += d
+			}
+			if test.wantSleep == 0 && len(clock.sleeps) > 0 {
+				t.Errorf("expected no pause, got: %v", clock.sleeps)
+			}
+			if test.wantSleep > 0 && paused != test.wantSleep {
+				t.Errorf("expected pause of %s, got: %v", test.wantSleep, clock.sleeps)
+			}
+		})
+	}
+}
+
+func TestRoundTripTimeout(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			// The first attempt hangs until it is cancelled.
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	client := testClient(clock)
+	client.Transport.(*Transport).timeout = 50 * time.Millisecond
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("expected hanging attempt to be retried, got %d after %d requests", resp.StatusCode, atomic.LoadInt32(&requests))
+	}
+}